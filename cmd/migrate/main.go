@@ -5,15 +5,18 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/aktagon/news-writer/pkg/newswriter"
 )
 
 func main() {
 	if len(os.Args) < 3 {
-		log.Fatal("Usage: migrate <add-hashes|remove-duplicates> <articles-directory>")
+		log.Fatal("Usage: migrate <add-hashes|remove-duplicates|find-stale-prompts> <articles-directory>")
 	}
 
 	command := os.Args[1]
@@ -28,6 +31,10 @@ func main() {
 		if err := removeDuplicates(articlesDir); err != nil {
 			log.Fatal(err)
 		}
+	case "find-stale-prompts":
+		if err := findStalePrompts(articlesDir); err != nil {
+			log.Fatal(err)
+		}
 	default:
 		log.Fatalf("Unknown command %q", command)
 	}
@@ -79,7 +86,12 @@ func processFile(filePath string) error {
 }
 
 func extractSourceURL(content string) string {
-	re := regexp.MustCompile(`source_url:\s*"([^"]*)"`)
+	return extractField(content, "source_url")
+}
+
+// extractField returns the quoted value of a frontmatter field, or "" when absent
+func extractField(content, key string) string {
+	re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:\s*"([^"]*)"`)
 	matches := re.FindStringSubmatch(content)
 	if len(matches) >= 2 {
 		return matches[1]
@@ -87,6 +99,75 @@ func extractSourceURL(content string) string {
 	return ""
 }
 
+// findStalePrompts prints the articles whose prompt_version is missing or differs from
+// the version the current settings and prompts would stamp, one path per line
+func findStalePrompts(articlesDir string) error {
+	config, err := newswriter.LoadConfig(nil)
+	if err != nil {
+		return err
+	}
+
+	stale := 0
+	if err := filepath.WalkDir(articlesDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return nil // Continue on errors
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Error reading %s: %v", path, err)
+			return nil
+		}
+		sourceURL := extractSourceURL(string(content))
+		if sourceURL == "" {
+			return nil
+		}
+
+		var domain string
+		if parsed, err := url.Parse(sourceURL); err == nil {
+			domain = parsed.Host
+		}
+		current, err := config.PromptVersion(domain, extractField(string(content), "planner_prompt_variant"), sourceKind(string(content), sourceURL))
+		if err != nil {
+			log.Printf("Warning: %s: %v", path, err)
+		}
+		if version := extractField(string(content), "prompt_version"); version == "" || version != current {
+			fmt.Println(path)
+			stale++
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("walking directory: %w", err)
+	}
+
+	log.Printf("Found %d articles generated with an older prompt version", stale)
+	return nil
+}
+
+// sourceKind returns the kind of source an article was written from, which selects its
+// templates_by_type writer prompt: its source_type, or a guess from the URL for articles
+// written before source_type was recorded
+func sourceKind(content, sourceURL string) newswriter.ContentKind {
+	if kind := extractField(content, "source_type"); kind != "" {
+		return newswriter.ContentKind(kind)
+	}
+	parsed, err := url.Parse(sourceURL)
+	if err != nil {
+		return newswriter.KindHTML
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	local := parsed.Scheme == "file" || filepath.IsAbs(sourceURL)
+	switch ext := strings.ToLower(filepath.Ext(parsed.Path)); {
+	case host == "youtube.com" || host == "youtu.be" || strings.HasSuffix(host, ".youtube.com"):
+		return newswriter.KindYouTube
+	case ext == ".pdf":
+		return newswriter.KindPDF
+	case local && (ext == ".md" || ext == ".markdown" || ext == ".txt"):
+		return newswriter.KindText
+	}
+	return newswriter.KindHTML
+}
+
 func generateURLHash(url string) string {
 	h := sha256.Sum256([]byte(url))
 	return fmt.Sprintf("%x", h)[:8]
@@ -1,28 +1,79 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/aktagon/news-writer/pkg/newswriter"
 	"github.com/spf13/cobra"
 )
 
 var (
 	rewriteMode      bool
+	force            bool
+	refresh          bool
+	absolutePaths    bool
 	configFile       string
 	apiKey           string
 	writerPromptPath string
 	templatePath     string
+	promptDir        string
 	debugMode        bool
+	urlFlags         []string
+	settingsPath     string
+	apiKeyFile       string
+	useKeyring       bool
+	noCache          bool
+	onlyCategories   []string
+	limitRate        int64
+	deterministic    bool
+	seed             uint64
+	seedSet          bool // --seed was given, even as 0
+	concurrency      int
+	dryRun           bool
+	debugDir         string
+	showDiff         bool
+	diffOnly         bool
+	preflight        bool
+	jsonlPath        string
+	reportPath       string
+	changedOnly      bool
+	deleteRemoved    bool
+	quietSkips       bool
+	pruneOlderThan   time.Duration
+	interactive      bool
+	previewLines     int
+	draft            bool
+	checkLinks       bool
+	frontmatterOnly  bool
 )
 
+// keyringService is the service name the API key is stored under in the OS keyring
+const keyringService = "news-writer"
+
 var rootCmd = &cobra.Command{
-	Use:   "news-writer [config-file]",
+	Use:   "news-writer [config-file | url]",
 	Short: "Minimal article distillation system using AI",
 	Long:  `A simplified tool for distilling web articles and PDFs using AI agents.`,
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		seedSet = cmd.Flags().Changed("seed")
+
+		// --url bypasses the config file, so a config argument with it would be ignored
+		if len(urlFlags) > 0 && len(args) > 0 {
+			log.Fatalf("--url cannot be combined with a config file or URL argument (%s)", args[0])
+		}
+
 		// Get config file path
 		if len(args) > 0 {
 			configFile = args[0]
@@ -31,31 +82,36 @@ var rootCmd = &cobra.Command{
 		}
 
 		// Get API key
-		if apiKey == "" {
-			apiKey = os.Getenv("ANTHROPIC_API_KEY")
-		}
-		if apiKey == "" {
-			log.Fatal("API key required: use --api-key flag or ANTHROPIC_API_KEY environment variable")
+		key, err := resolveAPIKey()
+		if err != nil {
+			log.Fatal(err)
 		}
+		apiKey = key
 
-		// Build config overrides
-		overrides := &ConfigOverrides{}
-		if writerPromptPath != "" {
-			overrides.WriterPromptPath = &writerPromptPath
-		}
-		if templatePath != "" {
-			overrides.TemplatePath = &templatePath
+		// Create processor with options from flags
+		opts := buildOptions()
+		if jsonlPath != "" {
+			out, err := openJSONL(jsonlPath)
+			if err != nil {
+				log.Fatalf("Failed to open JSON lines output: %v", err)
+			}
+			defer out.Close()
+			opts = append(opts, newswriter.WithJSONL(out))
 		}
-
-		// Create processor with config overrides
-		processor, err := NewArticleProcessor(apiKey, overrides)
+		processor, err := newswriter.NewArticleProcessor(apiKey, opts...)
 		if err != nil {
 			log.Fatalf("Failed to create processor: %v", err)
 		}
 
 		// Set debug mode globally
 		if debugMode {
-			SetDebugMode(true)
+			newswriter.SetDebugMode(true)
+		}
+
+		if preflight {
+			if err := processor.Preflight(context.Background()); err != nil {
+				log.Fatalf("Preflight failed:\n%v", err)
+			}
 		}
 
 		// Process URLs
@@ -64,6 +120,10 @@ var rootCmd = &cobra.Command{
 				log.Fatal("URL required for rewrite mode")
 			}
 			_, err = processor.ProcessURL(args[0], true)
+		} else if len(urlFlags) > 0 {
+			err = processor.ProcessURLs(urlFlags)
+		} else if isURL(configFile) {
+			err = processSingle(processor, configFile)
 		} else {
 			err = processor.ProcessURLsFromFile(configFile)
 		}
@@ -74,12 +134,448 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+var processCmd = &cobra.Command{
+	Use:   "process <file>",
+	Short: "Distill a local PDF, HTML, Markdown or text file into an article",
+	Long: `Distill a local file without a config or web server. The handler is chosen by
+extension (.pdf, .html, .md, .txt) and source_url records the file's absolute path.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			log.Fatal(err)
+		}
+
+		key, err := resolveAPIKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+		apiKey = key
+
+		processor, err := newswriter.NewArticleProcessor(apiKey, buildOptions()...)
+		if err != nil {
+			log.Fatalf("Failed to create processor: %v", err)
+		}
+		if debugMode {
+			newswriter.SetDebugMode(true)
+		}
+
+		if err := processSingle(processor, path); err != nil {
+			log.Fatalf("Processing failed: %v", err)
+		}
+	},
+}
+
+// processSingle processes one URL or local file, logging whether it was written or skipped
+func processSingle(processor *newswriter.ArticleProcessor, target string) error {
+	result := processor.ProcessURLResult(context.Background(), target, false)
+	if err := processor.WriteReport([]newswriter.ProcessingResult{result}); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	switch result.Status {
+	case newswriter.StatusSuccess:
+		log.Printf("✓ %s -> %s", target, result.Filename)
+	case newswriter.StatusSkipped:
+		log.Printf("→ Skipped %s: %v", target, result.Error)
+	default:
+		if errors.Is(result.Error, newswriter.ErrReviewQuit) {
+			log.Printf("→ Quit during review, not saved: %s", target)
+			return nil
+		}
+		return result.Error
+	}
+	return nil
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [config-file]",
+	Short: "Check settings, prompts, schema and URL config without calling any API",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		urlsFile := "articles.yaml"
+		if len(args) > 0 {
+			urlsFile = args[0]
+		}
+
+		var problems []error
+
+		config, err := newswriter.LoadConfig(buildOverrides())
+		if err != nil {
+			problems = append(problems, err)
+		} else {
+			problems = append(problems, config.Validate()...)
+		}
+
+		if _, err := newswriter.LoadURLs(urlsFile); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", urlsFile, err))
+		}
+
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				log.Printf("✗ %v", problem)
+			}
+			log.Fatalf("Validation failed: %d problem(s)", len(problems))
+		}
+
+		log.Printf("✓ Configuration is valid")
+	},
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <file>",
+	Short: "Publish a draft: move it from the draft directory to the output directory with draft: false",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := newswriter.LoadConfig(buildOverrides())
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+
+		target, err := config.ApproveDraft(args[0])
+		if err != nil {
+			log.Fatalf("Approve failed: %v", err)
+		}
+		log.Printf("✓ Approved: %s -> %s", args[0], target)
+	},
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the content cache",
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached entries with sizes and ages",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := newswriter.ListCache(cacheDirectory())
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, entry := range entries {
+			age := time.Since(entry.ModTime).Round(time.Minute)
+			fmt.Printf("%-10s %-20s %10d bytes  %s old\n", entry.Kind, entry.Key, entry.Size, age)
+		}
+	},
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache entry counts and total size",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := cacheDirectory()
+		entries, err := newswriter.ListCache(cacheDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		counts := make(map[string]int)
+		var total int64
+		for _, entry := range entries {
+			counts[entry.Kind]++
+			total += entry.Size
+		}
+
+		fmt.Printf("Cache directory: %s\n", cacheDir)
+		fmt.Printf("Entries: %d (%d bytes)\n", len(entries), total)
+		for kind, count := range counts {
+			fmt.Printf("  %s: %d\n", kind, count)
+		}
+		if len(entries) > 0 {
+			fmt.Printf("Oldest: %s\n", entries[0].ModTime.Format(time.RFC3339))
+			fmt.Printf("Newest: %s\n", entries[len(entries)-1].ModTime.Format(time.RFC3339))
+		}
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := cacheDirectory()
+		if err := newswriter.ClearCache(cacheDir); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("✓ Cleared %s", cacheDir)
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached entries older than --older-than",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := newswriter.PruneCache(cacheDirectory(), pruneOlderThan)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("✓ Pruned %d entries older than %s", removed, pruneOlderThan)
+	},
+}
+
+// cacheDirectory returns the configured cache directory, or the default when settings can't be loaded
+func cacheDirectory() string {
+	config, err := newswriter.LoadConfig(buildOverrides())
+	if err != nil {
+		log.Printf("Warning: %v; using %s", err, newswriter.DefaultCacheDirectory)
+		return newswriter.DefaultCacheDirectory
+	}
+	return config.Settings.CacheDirectory
+}
+
+// buildOptions maps command line flags to processor options
+func buildOptions() []newswriter.Option {
+	opts := []newswriter.Option{
+		newswriter.WithOverrides(buildOverrides()),
+		newswriter.WithConcurrency(concurrency),
+		newswriter.WithDryRun(dryRun),
+		newswriter.WithDebugDir(debugDir),
+		newswriter.WithManifest(true),
+		newswriter.WithChangedOnly(changedOnly, deleteRemoved),
+		newswriter.WithQuietSkips(quietSkips),
+		newswriter.WithReport(reportPath),
+		newswriter.WithForce(force),
+		newswriter.WithRefresh(refresh),
+		newswriter.WithAbsolutePaths(absolutePaths),
+		newswriter.WithDraft(draft),
+		newswriter.WithCheckLinks(checkLinks),
+		newswriter.WithFrontmatterOnly(frontmatterOnly),
+	}
+	if showDiff || diffOnly {
+		opts = append(opts, newswriter.WithDiff(os.Stdout, diffOnly))
+	}
+	if interactive {
+		opts = append(opts, newswriter.WithReview(reviewInTerminal(bufio.NewReader(os.Stdin), os.Stdout)))
+	}
+	return opts
+}
+
+// reviewInTerminal shows each generated article's title, deck, categories and first
+// lines, then asks whether to save, regenerate, edit it in $EDITOR or quit
+func reviewInTerminal(reader *bufio.Reader, out io.Writer) newswriter.ReviewFunc {
+	return func(article *newswriter.Article) (newswriter.ReviewDecision, error) {
+		for {
+			fmt.Fprintf(out, "\n  Title:      %s\n", article.Title)
+			fmt.Fprintf(out, "  Deck:       %s\n", article.Deck)
+			fmt.Fprintf(out, "  Categories: %s\n\n", strings.Join(article.Categories, ", "))
+			lines := strings.Split(strings.TrimSpace(article.Content), "\n")
+			for _, line := range lines[:min(previewLines, len(lines))] {
+				fmt.Fprintf(out, "  | %s\n", line)
+			}
+			if len(lines) > previewLines {
+				fmt.Fprintf(out, "  | ... (%d more lines)\n", len(lines)-previewLines)
+			}
+
+			fmt.Fprint(out, "\n  [s]ave, [r]egenerate, [e]dit, [q]uit? ")
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return newswriter.ReviewQuit, fmt.Errorf("reading input: %w", err)
+			}
+			switch strings.ToLower(strings.TrimSpace(input)) {
+			case "s", "save":
+				return newswriter.ReviewSave, nil
+			case "r", "regenerate":
+				return newswriter.ReviewRegenerate, nil
+			case "q", "quit":
+				return newswriter.ReviewQuit, nil
+			case "e", "edit":
+				if err := editInEditor(article); err != nil {
+					fmt.Fprintf(out, "  Edit failed: %v\n", err)
+				}
+			default:
+				fmt.Fprintln(out, "  Please enter s, r, e or q.")
+			}
+		}
+	}
+}
+
+// editInEditor opens the article body in $EDITOR (vi when unset) and reads back the result
+func editInEditor(article *newswriter.Article) error {
+	file, err := os.CreateTemp("", "news-writer-*.md")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(article.Content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", editor[0], err)
+	}
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return err
+	}
+	article.Content = string(content)
+	return nil
+}
+
+// buildOverrides collects config overrides from command line flags
+func buildOverrides() *newswriter.ConfigOverrides {
+	overrides := &newswriter.ConfigOverrides{}
+	if settingsPath != "" {
+		overrides.SettingsPath = &settingsPath
+	}
+	if writerPromptPath != "" {
+		overrides.WriterPromptPath = &writerPromptPath
+	}
+	if templatePath != "" {
+		overrides.TemplatePath = &templatePath
+	}
+	if promptDir != "" {
+		overrides.PromptDir = &promptDir
+	}
+	overrides.DisableCache = noCache
+	overrides.OnlyCategories = onlyCategories
+	overrides.MaxBytesPerSecond = limitRate
+	overrides.Deterministic = deterministic
+	if seedSet {
+		overrides.Seed = &seed
+	}
+	return overrides
+}
+
+// resolveAPIKey returns the API key using the precedence flag > key file > keyring > environment
+func resolveAPIKey() (string, error) {
+	if apiKey != "" {
+		return apiKey, nil
+	}
+
+	if apiKeyFile != "" {
+		data, err := os.ReadFile(apiKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("reading API key file: %w", err)
+		}
+		if key := strings.TrimSpace(string(data)); key != "" {
+			return key, nil
+		}
+		return "", fmt.Errorf("API key file %s is empty", apiKeyFile)
+	}
+
+	if useKeyring {
+		key, err := readKeyring()
+		if err != nil {
+			log.Printf("Warning: keyring lookup failed: %v", err)
+		} else if key != "" {
+			return key, nil
+		}
+	}
+
+	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+		return key, nil
+	}
+
+	return "", fmt.Errorf("API key required: use --api-key, --api-key-file, --keyring or the ANTHROPIC_API_KEY environment variable")
+}
+
+// readKeyring reads the API key from the OS keyring using the platform's CLI tool
+func readKeyring() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", "anthropic", "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", "anthropic")
+	default:
+		return "", fmt.Errorf("keyring not supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// openJSONL opens the --jsonl destination; "-" means stdout
+func openJSONL(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// isURL reports whether arg is an http(s) URL rather than a config file path
+func isURL(arg string) bool {
+	return strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://")
+}
+
 func init() {
 	rootCmd.Flags().StringVar(&apiKey, "api-key", "", "Anthropic API key")
-	rootCmd.Flags().BoolVar(&rewriteMode, "rewrite", false, "Rewrite a specific URL")
-	rootCmd.Flags().StringVar(&writerPromptPath, "writer-prompt", "", "Path to custom writer prompt file")
-	rootCmd.Flags().StringVar(&templatePath, "template", "", "Path to custom article template file")
+	rootCmd.Flags().StringVar(&apiKeyFile, "api-key-file", "", "Read the Anthropic API key from a file")
+	rootCmd.Flags().BoolVar(&useKeyring, "keyring", false, "Read the Anthropic API key from the OS keyring")
+	rootCmd.Flags().BoolVar(&rewriteMode, "rewrite", false, "Rewrite a specific URL, fetching its source in full")
+	rootCmd.Flags().BoolVar(&refresh, "refresh", false, "Regenerate existing articles whose source changed since the last fetch (ETag/Last-Modified)")
+	rootCmd.Flags().BoolVar(&absolutePaths, "absolute-paths", false, "Log, return and record article paths as absolute paths")
+	rootCmd.Flags().BoolVar(&force, "force", false, "Regenerate every URL, including existing articles, with a full fetch; overrides --changed-only")
+	rootCmd.Flags().BoolVar(&showDiff, "diff", false, "With --rewrite, print a unified diff of the existing and rewritten article")
+	rootCmd.Flags().BoolVar(&diffOnly, "diff-only", false, "With --rewrite, print the diff without writing the article")
+	rootCmd.PersistentFlags().StringVar(&writerPromptPath, "writer-prompt", "", "Path to custom writer prompt file")
+	rootCmd.PersistentFlags().StringVar(&templatePath, "template", "", "Path to custom article template file")
+	rootCmd.PersistentFlags().StringVar(&promptDir, "prompt-dir", "", "Directory of prompt files named like the defaults (writer-system-prompt.md, ...), re-read for each article")
 	rootCmd.Flags().BoolVar(&debugMode, "debug", false, "Enable debug logging")
+	rootCmd.Flags().StringVar(&debugDir, "debug-dir", "", "Write each URL's source, prompts and raw responses to this directory")
+	rootCmd.PersistentFlags().StringVar(&settingsPath, "settings", "", "Path to settings file (default: nearest .news-writer/settings.yaml)")
+	rootCmd.Flags().BoolVar(&preflight, "preflight", false, "Verify the Anthropic and YouTube transcript API keys before processing")
+	rootCmd.Flags().StringVar(&reportPath, "report", "", "Write an HTML report of the run (status, title, words, duration and cost per URL) to this file")
+	rootCmd.Flags().StringVar(&jsonlPath, "jsonl", "", "Write one JSON object per processed URL to this file, or - for stdout")
+	rootCmd.Flags().BoolVar(&quietSkips, "quiet-skips", false, "Don't log each skipped URL (existing article, noindex, ...); the summary still counts them")
+	rootCmd.Flags().BoolVar(&changedOnly, "changed-only", false, "Process only URLs not yet processed or skipped according to .news-writer/manifest.json")
+	rootCmd.Flags().BoolVar(&deleteRemoved, "delete-removed", false, "With --changed-only, delete articles whose URL was removed from the config")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Ignore cached content and fetch fresh (fresh results are still cached)")
+	rootCmd.Flags().Int64Var(&limitRate, "limit-rate", 0, "Cap download bandwidth in bytes per second, shared by concurrent fetches (overrides max_bytes_per_second)")
+	rootCmd.Flags().BoolVar(&deterministic, "deterministic", false, "Reproducible run: temperature 0, one URL at a time, seeded random choices")
+	rootCmd.Flags().Uint64Var(&seed, "seed", 0, "Seed for random choices in deterministic mode (overrides seed in settings)")
+	rootCmd.Flags().StringSliceVar(&onlyCategories, "only-categories", nil, "Only write articles whose planned categories match one of these (comma-separated or repeatable)")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of URLs to process in parallel")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Fetch and plan only; report filenames without writing articles")
+	rootCmd.Flags().BoolVar(&interactive, "interactive", false, "Review each generated article before saving: save, regenerate, edit in $EDITOR or quit (one URL at a time)")
+	rootCmd.Flags().IntVar(&previewLines, "preview-lines", 20, "With --interactive, the number of article lines to show")
+	rootCmd.Flags().BoolVar(&frontmatterOnly, "frontmatter-only", false, "Re-plan existing articles and rewrite only their frontmatter, keeping the body (no writer call); URLs without an article are skipped")
+	rootCmd.Flags().BoolVar(&checkLinks, "check-links", false, "After saving, check each article's outbound links with HEAD requests and report broken ones")
+	rootCmd.Flags().BoolVar(&draft, "draft", false, "Write articles with draft: true to the draft directory for review (see approve)")
+	rootCmd.Flags().StringArrayVar(&urlFlags, "url", nil, "URL to process directly (repeatable, bypasses config file, cannot be combined with a config file argument)")
+
+	cachePruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 30*24*time.Hour, "Remove entries older than this age")
+	cacheCmd.AddCommand(cacheListCmd, cacheStatsCmd, cacheClearCmd, cachePruneCmd)
+
+	processCmd.Flags().StringVar(&apiKey, "api-key", "", "Anthropic API key")
+	processCmd.Flags().StringVar(&apiKeyFile, "api-key-file", "", "Read the Anthropic API key from a file")
+	processCmd.Flags().BoolVar(&useKeyring, "keyring", false, "Read the Anthropic API key from the OS keyring")
+	processCmd.Flags().BoolVar(&force, "force", false, "Regenerate the article if it already exists")
+	processCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Plan only; report the filename without writing the article")
+	processCmd.Flags().BoolVar(&absolutePaths, "absolute-paths", false, "Log and return the article path as an absolute path")
+	processCmd.Flags().BoolVar(&debugMode, "debug", false, "Enable debug logging")
+	processCmd.Flags().BoolVar(&interactive, "interactive", false, "Review the generated article before saving: save, regenerate, edit in $EDITOR or quit")
+	processCmd.Flags().IntVar(&previewLines, "preview-lines", 20, "With --interactive, the number of article lines to show")
+	processCmd.Flags().BoolVar(&frontmatterOnly, "frontmatter-only", false, "Re-plan the existing article and rewrite only its frontmatter, keeping the body (no writer call)")
+	processCmd.Flags().BoolVar(&checkLinks, "check-links", false, "After saving, check the article's outbound links with HEAD requests and report broken ones")
+	processCmd.Flags().BoolVar(&draft, "draft", false, "Write the article with draft: true to the draft directory for review (see approve)")
+	processCmd.Flags().StringVar(&debugDir, "debug-dir", "", "Write the source, prompts and raw responses to this directory")
+
+	rootCmd.AddCommand(processCmd, approveCmd, validateCmd, cacheCmd)
 }
 
 func main() {
@@ -0,0 +1,757 @@
+package newswriter
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aktagon/llmkit/anthropic"
+	"github.com/aktagon/llmkit/anthropic/agents"
+	"github.com/aktagon/llmkit/anthropic/types"
+	llmerrors "github.com/aktagon/llmkit/errors"
+)
+
+// Target represents the target audience and tone for the article
+type Target struct {
+	Tone     string `json:"tone"`
+	Audience string `json:"audience"`
+}
+
+// FrontmatterMetadata represents the metadata extracted by the planner agent
+type FrontmatterMetadata struct {
+	Title           string   `json:"title"`
+	Categories      []string `json:"categories"`
+	Tags            []string `json:"tags"`
+	Deck            string   `json:"deck"`
+	MetaDescription string   `json:"meta_description,omitempty"` // Optional SEO field
+	Keywords        []string `json:"keywords,omitempty"`         // Optional SEO field
+	Target          Target   `json:"target"`
+
+	PromptVariant string `json:"-" xml:"-"` // Planner prompt variant used, if any
+}
+
+// maxMetaDescriptionChars is the SEO meta description limit
+const maxMetaDescriptionChars = 160
+
+// Prompter sends a prompt to the model and returns its response
+type Prompter interface {
+	Prompt(ctx context.Context, systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error)
+}
+
+// anthropicPrompter is the default Prompter, calling the Anthropic API through llmkit,
+// or through client when agents.base_url is set
+type anthropicPrompter struct {
+	client *http.Client
+}
+
+func (p anthropicPrompter) Prompt(ctx context.Context, systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error) {
+	if p.client != nil {
+		return promptWithClient(ctx, p.client, systemPrompt, userPrompt, schema, apiKey, settings, files...)
+	}
+	return promptWithContext(ctx, systemPrompt, userPrompt, schema, apiKey, settings, files...)
+}
+
+// AgentManager handles AI agent creation and management
+type AgentManager struct {
+	writerAgent  *agents.ChatAgent
+	plannerAgent *agents.ChatAgent
+	config       *Config
+	apiKey       string
+	prompter     Prompter
+	client       *http.Client // Sends Anthropic requests to agents.base_url; nil for the default endpoint
+
+	mu           sync.Mutex
+	apiKeys      []string // apiKey followed by Settings.Agents.APIKeys
+	keyIndex     int
+	variantIndex int        // Next planner prompt variant for the round-robin strategy
+	rng          *rand.Rand // Seeded with Settings.Seed in deterministic mode; created on first use
+}
+
+// NewAgentManager creates a new AgentManager with writer and planner agents
+func NewAgentManager(apiKey string, config *Config) (*AgentManager, error) {
+	writerAgent, err := agents.New(apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating writer agent: %w", err)
+	}
+
+	plannerAgent, err := agents.New(apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating planner agent: %w", err)
+	}
+
+	var client *http.Client
+	if baseURL := config.Settings.Agents.BaseURL; baseURL != "" {
+		client, err = newBaseURLClient(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("setting Anthropic base URL: %w", err)
+		}
+		log.Printf("→ Using Anthropic base URL %s", strings.TrimSpace(baseURL))
+	}
+
+	// Collect unique keys, primary key first
+	apiKeys := []string{apiKey}
+	for _, key := range config.Settings.Agents.APIKeys {
+		key = strings.TrimSpace(key)
+		if key != "" && !containsString(apiKeys, key) {
+			apiKeys = append(apiKeys, key)
+		}
+	}
+
+	return &AgentManager{
+		writerAgent:  writerAgent,
+		plannerAgent: plannerAgent,
+		config:       config,
+		apiKey:       apiKey,
+		prompter:     anthropicPrompter{client: client},
+		client:       client,
+		apiKeys:      apiKeys,
+	}, nil
+}
+
+// currentKey returns the API key currently in use
+func (am *AgentManager) currentKey() string {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if len(am.apiKeys) == 0 {
+		return am.apiKey
+	}
+	return am.apiKeys[am.keyIndex]
+}
+
+// rotateKey advances to the next API key if failedKey is still the current one
+func (am *AgentManager) rotateKey(ctx context.Context, failedKey string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if len(am.apiKeys) > 1 && am.apiKeys[am.keyIndex] == failedKey {
+		am.keyIndex = (am.keyIndex + 1) % len(am.apiKeys)
+		logf(ctx, "→ API key rate limited, rotating to key %d of %d", am.keyIndex+1, len(am.apiKeys))
+	}
+}
+
+// prompt sends a request, failing over to the next API key when one is rate limited
+func (am *AgentManager) prompt(ctx context.Context, systemPrompt, userPrompt, schema string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error) {
+	attempts := len(am.apiKeys)
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		key := am.currentKey()
+		var response *types.AnthropicResponse
+		response, err = am.prompter.Prompt(ctx, systemPrompt, userPrompt, schema, key, settings, files...)
+		if recorder := debugRecorderFrom(ctx); recorder != nil {
+			recorder.recordPrompt(systemPrompt, userPrompt, schema, key, settings, files, response, err)
+		}
+		if meter := usageMeterFrom(ctx); meter != nil && response != nil {
+			meter.add(response.Usage, am.modelPrice(settings.Model))
+		}
+		if err == nil || !isRateLimitError(err) {
+			return response, err
+		}
+		am.rotateKey(ctx, key)
+	}
+	return nil, err
+}
+
+// ErrEmptyResponse is returned when the writer responds without content, after any
+// retries configured with agents.writer.empty_retries
+var ErrEmptyResponse = errors.New("no content in response")
+
+// emptyRetryDelay is the backoff before the first empty-response retry; it doubles per retry
+var emptyRetryDelay = 2 * time.Second
+
+// logResponse logs the model, token usage and stop reason of an agent response in debug
+// mode, so truncated (stop_reason=max_tokens) or unexpectedly expensive calls stand out
+func logResponse(ctx context.Context, agent string, response *types.AnthropicResponse) {
+	if response == nil {
+		return
+	}
+	usage := response.Usage
+	debugf(ctx, "%s response: model=%s input_tokens=%d cache_write_tokens=%d cache_read_tokens=%d output_tokens=%d stop_reason=%s",
+		agent, response.Model, usage.InputTokens, usage.CacheCreationInputTokens, usage.CacheReadInputTokens, usage.OutputTokens, response.StopReason)
+}
+
+// modelPrice returns the configured price of model, or nil when it has none
+func (am *AgentManager) modelPrice(model string) *ModelPrice {
+	if price, ok := am.config.Settings.Pricing[model]; ok {
+		return &price
+	}
+	return nil
+}
+
+// isRateLimitError reports whether err is an Anthropic 429 (rate limit or quota) response
+func isRateLimitError(err error) bool {
+	var apiErr *llmerrors.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type writerNotesKey struct{}
+
+// withWriterNotes attaches an item's notes to ctx for the writer user prompt
+func withWriterNotes(ctx context.Context, notes string) context.Context {
+	return context.WithValue(ctx, writerNotesKey{}, notes)
+}
+
+// writerNotesFrom returns the notes set with withWriterNotes, or ""
+func writerNotesFrom(ctx context.Context) string {
+	notes, _ := ctx.Value(writerNotesKey{}).(string)
+	return notes
+}
+
+// notesEscaper escapes markup in notes while keeping their line breaks readable
+var notesEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// formatWriterNotes delimits notes for the user prompt. They are escaped so they
+// can't close the element and pose as the plan or source content.
+func formatWriterNotes(notes string) string {
+	return fmt.Sprintf(`Editor notes for this article (guidance from the editor, not part of the source):
+<editor_notes>
+%s
+</editor_notes>`, notesEscaper.Replace(strings.TrimSpace(notes)))
+}
+
+// Write generates article content using the writer agent
+func (am *AgentManager) Write(ctx context.Context, content *ContentResult, plan *FrontmatterMetadata) (string, error) {
+	logf(ctx, "→ Writing...")
+	systemPrompt, err := am.config.writerSystemPrompt(sourceOverrideFrom(ctx), content.Kind)
+	if err != nil {
+		return "", err
+	}
+	userPromptTemplate := am.config.GetWriterUserPrompt()
+
+	// Validate that template contains required variables
+	if !strings.Contains(userPromptTemplate, "{{.Plan}}") {
+		return "", fmt.Errorf("writer user prompt template must contain {{.Plan}} variable")
+	}
+
+	// Convert plan metadata to XML
+	planXML, err := xml.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal plan to XML: %w", err)
+	}
+
+	// Replace template variables
+	userPrompt := strings.ReplaceAll(userPromptTemplate, "{{.Plan}}", string(planXML))
+
+	if notes := writerNotesFrom(ctx); strings.TrimSpace(notes) != "" {
+		userPrompt += "\n\n" + formatWriterNotes(notes)
+	}
+
+	// For text content, add it to the user prompt
+	if content.Text != "" {
+		userPrompt = fmt.Sprintf(`%s
+
+Source content:
+%s`, userPrompt, content.Text)
+	}
+
+	var files []types.File
+	if content.FileID != "" {
+		files = append(files, types.File{ID: content.FileID})
+	}
+
+	settings := types.RequestSettings{
+		Model:       am.config.Settings.Agents.Writer.Model,
+		MaxTokens:   am.writerMaxTokens(ctx, content),
+		Temperature: am.writerTemperature(ctx, plan),
+		// TopK:        0,
+		// TopP:        0.0,
+	}
+	retries := am.config.Settings.Agents.Writer.EmptyRetries
+	for attempt := 0; ; attempt++ {
+		response, err := am.prompt(ctx, systemPrompt, userPrompt, "", settings, files...)
+		if err != nil {
+			return "", fmt.Errorf("writer agent failed: %w", err)
+		}
+		logResponse(ctx, "Writer", response)
+
+		if !isEmptyResponse(response) {
+			logf(ctx, "✓ Writing completed")
+			return response.Content[0].Text, nil
+		}
+		if attempt == retries {
+			if retries == 0 {
+				return "", ErrEmptyResponse
+			}
+			return "", fmt.Errorf("%w after %d retries", ErrEmptyResponse, retries)
+		}
+
+		delay := time.Duration(1<<attempt) * emptyRetryDelay
+		logf(ctx, "→ Writer returned no content, retrying in %s (%d/%d)", delay, attempt+1, retries)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isEmptyResponse reports whether a writer response has no text to use
+func isEmptyResponse(response *types.AnthropicResponse) bool {
+	return response == nil || len(response.Content) == 0 || strings.TrimSpace(response.Content[0].Text) == ""
+}
+
+// writerMaxTokens returns the writer token budget: summary_max_tokens in summary mode,
+// otherwise with min_tokens set the estimated source length clamped to [min_tokens,
+// max_tokens]; uploaded files, whose length is unknown, get max_tokens.
+func (am *AgentManager) writerMaxTokens(ctx context.Context, content *ContentResult) int {
+	writer := am.config.Settings.Agents.Writer
+	if am.config.summaryMode() {
+		if writer.SummaryMaxTokens > 0 {
+			return writer.SummaryMaxTokens
+		}
+		return defaultSummaryMaxTokens
+	}
+	if writer.MinTokens <= 0 || content.FileID != "" {
+		return writer.MaxTokens
+	}
+
+	sourceTokens := len(content.Text) / 4 // Rough approximation: 4 chars ≈ 1 token
+	budget := min(max(sourceTokens, writer.MinTokens), writer.MaxTokens)
+	debugf(ctx, "Writer max_tokens %d for ~%d source tokens", budget, sourceTokens)
+	return budget
+}
+
+// writerTemperature returns the writer temperature for a plan: the temperature_by_category
+// entry for its primary category, that category's parent, or its tone, else the base temperature
+func (am *AgentManager) writerTemperature(ctx context.Context, plan *FrontmatterMetadata) float64 {
+	writer := am.config.Settings.Agents.Writer
+	if len(writer.TemperatureByCategory) == 0 {
+		return writer.Temperature
+	}
+
+	var keys []string
+	if len(plan.Categories) > 0 {
+		primary := plan.Categories[0]
+		keys = append(keys, primary)
+		if parent, _, found := strings.Cut(primary, "/"); found {
+			keys = append(keys, parent)
+		}
+	}
+	if plan.Target.Tone != "" {
+		keys = append(keys, plan.Target.Tone)
+	}
+
+	for _, key := range keys {
+		for configured, temperature := range writer.TemperatureByCategory {
+			if strings.EqualFold(configured, key) {
+				logf(ctx, "→ Writer temperature %.2f (%s)", temperature, configured)
+				return temperature
+			}
+		}
+	}
+
+	logf(ctx, "→ Writer temperature %.2f (base)", writer.Temperature)
+	return writer.Temperature
+}
+
+// Revise asks the writer agent to rewrite a draft article following instruction
+func (am *AgentManager) Revise(ctx context.Context, draft, instruction string) (string, error) {
+	logf(ctx, "→ Revising...")
+	userPrompt := fmt.Sprintf(`Revise the article below. %s
+Return only the revised article.
+
+Article:
+%s`, instruction, draft)
+
+	settings := types.RequestSettings{
+		Model:       am.config.Settings.Agents.Writer.Model,
+		MaxTokens:   am.config.Settings.Agents.Writer.MaxTokens,
+		Temperature: am.config.Settings.Agents.Writer.Temperature,
+	}
+	systemPrompt, err := am.config.writerSystemPrompt(sourceOverrideFrom(ctx), contentKindFrom(ctx))
+	if err != nil {
+		return "", err
+	}
+	response, err := am.prompt(ctx, systemPrompt, userPrompt, "", settings)
+	if err != nil {
+		return "", fmt.Errorf("writer agent failed: %w", err)
+	}
+	logResponse(ctx, "Writer (revision)", response)
+
+	if len(response.Content) == 0 {
+		return "", fmt.Errorf("no content in response")
+	}
+
+	logf(ctx, "✓ Revision completed")
+	return response.Content[0].Text, nil
+}
+
+// PlanMetadata generates frontmatter metadata using the planner agent with structured output
+func (am *AgentManager) PlanMetadata(ctx context.Context, url string, content *ContentResult) (*FrontmatterMetadata, error) {
+	logf(ctx, "→ Planning %s", url)
+	// Limit source content to configured token limit
+	limitedContent := limitContentTokens(content.Text, am.config.Settings.Agents.Planner.ContentMaxTokens, am.config.Settings.Agents.Planner.ContentSampling)
+
+	// Build categories list for the system prompt
+	categories := am.config.Settings.Categories
+	override := sourceOverrideFrom(ctx)
+	if override != nil && len(override.Categories) > 0 {
+		categories = override.Categories
+	}
+	categoriesList := strings.Join(categories, "\n- ")
+
+	// Get prompts and validate template variables
+	variant, systemPromptTemplate, err := am.plannerSystemPrompt(ctx, override)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(systemPromptTemplate, "{{.categories}}") {
+		return nil, fmt.Errorf("planner system prompt template must contain {{.categories}} variable")
+	}
+	systemPrompt := strings.ReplaceAll(systemPromptTemplate, "{{.categories}}", "- "+categoriesList)
+
+	userPromptTemplate := am.config.GetPlannerUserPrompt()
+	if !strings.Contains(userPromptTemplate, "{{.source_content}}") {
+		return nil, fmt.Errorf("planner user prompt template must contain {{.source_content}} variable")
+	}
+	userPrompt := strings.ReplaceAll(userPromptTemplate, "{{.source_content}}", limitedContent)
+
+	// Get schema for structured output, or describe it in the prompt for providers without it
+	schema := am.config.GetPlannerSchema()
+	structured := am.config.Settings.Agents.Planner.StructuredOutput
+	schemaLess := structured != nil && !*structured
+	if schemaLess {
+		systemPrompt += "\n\nRespond with only a JSON object that conforms to this JSON schema:\n\n```json\n" + schema + "\n```"
+		schema = ""
+	}
+
+	// Handle PDF files
+	var files []types.File
+	if content.FileID != "" {
+		files = append(files, types.File{ID: content.FileID})
+	}
+
+	// Use structured output with schema
+	settings := types.RequestSettings{
+		Model:       am.config.Settings.Agents.Planner.Model,
+		MaxTokens:   am.config.Settings.Agents.Planner.MaxTokens,
+		Temperature: am.config.Settings.Agents.Planner.Temperature,
+		TopK:        0,
+		TopP:        0.0,
+	}
+	response, err := am.prompt(ctx, systemPrompt, userPrompt, schema, settings, files...)
+	if err != nil {
+		return nil, fmt.Errorf("planner agent failed: %w", err)
+	}
+	logResponse(ctx, "Planner", response)
+
+	if len(response.Content) == 0 {
+		return nil, fmt.Errorf("no content in planner response")
+	}
+
+	metadata, parseErr := am.parsePlanResponse(response.Content[0].Text, schemaLess)
+	if parseErr != nil {
+		// One repair attempt, telling the planner what was wrong with its response
+		logf(ctx, "→ Planner response unusable (%v), retrying once", parseErr)
+		repairPrompt := userPrompt + "\n\nYour previous response could not be used: " + parseErr.Error() +
+			"\nReturn only valid JSON that matches the schema, with no other text."
+		response, err := am.prompt(ctx, systemPrompt, repairPrompt, schema, settings, files...)
+		if err != nil {
+			return nil, fmt.Errorf("planner agent failed: %w", err)
+		}
+		logResponse(ctx, "Planner (repair)", response)
+		if response == nil || len(response.Content) == 0 {
+			return nil, parseErr
+		}
+		if metadata, parseErr = am.parsePlanResponse(response.Content[0].Text, schemaLess); parseErr != nil {
+			return nil, parseErr
+		}
+	}
+
+	if err := am.enforceDeckLength(ctx, metadata); err != nil {
+		return nil, err
+	}
+	am.enforceTagCount(ctx, metadata, func(note string) (*FrontmatterMetadata, error) {
+		response, err := am.prompt(ctx, systemPrompt, userPrompt+"\n\n"+note, schema, settings, files...)
+		if err != nil {
+			return nil, fmt.Errorf("planner agent failed: %w", err)
+		}
+		logResponse(ctx, "Planner (tags)", response)
+		if response == nil || len(response.Content) == 0 {
+			return nil, fmt.Errorf("no content in planner response")
+		}
+		return am.parsePlanResponse(response.Content[0].Text, schemaLess)
+	})
+	metadata.PromptVariant = variant
+	if override != nil && override.Tone != "" {
+		metadata.Target.Tone = override.Tone
+	}
+	if utf8.RuneCountInString(metadata.MetaDescription) > maxMetaDescriptionChars {
+		metadata.MetaDescription = truncateAtWord(metadata.MetaDescription, maxMetaDescriptionChars)
+	}
+
+	logf(ctx, "✓ Planned: %s | Categories: %v | Tags: %v | Deck: %s", metadata.Title, metadata.Categories, metadata.Tags, metadata.Deck)
+	return metadata, nil
+}
+
+// parsePlan parses the planner response: strict JSON for structured output, otherwise
+// the first JSON object in the text, checked for the fields the schema requires
+func parsePlan(text string, schemaLess bool) (*FrontmatterMetadata, error) {
+	var metadata FrontmatterMetadata
+	if !schemaLess {
+		if err := json.Unmarshal([]byte(text), &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse planner structured response: %w", err)
+		}
+		return &metadata, nil
+	}
+
+	if err := extractJSONObject(text, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse planner response: %w", err)
+	}
+	if err := validatePlannedMetadata(&metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// parsePlanResponse parses the planner response and, with Settings.RequireCategories, rejects
+// plans without categories so they get the repair attempt too
+func (am *AgentManager) parsePlanResponse(text string, schemaLess bool) (*FrontmatterMetadata, error) {
+	metadata, err := parsePlan(text, schemaLess)
+	if err == nil && am.config.Settings.RequireCategories && len(metadata.Categories) == 0 {
+		return nil, fmt.Errorf("%w; choose at least one of the listed categories", ErrNoCategories)
+	}
+	return metadata, err
+}
+
+// extractJSONObject decodes the first JSON object in text into v, skipping any prose
+// or code fences around it
+func extractJSONObject(text string, v any) error {
+	for i := strings.IndexByte(text, '{'); i >= 0; {
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(text[i:])).Decode(&raw); err == nil {
+			return json.Unmarshal(raw, v)
+		}
+		next := strings.IndexByte(text[i+1:], '{')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return fmt.Errorf("no JSON object found in response")
+}
+
+// validatePlannedMetadata checks the fields the planner schema requires, for responses
+// not constrained by it
+func validatePlannedMetadata(metadata *FrontmatterMetadata) error {
+	var missing []string
+	if strings.TrimSpace(metadata.Title) == "" {
+		missing = append(missing, "title")
+	}
+	if strings.TrimSpace(metadata.Deck) == "" {
+		missing = append(missing, "deck")
+	}
+	if len(metadata.Categories) == 0 {
+		missing = append(missing, "categories")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("planner response is missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// plannerSystemPrompt returns the planner system prompt template and, when
+// prompt variants are configured, the name of the variant chosen by the strategy.
+// A source override's planner prompt replaces both.
+func (am *AgentManager) plannerSystemPrompt(ctx context.Context, override *SourceOverride) (string, string, error) {
+	planner := am.config.Settings.Agents.Planner
+	if len(planner.PromptVariants) == 0 || (override != nil && override.PlannerPromptPath != "") {
+		prompt, err := am.config.plannerSystemPrompt(override, "")
+		return "", prompt, err
+	}
+
+	var variant PromptVariant
+	switch planner.VariantStrategy {
+	case "round-robin":
+		am.mu.Lock()
+		variant = planner.PromptVariants[am.variantIndex%len(planner.PromptVariants)]
+		am.variantIndex++
+		am.mu.Unlock()
+	case "random":
+		variant = planner.PromptVariants[am.randIntN(len(planner.PromptVariants))]
+	default:
+		variant = planner.PromptVariants[0]
+	}
+
+	prompt, err := am.config.plannerSystemPrompt(nil, variant.Name)
+	if err != nil {
+		return "", "", err
+	}
+	debugf(ctx, "Using planner prompt variant %q", variant.Name)
+	return variant.Name, prompt, nil
+}
+
+// randIntN returns a random int in [0, n), drawn from a source seeded with
+// Settings.Seed in deterministic mode
+func (am *AgentManager) randIntN(n int) int {
+	if !am.config.Settings.Deterministic {
+		return rand.IntN(n)
+	}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if am.rng == nil {
+		seed := am.config.Settings.Seed
+		am.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+	return am.rng.IntN(n)
+}
+
+// enforceDeckLength applies Settings.MinDeckChars and Settings.MaxDeckChars to the planned deck
+func (am *AgentManager) enforceDeckLength(ctx context.Context, metadata *FrontmatterMetadata) error {
+	metadata.Deck = strings.TrimSpace(metadata.Deck)
+	length := utf8.RuneCountInString(metadata.Deck)
+
+	if minChars := am.config.Settings.MinDeckChars; minChars > 0 && length < minChars {
+		return fmt.Errorf("planner deck is %d characters, minimum is %d", length, minChars)
+	}
+
+	if maxChars := am.config.Settings.MaxDeckChars; maxChars > 0 && length > maxChars {
+		metadata.Deck = truncateAtWord(metadata.Deck, maxChars)
+		logf(ctx, "→ Trimmed deck from %d to %d characters", length, utf8.RuneCountInString(metadata.Deck))
+	}
+
+	return nil
+}
+
+// enforceTagCount keeps the planned tags within Settings.MinTags and Settings.MaxTags.
+// Too few tags are asked for once more with replan, keeping the rest of the plan; too
+// many are trimmed to the first MaxTags, which the planner lists most relevant first.
+func (am *AgentManager) enforceTagCount(ctx context.Context, metadata *FrontmatterMetadata, replan func(note string) (*FrontmatterMetadata, error)) {
+	minTags, maxTags := am.config.Settings.MinTags, am.config.Settings.MaxTags
+
+	if count := len(metadata.Tags); minTags > 0 && count < minTags {
+		logf(ctx, "→ Planner returned %d tags, minimum is %d; asking again", count, minTags)
+		note := fmt.Sprintf("Your previous response had %d tags; return the same JSON with at least %d tags, most relevant first.", count, minTags)
+		if retried, err := replan(note); err != nil {
+			logf(ctx, "Warning: tag re-prompt failed, keeping %d tags: %v", count, err)
+		} else if len(retried.Tags) > count {
+			metadata.Tags = retried.Tags
+			logf(ctx, "→ Tags increased from %d to %d", count, len(metadata.Tags))
+		}
+		if len(metadata.Tags) < minTags {
+			logf(ctx, "Warning: keeping %d tags, below the minimum of %d", len(metadata.Tags), minTags)
+		}
+	}
+
+	if count := len(metadata.Tags); maxTags > 0 && count > maxTags {
+		metadata.Tags = metadata.Tags[:maxTags]
+		logf(ctx, "→ Trimmed tags from %d to %d", count, maxTags)
+	}
+}
+
+// truncateAtWord shortens text to at most maxChars runes, cutting at a word boundary and adding an ellipsis
+func truncateAtWord(text string, maxChars int) string {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+
+	// Leave room for the ellipsis
+	cut := string(runes[:maxChars-1])
+	if !unicode.IsSpace(runes[maxChars-1]) {
+		// Drop the partial word at the end
+		if i := strings.LastIndexAny(cut, " \t\n"); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	cut = strings.TrimRight(cut, " ,;:.-–—")
+
+	return cut + "…"
+}
+
+// promptWithContext runs a prompt and returns early when ctx is done.
+// llmkit has no context support, so an abandoned request finishes in the background.
+func promptWithContext(ctx context.Context, systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error) {
+	type result struct {
+		response *types.AnthropicResponse
+		err      error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		response, err := anthropic.PromptWithSettings(systemPrompt, userPrompt, schema, apiKey, settings, files...)
+		done <- result{response, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.response, r.err
+	}
+}
+
+// uniformSpans is the number of evenly spaced spans "uniform" content sampling keeps
+const uniformSpans = 5
+
+// sampleGap marks the source text left out between sampled spans
+const sampleGap = "\n\n[...]\n\n"
+
+// limitContentTokens limits content to approximately N tokens (using 4 chars ≈ 1 token).
+// The sampling strategy picks what to keep of longer content: "head" (the default) keeps
+// the beginning, "head-tail" the beginning and the end, where conclusions usually are,
+// and "uniform" evenly spaced spans from start to end.
+func limitContentTokens(content string, maxTokens int, sampling string) string {
+	maxChars := maxTokens * 4 // Rough approximation: 4 chars ≈ 1 token
+	if len(content) <= maxChars {
+		return content
+	}
+
+	spans := 1
+	switch sampling {
+	case "head-tail":
+		spans = 2
+	case "uniform":
+		spans = uniformSpans
+	}
+	if spans == 1 {
+		return content[:runeStart(content, maxChars)] + "..."
+	}
+
+	// Spread the budget left after the gap markers over the spans, the first at the
+	// start and the last at the end of content
+	spanChars := (maxChars - (spans-1)*len(sampleGap)) / spans
+	stride := (len(content) - spanChars) / (spans - 1)
+	parts := make([]string, spans)
+	for i := range parts {
+		start := runeStart(content, i*stride)
+		end := runeStart(content, min(start+spanChars, len(content)))
+		parts[i] = content[start:end]
+	}
+	return strings.Join(parts, sampleGap)
+}
+
+// runeStart moves i back to the start of the UTF-8 sequence it falls in
+func runeStart(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// GetModelInfo returns the model information for both agents
+func (am *AgentManager) GetModelInfo() (plannerModel, writerModel string) {
+	return am.config.Settings.Agents.Planner.Model, am.config.Settings.Agents.Writer.Model
+}
@@ -1,4 +1,4 @@
-package main
+package newswriter
 
 import (
 	"encoding/xml"
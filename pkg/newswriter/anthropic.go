@@ -0,0 +1,195 @@
+package newswriter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+	llmerrors "github.com/aktagon/llmkit/errors"
+)
+
+// llmkit sends every Anthropic request to api.anthropic.com on a client it creates
+// itself, so with agents.base_url the messages and file upload calls are made here
+// instead, on a client whose transport redirects them. The requests match llmkit's.
+
+// anthropicHost is the host llmkit sends every Anthropic request to
+const anthropicHost = "api.anthropic.com"
+
+// baseURLTransport redirects Anthropic API requests to a configured base URL
+type baseURLTransport struct {
+	base   http.RoundTripper
+	target *url.URL
+}
+
+func (t *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != anthropicHost {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.URL.Path = strings.TrimSuffix(t.target.Path, "/") + req.URL.Path
+	req.Host = ""
+	return t.base.RoundTrip(req)
+}
+
+// newBaseURLClient returns a client that sends Anthropic API requests to baseURL
+func newBaseURLClient(baseURL string) (*http.Client, error) {
+	target, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil {
+		return nil, err
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: must be an absolute http(s) URL", baseURL)
+	}
+	return &http.Client{Transport: &baseURLTransport{base: http.DefaultTransport, target: target}}, nil
+}
+
+// promptWithClient sends a messages request like llmkit's PromptWithSettings, on client
+func promptWithClient(ctx context.Context, client *http.Client, systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error) {
+	if apiKey == "" {
+		return nil, &llmerrors.ValidationError{Field: "apiKey", Message: "API key is required"}
+	}
+	if schema != "" {
+		userPrompt = fmt.Sprintf("You must output only the raw JSON without further explanation or formatting. %s\n\nUse the following JSON schema for the output format:\n\n%s", userPrompt, schema)
+	}
+
+	var content interface{} = userPrompt
+	if len(files) > 0 {
+		blocks := []types.Content{{Type: "text", Text: userPrompt}}
+		for _, file := range files {
+			blocks = append(blocks, types.Content{Type: "document", Source: &types.FileSource{Type: "file", FileID: file.ID}})
+		}
+		content = blocks
+	}
+
+	model := settings.Model
+	if model == "" {
+		model = types.Model
+	}
+	requestBody := map[string]interface{}{
+		"model":      model,
+		"messages":   []map[string]interface{}{{"role": "user", "content": content}},
+		"max_tokens": settings.MaxTokens,
+	}
+	if systemPrompt != "" {
+		requestBody["system"] = systemPrompt
+	}
+	if settings.Temperature > 0 {
+		requestBody["temperature"] = settings.Temperature
+	}
+	if settings.TopK > 0 {
+		requestBody["top_k"] = settings.TopK
+	}
+	if settings.TopP > 0 {
+		requestBody["top_p"] = settings.TopP
+	}
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("building request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, types.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	responseBody, err := sendAnthropic(client, req, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("calling Anthropic API: %w", err)
+	}
+
+	var response types.AnthropicResponse
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	return &response, nil
+}
+
+// uploadFileWithClient uploads a file to the Files API like llmkit's UploadFile, on client
+func uploadFileWithClient(client *http.Client, filePath, apiKey string) (*types.File, error) {
+	if apiKey == "" {
+		return nil, &llmerrors.ValidationError{Field: "apiKey", Message: "API key is required"}
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	mimeType := mime.TypeByExtension(filepath.Ext(filePath))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filepath.Base(filePath)))
+	header.Set("Content-Type", mimeType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, fmt.Errorf("creating form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("copying file data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing multipart writer: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, types.FilesEndpoint, &body)
+	if err != nil {
+		return nil, fmt.Errorf("creating upload request: %w", err)
+	}
+	req.Header.Set("content-type", writer.FormDataContentType())
+	responseBody, err := sendAnthropic(client, req, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploaded types.File
+	if err := json.Unmarshal(responseBody, &uploaded); err != nil {
+		return nil, fmt.Errorf("parsing upload response: %w", err)
+	}
+	return &uploaded, nil
+}
+
+// sendAnthropic sends req with the Anthropic API headers and returns the response body.
+// Non-200 responses are returned as llmkit's APIError, so rate limits are recognized.
+func sendAnthropic(client *http.Client, req *http.Request, apiKey string) ([]byte, error) {
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", types.AnthropicVersion)
+	req.Header.Set("anthropic-beta", types.FilesBetaHeader)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &llmerrors.APIError{
+			Provider:   "Anthropic",
+			StatusCode: resp.StatusCode,
+			Message:    string(body),
+			Endpoint:   req.URL.String(),
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,199 @@
+package newswriter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keyedMutex provides one mutex per key, e.g. per YouTube video ID
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock locks the mutex for key and returns its unlock function
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		k.locks[key] = lock
+	}
+	k.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// atomicTempInfix marks the temp files writeFileAtomic renames into place
+const atomicTempInfix = ".tmp-"
+
+// writeFileAtomic writes data to a temp file in the same directory and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+atomicTempInfix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// httpValidators are the response validators stored per URL for conditional requests
+type httpValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validatorsPath returns the sidecar file holding the validators for a URL, keyed by its normalized form
+func validatorsPath(cacheDir, url string) string {
+	sum := sha256.Sum256([]byte(normalizeURL(url)))
+	return filepath.Join(cacheDir, "http", hex.EncodeToString(sum[:16])+".json")
+}
+
+// loadValidators returns the stored validators for url, or nil when there are none
+func loadValidators(cacheDir, url string) *httpValidators {
+	data, err := os.ReadFile(validatorsPath(cacheDir, url))
+	if err != nil {
+		return nil
+	}
+	var v httpValidators
+	if err := json.Unmarshal(data, &v); err != nil || (v.ETag == "" && v.LastModified == "") {
+		return nil
+	}
+	return &v
+}
+
+// saveValidators stores the ETag and Last-Modified headers of a response, if it had any
+func saveValidators(cacheDir, url, etag, lastModified string) error {
+	v := httpValidators{ETag: etag, LastModified: lastModified}
+	if v.ETag == "" && v.LastModified == "" {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(validatorsPath(cacheDir, url), data)
+}
+
+// CacheEntry describes a single cached item (e.g. a YouTube transcript)
+type CacheEntry struct {
+	Kind    string // Subdirectory, e.g. "youtube"
+	Key     string // Cache key, e.g. the video ID
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// cacheKinds are the subdirectories of the cache directory this package writes to.
+// The cache directory is user-configurable, so listing and clearing stay inside them.
+var cacheKinds = []string{"youtube", "http"}
+
+// ListCache returns all entries in the cache directory, oldest first
+func ListCache(cacheDir string) ([]CacheEntry, error) {
+	var entries []CacheEntry
+
+	for _, kind := range cacheKinds {
+		root := filepath.Join(cacheDir, kind)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					return filepath.SkipDir // No cache yet
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if name := d.Name(); strings.HasPrefix(name, ".") && strings.Contains(name, atomicTempInfix) {
+				return nil // A write in progress, or left by an interrupted one
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			rel, _ := filepath.Rel(cacheDir, path)
+			entries = append(entries, CacheEntry{
+				Kind:    filepath.Dir(rel),
+				Key:     d.Name(),
+				Path:    path,
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading cache directory %s: %w", cacheDir, err)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.Before(entries[j].ModTime)
+	})
+
+	return entries, nil
+}
+
+// ClearCache removes the cache subdirectories this package owns, then the cache
+// directory itself if that leaves it empty. Other files in it are left alone.
+func ClearCache(cacheDir string) error {
+	for _, kind := range cacheKinds {
+		if err := os.RemoveAll(filepath.Join(cacheDir, kind)); err != nil {
+			return fmt.Errorf("clearing cache directory %s: %w", cacheDir, err)
+		}
+	}
+	os.Remove(cacheDir) // Fails, and is kept, when it holds anything else
+	return nil
+}
+
+// PruneCache removes entries older than maxAge and returns how many were removed
+func PruneCache(cacheDir string, maxAge time.Duration) (int, error) {
+	entries, err := ListCache(cacheDir)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if entry.ModTime.Before(cutoff) {
+			if err := os.Remove(entry.Path); err != nil {
+				return removed, fmt.Errorf("removing %s: %w", entry.Path, err)
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,82 @@
+package newswriter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheListAndPrune(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), ".cache")
+
+	// Missing cache directory is an empty cache
+	entries, err := ListCache(cacheDir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("ListCache() on missing dir = %v, %v", entries, err)
+	}
+
+	youtubeDir := filepath.Join(cacheDir, "youtube")
+	os.MkdirAll(youtubeDir, 0755)
+	oldEntry := filepath.Join(youtubeDir, "old-video")
+	newEntry := filepath.Join(youtubeDir, "new-video")
+	os.WriteFile(oldEntry, []byte("old"), 0644)
+	os.WriteFile(newEntry, []byte("newer"), 0644)
+	past := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(oldEntry, past, past)
+	os.WriteFile(filepath.Join(youtubeDir, ".new-video.tmp-123"), []byte("partial"), 0644)
+
+	entries, err = ListCache(cacheDir)
+	if err != nil {
+		t.Fatalf("ListCache() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ListCache() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Key != "old-video" || entries[0].Kind != "youtube" || entries[0].Size != 3 {
+		t.Errorf("ListCache()[0] = %+v, want oldest youtube entry first", entries[0])
+	}
+
+	removed, err := PruneCache(cacheDir, 24*time.Hour)
+	if err != nil || removed != 1 {
+		t.Errorf("PruneCache() = %d, %v, want 1 removed", removed, err)
+	}
+	if _, err := os.Stat(newEntry); err != nil {
+		t.Error("PruneCache() removed a fresh entry")
+	}
+
+	if err := ClearCache(cacheDir); err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Error("ClearCache() left the cache directory behind")
+	}
+}
+
+func TestClearCacheKeepsForeignFiles(t *testing.T) {
+	// A cache_directory pointing at a project root must not wipe the project
+	cacheDir := t.TempDir()
+	userFile := filepath.Join(cacheDir, "notes.md")
+	os.WriteFile(userFile, []byte("mine"), 0644)
+	os.MkdirAll(filepath.Join(cacheDir, "articles"), 0755)
+	os.WriteFile(filepath.Join(cacheDir, "articles", "post.md"), []byte("mine"), 0644)
+	os.MkdirAll(filepath.Join(cacheDir, "youtube"), 0755)
+	os.WriteFile(filepath.Join(cacheDir, "youtube", "video"), []byte("cached"), 0644)
+
+	entries, err := ListCache(cacheDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListCache() = %v, %v, want only the youtube entry", entries, err)
+	}
+
+	if err := ClearCache(cacheDir); err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "youtube")); !os.IsNotExist(err) {
+		t.Error("ClearCache() left the youtube cache behind")
+	}
+	for _, path := range []string{userFile, filepath.Join(cacheDir, "articles", "post.md")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("ClearCache() removed %s", path)
+		}
+	}
+}
@@ -0,0 +1,882 @@
+package newswriter
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const minContentMaxTokens = 2000
+
+// DefaultCacheDirectory is used when Settings.CacheDirectory is empty
+const DefaultCacheDirectory = ".cache"
+
+// defaultDraftDirectory is used when Settings.DraftDirectory is empty
+const defaultDraftDirectory = "drafts"
+
+// configDirName is the directory holding settings and prompt overrides
+const configDirName = ".news-writer"
+
+// ConfigOverrides allows overriding embedded defaults with file paths and settings with flags
+type ConfigOverrides struct {
+	SettingsPath      *string
+	WriterPromptPath  *string
+	PlannerPromptPath *string
+	PlannerSchemaPath *string
+	TemplatePath      *string
+	PromptDir         *string // Prompt files named like the embedded defaults, read fresh for each article
+	DisableCache      bool
+	OnlyCategories    []string
+	MaxBytesPerSecond int64
+	Deterministic     bool
+	Seed              *uint64
+}
+
+// Embedded configuration files
+//
+//go:embed defaults/writer-system-prompt.md
+var defaultWriterSystemPrompt string
+
+//go:embed defaults/summary-system-prompt.md
+var defaultSummarySystemPrompt string
+
+//go:embed defaults/writer-user-prompt.md
+var defaultWriterUserPrompt string
+
+//go:embed defaults/planner-system-prompt.md
+var defaultPlannerSystemPrompt string
+
+//go:embed defaults/planner-user-prompt.md
+var defaultPlannerUserPrompt string
+
+//go:embed defaults/planner-output-schema.json
+var defaultPlannerSchema string
+
+//go:embed defaults/news-article-template.md
+var defaultTemplate string
+
+// TypeTemplate tailors the writer to one kind of source, e.g. a video digest structure
+type TypeTemplate struct {
+	WriterPromptPath string `yaml:"writer_prompt_path"` // Writer system prompt used instead of the default
+}
+
+// Settings.Mode values
+const (
+	ModeArticle = "article" // Full article rewrite (default)
+	ModeSummary = "summary" // 3-5 sentence digest plus key points
+)
+
+// defaultSummaryMaxTokens is the writer budget in summary mode when summary_max_tokens is unset
+const defaultSummaryMaxTokens = 1000
+
+// PromptVariant is a named planner system prompt file used for A/B experiments
+type PromptVariant struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// SourceOverride customizes prompts, tone and categories for URLs from one domain
+type SourceOverride struct {
+	WriterPromptPath  string   `yaml:"writer_prompt_path"`  // Writer system prompt used instead of the default
+	PlannerPromptPath string   `yaml:"planner_prompt_path"` // Planner system prompt used instead of the default and any variants
+	Tone              string   `yaml:"tone"`                // Replaces the tone chosen by the planner
+	Categories        []string `yaml:"categories"`          // Categories offered to the planner instead of Settings.Categories
+}
+
+// OutputRule routes articles matching a source domain and/or planned category to a
+// subdirectory of output_directory; the first matching rule wins
+type OutputRule struct {
+	Domain    string `yaml:"domain"`    // Source domain; also matches its subdomains
+	Category  string `yaml:"category"`  // Planned category; a parent category matches its subcategories
+	Directory string `yaml:"directory"` // Relative to output_directory, e.g. "video"
+}
+
+// Settings represents the YAML configuration structure
+type Settings struct {
+	OutputDirectory            string                       `yaml:"output_directory"`
+	DraftDirectory             string                       `yaml:"draft_directory"` // Where drafts are written until approved; defaults to "drafts"
+	DefaultDraft               bool                         `yaml:"default_draft"`   // Write every article as a draft, as with --draft
+	TemplatePath               string                       `yaml:"template_path"`
+	PerURLTimeout              time.Duration                `yaml:"per_url_timeout"`               // e.g. "5m"; zero disables the deadline
+	PlannerConcurrency         int                          `yaml:"planner_concurrency"`           // Planner stage workers, feeding planned articles to the writer stage; zero means --concurrency
+	WriterConcurrency          int                          `yaml:"writer_concurrency"`            // Writer stage workers; with both zero, each URL runs start to finish in one of the --concurrency workers
+	LinkCheckConcurrency       int                          `yaml:"link_check_concurrency"`        // Simultaneous --check-links requests; defaults to 4
+	LinkChecksPerSecond        int                          `yaml:"link_checks_per_second"`        // --check-links request rate across all URLs; defaults to 5
+	FailOnBrokenLinks          bool                         `yaml:"fail_on_broken_links"`          // Fail the URL when --check-links finds broken links, instead of warning
+	SplitLongSources           bool                         `yaml:"split_long_sources"`            // Split sources longer than split_threshold_tokens into one article per topic
+	SplitThresholdTokens       int                          `yaml:"split_threshold_tokens"`        // Source length that triggers splitting; defaults to 20000
+	SplitMaxSegments           int                          `yaml:"split_max_segments"`            // Most articles written from one split source; defaults to 5
+	StreamLogs                 bool                         `yaml:"stream_logs"`                   // Log lines as they happen even when URLs run concurrently, instead of grouped per URL
+	Mode                       string                       `yaml:"mode"`                          // "article" (default) or "summary" for a TL;DR digest with key points
+	UseHeadRequest             bool                         `yaml:"use_head_request"`              // Inspect headers with HEAD before downloading
+	HandlerFallback            bool                         `yaml:"handler_fallback"`              // When a handler fails, try the next one that matches the URL
+	MaxContentBytes            int64                        `yaml:"max_content_bytes"`             // Reject larger responses; zero disables the limit
+	MaxBytesPerSecond          int64                        `yaml:"max_bytes_per_second"`          // Cap total download bandwidth across concurrent fetches; zero disables the cap
+	MinDeckChars               int                          `yaml:"min_deck_chars"`                // Reject shorter planner decks; zero disables the check
+	MaxDeckChars               int                          `yaml:"max_deck_chars"`                // Trim longer decks at a word boundary; zero disables
+	MinTags                    int                          `yaml:"min_tags"`                      // Ask the planner once more when it returns fewer tags; zero disables
+	MaxTags                    int                          `yaml:"max_tags"`                      // Keep the first max_tags planned tags; zero disables
+	SaveSource                 bool                         `yaml:"save_source"`                   // Save fetched source next to the article
+	DisableCache               bool                         `yaml:"disable_cache"`                 // Ignore cached entries (fresh entries are still written)
+	CacheDirectory             string                       `yaml:"cache_directory"`               // Defaults to .cache
+	SkipNoindex                bool                         `yaml:"skip_noindex"`                  // Skip pages marked noindex via meta robots or X-Robots-Tag
+	OnlyCategories             []string                     `yaml:"only_categories"`               // Skip articles whose planned categories match none of these
+	BannedPatterns             []string                     `yaml:"banned_patterns"`               // Regexes the written article must not match
+	BannedAction               string                       `yaml:"banned_action"`                 // "fail" (default) or "revise" to ask the writer for one revision
+	DuplicateTitles            string                       `yaml:"duplicate_titles"`              // "warn" (default) or "disambiguate" to append the source domain
+	AttributionTemplate        string                       `yaml:"attribution_template"`          // Footer appended to each article; supports {source_url}, {source_domain}, {date}
+	IncludeHashInFilename      *bool                        `yaml:"include_hash_in_filename"`      // Append the URL hash to filenames; defaults to true
+	SlugCollisionStrategy      string                       `yaml:"slug_collision_strategy"`       // Without the URL hash: "numeric" (default), "content-hash" or "fail"
+	CategorySubdirs            bool                         `yaml:"category_subdirs"`              // Write articles under a directory named after the slug of the primary category
+	DateSubdirs                *bool                        `yaml:"date_subdirs"`                  // Write articles under year/month directories; defaults to true
+	ExtraFrontmatter           map[string]any               `yaml:"extra_frontmatter"`             // Added to every article after the core fields, keys sorted
+	IncludeTargetInFrontmatter bool                         `yaml:"include_target_in_frontmatter"` // Emit the planner's tone and audience
+	IncludeSourceDate          bool                         `yaml:"include_source_date"`           // Emit the source's publish date, when the page declares one
+	IncludeFetchMetadata       bool                         `yaml:"include_fetch_metadata"`        // Emit fetched_at, http_status and final_url
+	FrontmatterDialect         string                       `yaml:"frontmatter_dialect"`           // "yaml" (default), "hugo-yaml", "hugo-toml", "jekyll" or "zola"
+	MarkdownLint               string                       `yaml:"markdown_lint"`                 // Check generated markdown for unclosed fences, broken tables, raw HTML and broken links: "" (off), "warn" or "reject"
+	RequireCategories          bool                         `yaml:"require_categories"`            // Fail the URL when the planner assigns no categories, after asking once more
+	SourceOverrides            map[string]SourceOverride    `yaml:"source_overrides"`              // Keyed by domain; also applies to its subdomains
+	TemplatesByType            map[ContentKind]TypeTemplate `yaml:"templates_by_type"`             // Keyed by source kind: youtube, pdf, html or text
+	OutputRules                []OutputRule                 `yaml:"output_rules"`                  // Per-article output subdirectory by domain or category
+	MaxSourceLinks             int                          `yaml:"max_source_links"`              // Record up to this many outbound source links as source_links; zero disables
+	AbortOnSaveError           bool                         `yaml:"abort_on_save_error"`           // Stop the run when a save fails with disk full, read-only or permission denied
+	Deterministic              bool                         `yaml:"deterministic"`                 // Temperature 0, sequential processing and seeded random choices, for reproducible runs
+	Seed                       uint64                       `yaml:"seed"`                          // Seed for random choices (e.g. variant_strategy random) in deterministic mode
+	ExpandShortURLs            bool                         `yaml:"expand_short_urls"`             // Resolve short links (t.co, bit.ly, ...) to their destination before processing
+	ShortURLHosts              []string                     `yaml:"short_url_hosts"`               // Extra shortener hosts to expand
+	StyleGuide                 string                       `yaml:"style_guide"`                   // House style appended to the writer system prompt: inline text, or a path ending in .md or .txt
+	Pricing                    map[string]ModelPrice        `yaml:"pricing"`                       // USD per million tokens by model name, for the cost column of --report
+	Agents                     struct {
+		APIKeys []string `yaml:"api_keys"` // Extra keys rotated to when one is rate limited
+		BaseURL string   `yaml:"base_url"` // Anthropic API base URL, e.g. a proxy or gateway
+		Planner struct {
+			Model            string  `yaml:"model"`
+			MaxTokens        int     `yaml:"max_tokens"`
+			Temperature      float64 `yaml:"temperature"`
+			ContentMaxTokens int     `yaml:"content_max_tokens"`
+			ContentSampling  string  `yaml:"content_sampling"` // Spans of long sources the planner sees: "head" (default), "head-tail" or "uniform"
+
+			PromptVariants  []PromptVariant `yaml:"prompt_variants"`  // Named planner system prompts to experiment with
+			VariantStrategy string          `yaml:"variant_strategy"` // "fixed" (first variant, default), "round-robin" or "random"
+
+			StructuredOutput *bool `yaml:"structured_output"` // Request schema-constrained output; when false, ask for a JSON block in the prompt. Defaults to true
+		} `yaml:"planner"`
+		Writer struct {
+			Model            string  `yaml:"model"`
+			MaxTokens        int     `yaml:"max_tokens"`
+			MinTokens        int     `yaml:"min_tokens"`         // When set, the budget scales with source length between min_tokens and max_tokens
+			EmptyRetries     int     `yaml:"empty_retries"`      // Retries, with backoff, when the writer returns no content. Defaults to 0
+			SummaryMaxTokens int     `yaml:"summary_max_tokens"` // Writer budget in summary mode. Defaults to 1000
+			Temperature      float64 `yaml:"temperature"`
+
+			TemperatureByCategory map[string]float64 `yaml:"temperature_by_category"` // Keyed by category, parent category or tone
+		} `yaml:"writer"`
+	} `yaml:"agents"`
+	Git struct {
+		AutoCommit bool `yaml:"auto_commit"` // Commit new and changed articles after a batch run
+		Push       bool `yaml:"push"`        // Push after committing
+	} `yaml:"git"`
+	YouTube struct {
+		CaptionFallback    bool     `yaml:"caption_fallback"`
+		MinTranscriptChars int      `yaml:"min_transcript_chars"` // Skip videos with shorter transcripts; empty transcripts are always skipped
+		StripPatterns      []string `yaml:"strip_patterns"`       // Regexes removed from transcripts before use, e.g. "(?i)transcribed by .*"
+	} `yaml:"youtube"`
+	Markdown struct {
+		Domain         string `yaml:"domain"`           // Base domain for resolving relative links
+		StripLinks     bool   `yaml:"strip_links"`      // Keep link text, drop the href
+		StripImages    bool   `yaml:"strip_images"`     // Drop images entirely
+		HeadingStyle   string `yaml:"heading_style"`    // "atx" (default) or "setext"
+		CodeBlockStyle string `yaml:"code_block_style"` // "indented" (default) or "fenced"
+		Fence          string `yaml:"fence"`            // "```" (default) or "~~~"
+	} `yaml:"markdown"`
+	PDF struct {
+		MaxConcurrentUploads int `yaml:"max_concurrent_uploads"` // Limit simultaneous Anthropic file uploads; zero means no limit
+	} `yaml:"pdf"`
+	HTML struct {
+		RemoveSelectors []string          `yaml:"remove_selectors"`  // CSS selectors stripped before conversion
+		MinContentChars int               `yaml:"min_content_chars"` // Skip pages with less extracted text; zero skips only empty pages
+		ConsentCookies  map[string]string `yaml:"consent_cookies"`   // Cookie header sent to a domain and its subdomains to get past consent walls
+	} `yaml:"html"`
+	Categories []string `yaml:"categories"`
+}
+
+// Config holds configuration and overrides
+type Config struct {
+	Settings  *Settings
+	Overrides *ConfigOverrides
+}
+
+// NewConfig creates a new Config with settings and overrides, failing fast on a malformed planner schema
+func NewConfig(overrides *ConfigOverrides) (*Config, error) {
+	config, err := LoadConfig(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePlannerSchema(config.GetPlannerSchema()); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.plannerSchemaSource(), err)
+	}
+
+	return config, nil
+}
+
+// LoadConfig creates a Config from settings and overrides without validating prompts or schema
+func LoadConfig(overrides *ConfigOverrides) (*Config, error) {
+	var settingsPath string
+	if overrides != nil && overrides.SettingsPath != nil {
+		settingsPath = *overrides.SettingsPath
+	}
+
+	settings, err := loadSettings(settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if overrides != nil && overrides.DisableCache {
+		settings.DisableCache = true
+	}
+	if overrides != nil && len(overrides.OnlyCategories) > 0 {
+		settings.OnlyCategories = overrides.OnlyCategories
+	}
+	if overrides != nil && overrides.MaxBytesPerSecond > 0 {
+		settings.MaxBytesPerSecond = overrides.MaxBytesPerSecond
+	}
+	if overrides != nil && overrides.Deterministic {
+		settings.Deterministic = true
+	}
+	if overrides != nil && overrides.Seed != nil {
+		settings.Seed = *overrides.Seed
+	}
+	if settings.Deterministic {
+		settings.applyDeterministic()
+	}
+	if settings.CacheDirectory == "" {
+		settings.CacheDirectory = DefaultCacheDirectory
+	}
+	if overrides != nil && overrides.PromptDir != nil {
+		if info, err := os.Stat(*overrides.PromptDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("prompt directory %s is not a directory", *overrides.PromptDir)
+		}
+	}
+
+	return &Config{
+		Settings:  settings,
+		Overrides: overrides,
+	}, nil
+}
+
+// sourceOverride returns the override configured for domain or, failing that, its
+// closest parent domain, so "example.com" also covers "blog.example.com"
+func (c *Config) sourceOverride(domain string) (string, *SourceOverride) {
+	domain = strings.ToLower(domain)
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	for domain != "" {
+		for key, override := range c.Settings.SourceOverrides {
+			if strings.EqualFold(key, domain) {
+				return key, &override
+			}
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return "", nil
+}
+
+type sourceOverrideKey struct{}
+
+// withSourceOverride attaches the override for the URL being processed to ctx
+func withSourceOverride(ctx context.Context, override *SourceOverride) context.Context {
+	return context.WithValue(ctx, sourceOverrideKey{}, override)
+}
+
+// sourceOverrideFrom returns the override attached to ctx, or nil
+func sourceOverrideFrom(ctx context.Context) *SourceOverride {
+	override, _ := ctx.Value(sourceOverrideKey{}).(*SourceOverride)
+	return override
+}
+
+// writerSystemPrompt returns the writer system prompt for a URL with the given source
+// override, which may be nil, and source kind, which may be empty. Summary mode uses the
+// embedded summary prompt instead of the article prompts; otherwise a source override's
+// prompt beats the one in templates_by_type for the kind.
+func (c *Config) writerSystemPrompt(override *SourceOverride, kind ContentKind) (string, error) {
+	prompt := c.GetWriterSystemPrompt()
+	typePrompt := c.Settings.TemplatesByType[kind].WriterPromptPath
+	switch {
+	case c.summaryMode():
+		prompt = defaultSummarySystemPrompt
+		if content, ok := c.promptDirFile("summary-system-prompt.md"); ok {
+			prompt = content
+		}
+	case override != nil && override.WriterPromptPath != "":
+		content, err := os.ReadFile(override.WriterPromptPath)
+		if err != nil {
+			return "", fmt.Errorf("reading source override writer prompt: %w", err)
+		}
+		prompt = string(content)
+	case typePrompt != "":
+		content, err := os.ReadFile(typePrompt)
+		if err != nil {
+			return "", fmt.Errorf("reading %s writer prompt: %w", kind, err)
+		}
+		prompt = string(content)
+	}
+
+	guide, err := c.styleGuide()
+	if err != nil {
+		return "", err
+	}
+	if guide != "" {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n## House Style\n\n" + guide + "\n"
+	}
+	return prompt, nil
+}
+
+// summaryMode reports whether Settings.Mode asks for summaries instead of articles
+func (c *Config) summaryMode() bool {
+	return c.Settings.Mode == ModeSummary
+}
+
+// styleGuide returns Settings.StyleGuide: the contents of the file it names, when it is
+// a path, or else the text itself
+func (c *Config) styleGuide() (string, error) {
+	guide := strings.TrimSpace(c.Settings.StyleGuide)
+	if !isStyleGuidePath(guide) {
+		return guide, nil
+	}
+	content, err := os.ReadFile(guide)
+	if err != nil {
+		return "", fmt.Errorf("reading style guide: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// isStyleGuidePath reports whether a style_guide value is a path: a single line ending in .md or .txt
+func isStyleGuidePath(guide string) bool {
+	ext := strings.ToLower(filepath.Ext(guide))
+	return !strings.Contains(guide, "\n") && (ext == ".md" || ext == ".txt")
+}
+
+// plannerSystemPrompt returns the planner system prompt template for a URL with the given
+// source override, which may be nil, and prompt variant name, which may be empty
+func (c *Config) plannerSystemPrompt(override *SourceOverride, variant string) (string, error) {
+	if override != nil && override.PlannerPromptPath != "" {
+		content, err := os.ReadFile(override.PlannerPromptPath)
+		if err != nil {
+			return "", fmt.Errorf("reading source override planner prompt: %w", err)
+		}
+		return string(content), nil
+	}
+	if variant != "" {
+		for _, v := range c.Settings.Agents.Planner.PromptVariants {
+			if v.Name != variant {
+				continue
+			}
+			content, err := os.ReadFile(v.Path)
+			if err != nil {
+				return "", fmt.Errorf("reading planner prompt variant %q: %w", v.Name, err)
+			}
+			return string(content), nil
+		}
+		return "", fmt.Errorf("unknown planner prompt variant %q", variant)
+	}
+	return c.GetPlannerSystemPrompt(), nil
+}
+
+// PromptVersion returns a short hash of the planner and writer system prompts in effect
+// for articles from domain generated with the given planner prompt variant (empty for none)
+// from a source of the given kind (empty when unknown)
+func (c *Config) PromptVersion(domain, variant string, kind ContentKind) (string, error) {
+	_, override := c.sourceOverride(domain)
+	planner, err := c.plannerSystemPrompt(override, variant)
+	if err != nil {
+		return "", err
+	}
+	writer, err := c.writerSystemPrompt(override, kind)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(planner + "\x00" + writer))
+	return fmt.Sprintf("%x", hash[:4]), nil
+}
+
+// promptDirFile returns the contents of name in the prompt directory, when one is
+// configured and has the file. It is read on every call, so edits take effect on the
+// next article of a running batch.
+func (c *Config) promptDirFile(name string) (string, bool) {
+	if c.Overrides == nil || c.Overrides.PromptDir == nil {
+		return "", false
+	}
+	content, err := os.ReadFile(filepath.Join(*c.Overrides.PromptDir, name))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: reading %s from prompt directory, using the default: %v", name, err)
+		}
+		return "", false
+	}
+	return string(content), true
+}
+
+// GetWriterSystemPrompt returns the writer system prompt (from override file, prompt directory or embedded)
+func (c *Config) GetWriterSystemPrompt() string {
+	if c.Overrides != nil && c.Overrides.WriterPromptPath != nil {
+		if content, err := os.ReadFile(*c.Overrides.WriterPromptPath); err == nil {
+			return string(content)
+		}
+	}
+	if content, ok := c.promptDirFile("writer-system-prompt.md"); ok {
+		return content
+	}
+	return defaultWriterSystemPrompt
+}
+
+// GetWriterUserPrompt returns the writer user prompt (from prompt directory or embedded)
+func (c *Config) GetWriterUserPrompt() string {
+	if content, ok := c.promptDirFile("writer-user-prompt.md"); ok {
+		return content
+	}
+	return defaultWriterUserPrompt
+}
+
+// GetPlannerSystemPrompt returns the planner system prompt (from override file, prompt directory or embedded)
+func (c *Config) GetPlannerSystemPrompt() string {
+	if c.Overrides != nil && c.Overrides.PlannerPromptPath != nil {
+		if content, err := os.ReadFile(*c.Overrides.PlannerPromptPath); err == nil {
+			return string(content)
+		}
+	}
+	if content, ok := c.promptDirFile("planner-system-prompt.md"); ok {
+		return content
+	}
+	return defaultPlannerSystemPrompt
+}
+
+// GetPlannerUserPrompt returns the planner user prompt (from prompt directory or embedded)
+func (c *Config) GetPlannerUserPrompt() string {
+	if content, ok := c.promptDirFile("planner-user-prompt.md"); ok {
+		return content
+	}
+	return defaultPlannerUserPrompt
+}
+
+// GetPlannerSchema returns the planner schema (from override file, prompt directory or embedded)
+func (c *Config) GetPlannerSchema() string {
+	if c.Overrides != nil && c.Overrides.PlannerSchemaPath != nil {
+		if content, err := os.ReadFile(*c.Overrides.PlannerSchemaPath); err == nil {
+			return string(content)
+		}
+	}
+	if content, ok := c.promptDirFile("planner-output-schema.json"); ok {
+		return content
+	}
+	return defaultPlannerSchema
+}
+
+// plannerSchemaSource describes where the planner schema is loaded from, for error messages
+func (c *Config) plannerSchemaSource() string {
+	if c.Overrides != nil && c.Overrides.PlannerSchemaPath != nil {
+		return *c.Overrides.PlannerSchemaPath
+	}
+	if _, ok := c.promptDirFile("planner-output-schema.json"); ok {
+		return filepath.Join(*c.Overrides.PromptDir, "planner-output-schema.json")
+	}
+	return "embedded planner schema"
+}
+
+// GetTemplate returns the template (from override file, prompt directory or embedded)
+func (c *Config) GetTemplate() string {
+	if c.Overrides != nil && c.Overrides.TemplatePath != nil {
+		if content, err := os.ReadFile(*c.Overrides.TemplatePath); err == nil {
+			return string(content)
+		}
+	}
+	if content, ok := c.promptDirFile("news-article-template.md"); ok {
+		return content
+	}
+	return defaultTemplate
+}
+
+// Validate checks prompts, schema and settings without calling any API and returns every problem found
+func (c *Config) Validate() []error {
+	var problems []error
+
+	if !strings.Contains(c.GetWriterUserPrompt(), "{{.Plan}}") {
+		problems = append(problems, fmt.Errorf("writer user prompt template must contain {{.Plan}} variable"))
+	}
+	if !strings.Contains(c.GetPlannerSystemPrompt(), "{{.categories}}") {
+		problems = append(problems, fmt.Errorf("planner system prompt template must contain {{.categories}} variable"))
+	}
+	if !strings.Contains(c.GetPlannerUserPrompt(), "{{.source_content}}") {
+		problems = append(problems, fmt.Errorf("planner user prompt template must contain {{.source_content}} variable"))
+	}
+	if err := validatePlannerSchema(c.GetPlannerSchema()); err != nil {
+		problems = append(problems, fmt.Errorf("%s: %w", c.plannerSchemaSource(), err))
+	}
+
+	settings := c.Settings
+	if settings.OutputDirectory == "" {
+		problems = append(problems, fmt.Errorf("settings: output_directory is required"))
+	}
+	if settings.DraftDirectory != "" && filepath.Clean(settings.DraftDirectory) == filepath.Clean(settings.OutputDirectory) {
+		problems = append(problems, fmt.Errorf("settings: draft_directory must differ from output_directory"))
+	}
+	if settings.Agents.Planner.MaxTokens < 1 {
+		problems = append(problems, fmt.Errorf("settings: agents.planner.max_tokens must be >= 1"))
+	}
+	if settings.Agents.Writer.MaxTokens < 1 {
+		problems = append(problems, fmt.Errorf("settings: agents.writer.max_tokens must be >= 1"))
+	}
+	if w := settings.Agents.Writer; w.MinTokens < 0 || w.MinTokens > w.MaxTokens {
+		problems = append(problems, fmt.Errorf("settings: agents.writer.min_tokens must be between 0 and max_tokens"))
+	}
+	if m := settings.Mode; m != "" && m != ModeArticle && m != ModeSummary {
+		problems = append(problems, fmt.Errorf("settings: mode must be %q or %q, got %q", ModeArticle, ModeSummary, m))
+	}
+	if settings.Agents.Writer.SummaryMaxTokens < 0 {
+		problems = append(problems, fmt.Errorf("settings: agents.writer.summary_max_tokens must be >= 0"))
+	}
+	if settings.Agents.Writer.EmptyRetries < 0 {
+		problems = append(problems, fmt.Errorf("settings: agents.writer.empty_retries must be >= 0"))
+	}
+	if t := settings.Agents.Planner.Temperature; t < 0 || t > 1 {
+		problems = append(problems, fmt.Errorf("settings: agents.planner.temperature must be between 0 and 1"))
+	}
+	if t := settings.Agents.Writer.Temperature; t < 0 || t > 1 {
+		problems = append(problems, fmt.Errorf("settings: agents.writer.temperature must be between 0 and 1"))
+	}
+	for key, t := range settings.Agents.Writer.TemperatureByCategory {
+		if t < 0 || t > 1 {
+			problems = append(problems, fmt.Errorf("settings: agents.writer.temperature_by_category[%q] must be between 0 and 1", key))
+		}
+	}
+	// Keys are matched case-insensitively, so keys differing only in case are ambiguous
+	var temperatureKeys []string
+	for key := range settings.Agents.Writer.TemperatureByCategory {
+		temperatureKeys = append(temperatureKeys, key)
+	}
+	sort.Strings(temperatureKeys)
+	seenTemperatureKeys := make(map[string]string)
+	for _, key := range temperatureKeys {
+		if other, ok := seenTemperatureKeys[strings.ToLower(key)]; ok {
+			problems = append(problems, fmt.Errorf("settings: agents.writer.temperature_by_category keys %q and %q differ only in case", other, key))
+			continue
+		}
+		seenTemperatureKeys[strings.ToLower(key)] = key
+	}
+	if settings.PDF.MaxConcurrentUploads < 0 {
+		problems = append(problems, fmt.Errorf("settings: pdf.max_concurrent_uploads must be >= 0"))
+	}
+	for model, price := range settings.Pricing {
+		if price.Input < 0 || price.Output < 0 {
+			problems = append(problems, fmt.Errorf("settings: pricing for %s must be >= 0", model))
+		}
+	}
+	if dialect := settings.FrontmatterDialect; dialect != "" && !containsString(frontmatterDialects, dialect) {
+		problems = append(problems, fmt.Errorf("settings: frontmatter_dialect must be one of %s, got %q", strings.Join(frontmatterDialects, ", "), dialect))
+	}
+	switch settings.MarkdownLint {
+	case MarkdownLintOff, MarkdownLintWarn, MarkdownLintReject:
+	default:
+		problems = append(problems, fmt.Errorf("settings: markdown_lint must be \"warn\" or \"reject\", got %q", settings.MarkdownLint))
+	}
+	if settings.LinkCheckConcurrency < 0 || settings.LinkChecksPerSecond < 0 {
+		problems = append(problems, fmt.Errorf("settings: link_check_concurrency and link_checks_per_second must be >= 0"))
+	}
+	if settings.MinTags < 0 || settings.MaxTags < 0 {
+		problems = append(problems, fmt.Errorf("settings: min_tags and max_tags must be >= 0"))
+	} else if settings.MaxTags > 0 && settings.MinTags > settings.MaxTags {
+		problems = append(problems, fmt.Errorf("settings: min_tags (%d) must not exceed max_tags (%d)", settings.MinTags, settings.MaxTags))
+	}
+	if settings.SplitThresholdTokens < 0 || settings.SplitMaxSegments < 0 {
+		problems = append(problems, fmt.Errorf("settings: split_threshold_tokens and split_max_segments must be >= 0"))
+	}
+	if settings.SplitLongSources && settings.IncludeHashInFilename != nil && !*settings.IncludeHashInFilename {
+		problems = append(problems, fmt.Errorf("settings: split_long_sources requires include_hash_in_filename, which tells the segments of a source apart"))
+	}
+	if settings.PlannerConcurrency < 0 {
+		problems = append(problems, fmt.Errorf("settings: planner_concurrency must be >= 0"))
+	}
+	if settings.WriterConcurrency < 0 {
+		problems = append(problems, fmt.Errorf("settings: writer_concurrency must be >= 0"))
+	}
+	if settings.MaxBytesPerSecond < 0 {
+		problems = append(problems, fmt.Errorf("settings: max_bytes_per_second must be >= 0"))
+	}
+	if settings.MaxSourceLinks < 0 {
+		problems = append(problems, fmt.Errorf("settings: max_source_links must be >= 0"))
+	}
+	if settings.YouTube.MinTranscriptChars < 0 {
+		problems = append(problems, fmt.Errorf("settings: youtube.min_transcript_chars must be >= 0"))
+	}
+	if settings.HTML.MinContentChars < 0 {
+		problems = append(problems, fmt.Errorf("settings: html.min_content_chars must be >= 0"))
+	}
+	if len(settings.Categories) == 0 {
+		problems = append(problems, fmt.Errorf("settings: categories must not be empty"))
+	}
+	switch settings.Agents.Planner.ContentSampling {
+	case "", "head", "head-tail", "uniform":
+	default:
+		problems = append(problems, fmt.Errorf("settings: agents.planner.content_sampling must be \"head\", \"head-tail\" or \"uniform\", got %q", settings.Agents.Planner.ContentSampling))
+	}
+	switch settings.Agents.Planner.VariantStrategy {
+	case "", "fixed", "round-robin", "random":
+	default:
+		problems = append(problems, fmt.Errorf("settings: agents.planner.variant_strategy must be \"fixed\", \"round-robin\" or \"random\", got %q", settings.Agents.Planner.VariantStrategy))
+	}
+	for i, variant := range settings.Agents.Planner.PromptVariants {
+		if variant.Name == "" {
+			problems = append(problems, fmt.Errorf("settings: agents.planner.prompt_variants[%d]: name is required", i))
+		}
+		content, err := os.ReadFile(variant.Path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("settings: agents.planner.prompt_variants[%d]: %w", i, err))
+		} else if !strings.Contains(string(content), "{{.categories}}") {
+			problems = append(problems, fmt.Errorf("%s: planner system prompt template must contain {{.categories}} variable", variant.Path))
+		}
+	}
+	for _, pattern := range settings.BannedPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Errorf("settings: banned_patterns: %w", err))
+		}
+	}
+	for _, pattern := range settings.YouTube.StripPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Errorf("settings: youtube.strip_patterns: %w", err))
+		}
+	}
+	switch settings.SlugCollisionStrategy {
+	case "", "numeric", "content-hash", "fail":
+	default:
+		problems = append(problems, fmt.Errorf("settings: slug_collision_strategy must be \"numeric\", \"content-hash\" or \"fail\", got %q", settings.SlugCollisionStrategy))
+	}
+	if d := settings.DuplicateTitles; d != "" && d != "warn" && d != "disambiguate" {
+		problems = append(problems, fmt.Errorf("settings: duplicate_titles must be \"warn\" or \"disambiguate\", got %q", d))
+	}
+	if a := settings.BannedAction; a != "" && a != "fail" && a != "revise" {
+		problems = append(problems, fmt.Errorf("settings: banned_action must be \"fail\" or \"revise\", got %q", a))
+	}
+	if guide := strings.TrimSpace(settings.StyleGuide); isStyleGuidePath(guide) {
+		if _, err := os.ReadFile(guide); err != nil {
+			problems = append(problems, fmt.Errorf("settings: style_guide: %w", err))
+		}
+	}
+	for i, rule := range settings.OutputRules {
+		if rule.Domain == "" && rule.Category == "" {
+			problems = append(problems, fmt.Errorf("settings: output_rules[%d] needs a domain or category", i))
+		}
+		if !filepath.IsLocal(filepath.FromSlash(rule.Directory)) {
+			problems = append(problems, fmt.Errorf("settings: output_rules[%d].directory %q must be a relative path inside output_directory", i, rule.Directory))
+		}
+	}
+	for kind, tmpl := range settings.TemplatesByType {
+		if tmpl.WriterPromptPath != "" {
+			if _, err := os.ReadFile(tmpl.WriterPromptPath); err != nil {
+				problems = append(problems, fmt.Errorf("settings: templates_by_type[%q].writer_prompt_path: %w", kind, err))
+			}
+		}
+	}
+	for domain, override := range settings.SourceOverrides {
+		if override.WriterPromptPath != "" {
+			if _, err := os.ReadFile(override.WriterPromptPath); err != nil {
+				problems = append(problems, fmt.Errorf("settings: source_overrides[%q].writer_prompt_path: %w", domain, err))
+			}
+		}
+		if override.PlannerPromptPath != "" {
+			content, err := os.ReadFile(override.PlannerPromptPath)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("settings: source_overrides[%q].planner_prompt_path: %w", domain, err))
+			} else if !strings.Contains(string(content), "{{.categories}}") {
+				problems = append(problems, fmt.Errorf("%s: planner system prompt template must contain {{.categories}} variable", override.PlannerPromptPath))
+			}
+		}
+	}
+
+	return problems
+}
+
+// validatePlannerSchema checks the planner schema is JSON with the fields Anthropic structured output requires
+func validatePlannerSchema(schema string) error {
+	var parsed struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Strict      bool                   `json:"strict"`
+		Schema      map[string]interface{} `json:"schema"`
+	}
+	if err := json.Unmarshal([]byte(schema), &parsed); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			// Offset points just past the offending byte
+			line, col := offsetToLineCol(schema, syntaxErr.Offset-1)
+			return fmt.Errorf("planner schema: invalid JSON at line %d, column %d: %w", line, col, err)
+		case errors.As(err, &typeErr):
+			line, col := offsetToLineCol(schema, typeErr.Offset)
+			return fmt.Errorf("planner schema: field %q has wrong type at line %d, column %d: %w", typeErr.Field, line, col, err)
+		default:
+			return fmt.Errorf("planner schema: invalid JSON: %w", err)
+		}
+	}
+
+	switch {
+	case parsed.Name == "":
+		return fmt.Errorf("planner schema: missing required field \"name\"")
+	case parsed.Description == "":
+		return fmt.Errorf("planner schema: missing required field \"description\"")
+	case !parsed.Strict:
+		return fmt.Errorf("planner schema: field \"strict\" must be true")
+	case parsed.Schema == nil:
+		return fmt.Errorf("planner schema: missing required field \"schema\"")
+	case parsed.Schema["type"] != "object":
+		return fmt.Errorf("planner schema: \"schema.type\" must be \"object\"")
+	}
+
+	return nil
+}
+
+// offsetToLineCol converts a byte offset into a 1-based line and column
+func offsetToLineCol(text string, offset int64) (line, col int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	before := text[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = int(offset) - strings.LastIndex(before, "\n")
+	return line, col
+}
+
+// applyDeterministic zeroes the planner and writer temperatures, including per-category
+// writer temperatures, for reproducible runs
+func (s *Settings) applyDeterministic() {
+	s.Agents.Planner.Temperature = 0
+	s.Agents.Writer.Temperature = 0
+	s.Agents.Writer.TemperatureByCategory = nil
+}
+
+// loadSettings loads settings from settingsPath, or from the nearest .news-writer directory when empty
+func loadSettings(settingsPath string) (*Settings, error) {
+	projectRoot := ""
+	if settingsPath == "" {
+		configDir := findConfigDir()
+		settingsPath = filepath.Join(configDir, "settings.yaml")
+		projectRoot = filepath.Dir(configDir)
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file %s: %w", settingsPath, err)
+	}
+
+	var settings Settings
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings YAML: %w", err)
+	}
+
+	// Ensure ContentMaxTokens is at least the minimum
+	if settings.Agents.Planner.ContentMaxTokens < minContentMaxTokens {
+		log.Printf("Warning: planner.content_max_tokens is %d, defaulting to %d (minimum)", settings.Agents.Planner.ContentMaxTokens, minContentMaxTokens)
+		settings.Agents.Planner.ContentMaxTokens = minContentMaxTokens
+	}
+
+	if settings.DraftDirectory == "" {
+		settings.DraftDirectory = defaultDraftDirectory
+	}
+
+	// Settings found in a parent directory write articles relative to that project root
+	if projectRoot != "" && projectRoot != "." && settings.OutputDirectory != "" && !filepath.IsAbs(settings.OutputDirectory) {
+		settings.OutputDirectory = filepath.Join(projectRoot, settings.OutputDirectory)
+	}
+	if projectRoot != "" && projectRoot != "." && !filepath.IsAbs(settings.DraftDirectory) {
+		settings.DraftDirectory = filepath.Join(projectRoot, settings.DraftDirectory)
+	}
+
+	return &settings, nil
+}
+
+// findConfigDir searches upward from the working directory for a .news-writer directory.
+// It falls back to .news-writer in the working directory when none is found.
+func findConfigDir() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return configDirName
+	}
+
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		candidate := filepath.Join(dir, configDirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			if dir == wd {
+				return configDirName
+			}
+			return candidate
+		}
+		if filepath.Dir(dir) == dir {
+			return configDirName
+		}
+	}
+}
+
+// getConfigPath returns the path to a config file in .news-writer directory
+func getConfigPath(filename string) string {
+	return filepath.Join(configDirName, filename)
+}
+
+// ensureConfigExists creates the config directory and default files if they don't exist
+func ensureConfigExists() error {
+	configDir := configDirName
+
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(configDir, 0755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
+	}
+
+	// Write default settings if it doesn't exist
+	settingsPath := getConfigPath("settings.yaml")
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		defaultSettings := `output_directory: articles
+template_path: .news-writer/news-article-template.md
+agents:
+  planner:
+    model: claude-sonnet-4-20250514
+    max_tokens: 1000
+    temperature: 0.0
+    content_max_tokens: 2000
+  writer:
+    model: claude-sonnet-4-20250514
+    max_tokens: 6000
+    temperature: 0.2
+categories:
+  - "Development/Programming"
+  - "Technology/Innovation"
+  - "Artificial Intelligence/Large Language Models"
+`
+		if err := os.WriteFile(settingsPath, []byte(defaultSettings), 0644); err != nil {
+			return fmt.Errorf("failed to write default settings: %w", err)
+		}
+	}
+
+	return nil
+}
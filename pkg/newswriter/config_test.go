@@ -0,0 +1,380 @@
+package newswriter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSettingsSearchesUpward(t *testing.T) {
+	root := t.TempDir()
+	configDir := filepath.Join(root, ".news-writer")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "settings.yaml"), []byte("output_directory: articles\n"), 0644)
+
+	subDir := filepath.Join(root, "a", "b")
+	os.MkdirAll(subDir, 0755)
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(subDir)
+
+	settings, err := loadSettings("")
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+
+	// Resolve symlinks (e.g. /tmp on macOS) before comparing
+	wantRoot, _ := filepath.EvalSymlinks(root)
+	gotDir, _ := filepath.EvalSymlinks(filepath.Dir(settings.OutputDirectory))
+	if gotDir != wantRoot || filepath.Base(settings.OutputDirectory) != "articles" {
+		t.Errorf("OutputDirectory = %q, want articles under %q", settings.OutputDirectory, wantRoot)
+	}
+}
+
+func TestLoadSettingsExplicitPath(t *testing.T) {
+	settingsFile := filepath.Join(t.TempDir(), "custom.yaml")
+	os.WriteFile(settingsFile, []byte("output_directory: out\n"), 0644)
+
+	settings, err := loadSettings(settingsFile)
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+
+	if settings.OutputDirectory != "out" {
+		t.Errorf("OutputDirectory = %q, want %q", settings.OutputDirectory, "out")
+	}
+
+	if _, err := loadSettings(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadSettings() expected error for missing file")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	validSettings := func() *Settings {
+		s := &Settings{OutputDirectory: "articles", Categories: []string{"Dev"}}
+		s.Agents.Planner.MaxTokens = 1000
+		s.Agents.Writer.MaxTokens = 5000
+		return s
+	}
+
+	t.Run("embedded defaults are valid", func(t *testing.T) {
+		config := &Config{Settings: validSettings()}
+		if problems := config.Validate(); len(problems) != 0 {
+			t.Errorf("Validate() = %v, want no problems", problems)
+		}
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		dir := t.TempDir()
+		writerPrompt := filepath.Join(dir, "writer.md")
+		os.WriteFile(writerPrompt, []byte("no plan here"), 0644)
+		schema := filepath.Join(dir, "schema.json")
+		os.WriteFile(schema, []byte("{not json"), 0644)
+
+		settings := validSettings()
+		settings.Agents.Writer.Temperature = 2
+		settings.BannedPatterns = []string{"guaranteed (returns"}
+		settings.BannedAction = "ignore"
+		config := &Config{
+			Settings: settings,
+			Overrides: &ConfigOverrides{
+				PlannerPromptPath: &writerPrompt,
+				PlannerSchemaPath: &schema,
+			},
+		}
+
+		problems := config.Validate()
+		if len(problems) != 5 {
+			t.Fatalf("Validate() returned %d problems, want 5: %v", len(problems), problems)
+		}
+	})
+
+	t.Run("temperature_by_category keys differing only in case", func(t *testing.T) {
+		settings := validSettings()
+		settings.Agents.Writer.TemperatureByCategory = map[string]float64{"AI": 0.9, "ai": 0.3, "Security": 0.5}
+		problems := (&Config{Settings: settings}).Validate()
+		if len(problems) != 1 || !strings.Contains(problems[0].Error(), `"AI" and "ai" differ only in case`) {
+			t.Errorf("Validate() = %v, want the colliding keys reported", problems)
+		}
+	})
+
+	t.Run("output rules stay inside output_directory", func(t *testing.T) {
+		settings := validSettings()
+		settings.OutputRules = []OutputRule{
+			{Domain: "youtube.com", Directory: "video"},
+			{Domain: "example.com", Directory: "../outside"},
+			{Category: "AI", Directory: "/abs"},
+			{Directory: "any"},
+		}
+		problems := (&Config{Settings: settings}).Validate()
+		if len(problems) != 3 {
+			t.Fatalf("Validate() returned %d problems, want 3: %v", len(problems), problems)
+		}
+		for i, want := range []string{"output_rules[1].directory", "output_rules[2].directory", "output_rules[3] needs a domain or category"} {
+			if !strings.Contains(problems[i].Error(), want) {
+				t.Errorf("problem %d = %v, want containing %q", i, problems[i], want)
+			}
+		}
+	})
+}
+
+func TestWriterSystemPromptStyleGuide(t *testing.T) {
+	dir := t.TempDir()
+	guidePath := filepath.Join(dir, "style.md")
+	os.WriteFile(guidePath, []byte("- Use the Oxford comma.\n"), 0644)
+
+	tests := []struct {
+		name     string
+		guide    string
+		expected string
+		wantErr  bool
+	}{
+		{"none", "", "", false},
+		{"inline", "No em-dashes.\nActive voice.", "\n\n## House Style\n\nNo em-dashes.\nActive voice.\n", false},
+		{"inline ending in a period", "Use active voice.", "\n\n## House Style\n\nUse active voice.\n", false},
+		{"path", guidePath, "\n\n## House Style\n\n- Use the Oxford comma.\n", false},
+		{"missing path", filepath.Join(dir, "missing.md"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Settings: &Settings{OutputDirectory: "articles", Categories: []string{"Dev"}, StyleGuide: tt.guide}}
+			config.Settings.Agents.Planner.MaxTokens = 1000
+			config.Settings.Agents.Writer.MaxTokens = 5000
+
+			prompt, err := config.writerSystemPrompt(nil, "")
+			problems := config.Validate()
+			if tt.wantErr {
+				if err == nil || len(problems) != 1 || !strings.Contains(problems[0].Error(), "style_guide") {
+					t.Errorf("writerSystemPrompt() error = %v, Validate() = %v, want style guide errors", err, problems)
+				}
+				return
+			}
+			if err != nil || len(problems) != 0 {
+				t.Fatalf("writerSystemPrompt() error = %v, Validate() = %v", err, problems)
+			}
+			if want := strings.TrimRight(defaultWriterSystemPrompt, "\n") + tt.expected; tt.guide != "" && prompt != want {
+				t.Errorf("writerSystemPrompt() ends with %q, want %q", prompt[len(prompt)-min(len(prompt), 80):], tt.expected)
+			}
+			if tt.guide == "" && prompt != defaultWriterSystemPrompt {
+				t.Error("writerSystemPrompt() changed without a style guide")
+			}
+		})
+	}
+}
+
+func TestValidatePlannerSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		errorMsg string
+	}{
+		{"embedded schema", defaultPlannerSchema, ""},
+		{"invalid json", `{"name": `, "invalid JSON"},
+		{"syntax error location", "{\n  \"name\": \"n\",\n  \"strict\": tru\n}", "line 3, column"},
+		{"wrong field type", "{\n  \"name\": 42\n}", `field "name" has wrong type at line 2`},
+		{"missing name", `{"description": "d", "strict": true, "schema": {"type": "object"}}`, `"name"`},
+		{"not strict", `{"name": "n", "description": "d", "schema": {"type": "object"}}`, `"strict"`},
+		{"missing schema", `{"name": "n", "description": "d", "strict": true}`, `"schema"`},
+		{"non-object schema", `{"name": "n", "description": "d", "strict": true, "schema": {"type": "array"}}`, `"schema.type"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePlannerSchema(tt.schema)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("validatePlannerSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("validatePlannerSchema() error = %v, want containing %q", err, tt.errorMsg)
+			}
+		})
+	}
+}
+
+func TestNewConfigRejectsMalformedSchema(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "settings.yaml")
+	os.WriteFile(settingsPath, []byte("output_directory: articles\n"), 0644)
+	schemaPath := filepath.Join(dir, "schema.json")
+	os.WriteFile(schemaPath, []byte(`{"name": "n",}`), 0644)
+
+	_, err := NewConfig(&ConfigOverrides{SettingsPath: &settingsPath, PlannerSchemaPath: &schemaPath})
+	if err == nil {
+		t.Fatal("NewConfig() expected error for malformed schema")
+	}
+	if !strings.Contains(err.Error(), schemaPath) || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("NewConfig() error = %v, want schema path and location", err)
+	}
+}
+
+func TestSourceOverride(t *testing.T) {
+	config := &Config{Settings: &Settings{SourceOverrides: map[string]SourceOverride{
+		"example.com":      {Tone: "casual"},
+		"news.example.com": {Tone: "neutral"},
+	}}}
+
+	tests := []struct {
+		domain  string
+		wantKey string
+	}{
+		{"example.com", "example.com"},
+		{"Blog.Example.com", "example.com"},
+		{"news.example.com", "news.example.com"},
+		{"example.com:8080", "example.com"},
+		{"other.org", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		key, override := config.sourceOverride(tt.domain)
+		if key != tt.wantKey || (override == nil) != (tt.wantKey == "") {
+			t.Errorf("sourceOverride(%q) = %q, %v, want %q", tt.domain, key, override, tt.wantKey)
+		}
+	}
+}
+
+func TestPromptVersion(t *testing.T) {
+	dir := t.TempDir()
+	writerPath := filepath.Join(dir, "writer.md")
+	os.WriteFile(writerPath, []byte("Custom writer"), 0644)
+	variantPath := filepath.Join(dir, "variant.md")
+	os.WriteFile(variantPath, []byte("Variant {{.categories}}"), 0644)
+
+	config := &Config{Settings: &Settings{SourceOverrides: map[string]SourceOverride{
+		"research.org": {WriterPromptPath: writerPath},
+	}}}
+	config.Settings.Agents.Planner.PromptVariants = []PromptVariant{{Name: "terse", Path: variantPath}}
+
+	base, err := config.PromptVersion("example.com", "", "")
+	if err != nil {
+		t.Fatalf("PromptVersion() error = %v", err)
+	}
+	if len(base) != 8 {
+		t.Errorf("PromptVersion() = %q, want 8 hex characters", base)
+	}
+	if again, _ := config.PromptVersion("other.com", "", ""); again != base {
+		t.Errorf("PromptVersion() = %q for same prompts, want %q", again, base)
+	}
+	if overridden, _ := config.PromptVersion("research.org", "", ""); overridden == base {
+		t.Error("PromptVersion() should change with a source override writer prompt")
+	}
+	if variant, _ := config.PromptVersion("example.com", "terse", ""); variant == base {
+		t.Error("PromptVersion() should change with the planner prompt variant")
+	}
+	if _, err := config.PromptVersion("example.com", "removed", ""); err == nil {
+		t.Error("PromptVersion() expected error for unknown variant")
+	}
+
+	os.WriteFile(writerPath, []byte("Edited writer"), 0644)
+	before, _ := config.PromptVersion("research.org", "", "")
+	os.WriteFile(writerPath, []byte("Edited again"), 0644)
+	if after, _ := config.PromptVersion("research.org", "", ""); after == before {
+		t.Error("PromptVersion() should change when a prompt file is edited")
+	}
+}
+
+func TestWriterSystemPromptByType(t *testing.T) {
+	dir := t.TempDir()
+	videoPath := filepath.Join(dir, "video.md")
+	os.WriteFile(videoPath, []byte("Video digest writer"), 0644)
+	researchPath := filepath.Join(dir, "research.md")
+	os.WriteFile(researchPath, []byte("Research writer"), 0644)
+
+	config := &Config{Settings: &Settings{TemplatesByType: map[ContentKind]TypeTemplate{
+		KindYouTube: {WriterPromptPath: videoPath},
+	}}}
+
+	tests := []struct {
+		name     string
+		override *SourceOverride
+		kind     ContentKind
+		expected string
+	}{
+		{"kind with template", nil, KindYouTube, "Video digest writer"},
+		{"kind without template", nil, KindHTML, defaultWriterSystemPrompt},
+		{"unknown kind", nil, "", defaultWriterSystemPrompt},
+		{"source override wins", &SourceOverride{WriterPromptPath: researchPath}, KindYouTube, "Research writer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := config.writerSystemPrompt(tt.override, tt.kind)
+			if err != nil || prompt != tt.expected {
+				t.Errorf("writerSystemPrompt() = %q, %v, want %q", truncate(prompt, 40), err, truncate(tt.expected, 40))
+			}
+		})
+	}
+
+	html, _ := config.PromptVersion("example.com", "", KindHTML)
+	if video, _ := config.PromptVersion("example.com", "", KindYouTube); video == html {
+		t.Error("PromptVersion() should change with the source kind's writer prompt")
+	}
+}
+
+func TestLoadConfigDeterministic(t *testing.T) {
+	settingsPath := filepath.Join(t.TempDir(), "settings.yaml")
+	os.WriteFile(settingsPath, []byte(`seed: 7
+agents:
+  planner:
+    temperature: 0.5
+  writer:
+    temperature: 0.7
+    temperature_by_category:
+      AI: 0.9
+`), 0644)
+
+	seed := uint64(42)
+	config, err := LoadConfig(&ConfigOverrides{SettingsPath: &settingsPath, Deterministic: true, Seed: &seed})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	agents := config.Settings.Agents
+	if !config.Settings.Deterministic || config.Settings.Seed != 42 {
+		t.Errorf("deterministic = %v, seed = %d, want true and 42", config.Settings.Deterministic, config.Settings.Seed)
+	}
+	if agents.Planner.Temperature != 0 || agents.Writer.Temperature != 0 || len(agents.Writer.TemperatureByCategory) != 0 {
+		t.Errorf("temperatures not zeroed: planner %v, writer %v, by category %v",
+			agents.Planner.Temperature, agents.Writer.Temperature, agents.Writer.TemperatureByCategory)
+	}
+}
+
+func TestPromptDirReloads(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{Settings: &Settings{}, Overrides: &ConfigOverrides{PromptDir: &dir}}
+
+	if got := config.GetWriterSystemPrompt(); got != defaultWriterSystemPrompt {
+		t.Errorf("GetWriterSystemPrompt() without the file = %q, want the embedded default", truncate(got, 40))
+	}
+
+	path := filepath.Join(dir, "writer-system-prompt.md")
+	os.WriteFile(path, []byte("First draft"), 0644)
+	if got := config.GetWriterSystemPrompt(); got != "First draft" {
+		t.Errorf("GetWriterSystemPrompt() = %q, want the prompt directory file", got)
+	}
+	os.WriteFile(path, []byte("Second draft"), 0644)
+	if got := config.GetWriterSystemPrompt(); got != "Second draft" {
+		t.Errorf("GetWriterSystemPrompt() after editing = %q, want the edited file", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "planner-user-prompt.md"), []byte("Plan {{.source_content}}"), 0644)
+	if got := config.GetPlannerUserPrompt(); got != "Plan {{.source_content}}" {
+		t.Errorf("GetPlannerUserPrompt() = %q, want the prompt directory file", got)
+	}
+
+	override := filepath.Join(t.TempDir(), "writer.md")
+	os.WriteFile(override, []byte("Explicit"), 0644)
+	config.Overrides.WriterPromptPath = &override
+	if got := config.GetWriterSystemPrompt(); got != "Explicit" {
+		t.Errorf("GetWriterSystemPrompt() = %q, want --writer-prompt to take precedence", got)
+	}
+
+	missing := filepath.Join(dir, "missing")
+	if _, err := LoadConfig(&ConfigOverrides{PromptDir: &missing}); err == nil || !strings.Contains(err.Error(), "prompt directory") {
+		t.Errorf("LoadConfig() with a missing prompt directory error = %v", err)
+	}
+}
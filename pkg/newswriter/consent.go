@@ -0,0 +1,78 @@
+package newswriter
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrConsentWall is returned when an HTML page is a cookie consent interstitial rather than the article
+var ErrConsentWall = errors.New("consent wall instead of content")
+
+// consentWallMaxChars is how much text a page with consent markers may have and still be
+// treated as an interstitial; articles with a consent banner on top are longer than this
+const consentWallMaxChars = 1500
+
+// consentSelectors match the containers of common consent management platforms
+var consentSelectors = []string{
+	"#onetrust-consent-sdk", "#CybotCookiebotDialog", "#didomi-host", "#qc-cmp2-container",
+	"#sp_message_container", ".fc-consent-root", "#usercentrics-root", "#truste-consent-track",
+	"iframe[src*='consent']", "form[action*='consent']",
+}
+
+// consentScripts are IAB TCF and CMP APIs defined by consent scripts
+var consentScripts = []string{"__tcfapi", "__cmp(", "__uspapi"}
+
+// consentPhrases are typical calls to action of consent interstitials, matched lowercased
+var consentPhrases = []string{
+	"accept cookies to continue", "accept all cookies to continue", "consent to continue",
+	"agree to continue", "before you continue", "we value your privacy", "cookies and data to",
+}
+
+// consentMarker returns the first consent-wall marker found in doc and its converted
+// text, or "" when there is none
+func consentMarker(doc *goquery.Document, text string) string {
+	for _, selector := range consentSelectors {
+		if doc.Find(selector).Length() > 0 {
+			return selector
+		}
+	}
+
+	var marker string
+	doc.Find("script").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		script := s.Text() + " " + s.AttrOr("src", "")
+		for _, api := range consentScripts {
+			if strings.Contains(script, api) {
+				marker = api
+				return false
+			}
+		}
+		return true
+	})
+	if marker != "" {
+		return marker
+	}
+
+	lower := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, phrase := range consentPhrases {
+		if strings.Contains(lower, phrase) {
+			return phrase
+		}
+	}
+	return ""
+}
+
+// setConsentCookie adds the cookie configured in Settings.HTML.ConsentCookies for the
+// request's host, or a parent domain of it, so sites skip their consent interstitial
+func (f *ContentFetcher) setConsentCookie(req *http.Request) {
+	host := strings.ToLower(req.URL.Hostname())
+	for domain, cookie := range f.consentCookies {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			req.Header.Add("Cookie", cookie)
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package newswriter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+// debugRecorder persists the source, prompts and raw responses for one URL into its own directory
+type debugRecorder struct {
+	dir   string
+	mu    sync.Mutex
+	calls int
+}
+
+type debugRecorderKey struct{}
+
+// newDebugRecorder creates <root>/<timestamp>-<url hash> for recording a URL
+func newDebugRecorder(root, url string) (*debugRecorder, error) {
+	hash := sha256.Sum256([]byte(url))
+	dir := filepath.Join(root, fmt.Sprintf("%s-%x", time.Now().Format("20060102-150405"), hash[:4]))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating debug directory: %w", err)
+	}
+	return &debugRecorder{dir: dir}, nil
+}
+
+func withDebugRecorder(ctx context.Context, r *debugRecorder) context.Context {
+	return context.WithValue(ctx, debugRecorderKey{}, r)
+}
+
+// debugRecorderFrom returns the recorder attached to ctx, or nil
+func debugRecorderFrom(ctx context.Context) *debugRecorder {
+	r, _ := ctx.Value(debugRecorderKey{}).(*debugRecorder)
+	return r
+}
+
+// write stores a file in the recorder's directory; failures are logged, never fatal
+func (r *debugRecorder) write(name string, data []byte) {
+	if err := os.WriteFile(filepath.Join(r.dir, name), data, 0644); err != nil {
+		log.Printf("Warning: writing debug file %s: %v", name, err)
+	}
+}
+
+// recordSource stores the fetched source content
+func (r *debugRecorder) recordSource(url string, content *ContentResult) {
+	source := content.Text
+	if content.FileID != "" {
+		source += fmt.Sprintf("\n\n[uploaded file: %s]", content.FileID)
+	}
+	r.write("source.md", []byte(fmt.Sprintf("<!-- %s -->\n\n%s", url, source)))
+}
+
+// recordPrompt stores one model call as numbered files: system and user prompts,
+// request settings (API key redacted) and the raw response text or error
+func (r *debugRecorder) recordPrompt(systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files []types.File, response *types.AnthropicResponse, err error) {
+	r.mu.Lock()
+	r.calls++
+	prefix := fmt.Sprintf("%d-writer", r.calls)
+	if schema != "" {
+		prefix = fmt.Sprintf("%d-planner", r.calls)
+	}
+	r.mu.Unlock()
+
+	r.write(prefix+"-system.md", []byte(systemPrompt))
+	r.write(prefix+"-user.md", []byte(userPrompt))
+
+	request, _ := json.MarshalIndent(struct {
+		APIKey   string                `json:"api_key"`
+		Settings types.RequestSettings `json:"settings"`
+		Files    []types.File          `json:"files,omitempty"`
+	}{redactKey(apiKey), settings, files}, "", "  ")
+	r.write(prefix+"-request.json", request)
+
+	switch {
+	case err != nil:
+		r.write(prefix+"-error.txt", []byte(err.Error()))
+	case response != nil && len(response.Content) > 0:
+		ext := ".md"
+		if schema != "" {
+			ext = ".json"
+		}
+		r.write(prefix+"-response"+ext, []byte(response.Content[0].Text))
+	}
+}
+
+// redactKey hides all but the last four characters of an API key
+func redactKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", 8) + key[len(key)-4:]
+}
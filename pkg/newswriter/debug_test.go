@@ -0,0 +1,63 @@
+package newswriter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestProcessURLDebugDir(t *testing.T) {
+	debugDir := t.TempDir()
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Fetched source"}}, &routingPrompter{})
+	p.debugDir = debugDir
+	p.agents.apiKey = "sk-ant-secret-1234"
+
+	if _, err := p.ProcessURL("https://example.com/post", false); err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+
+	dirs, _ := os.ReadDir(debugDir)
+	if len(dirs) != 1 {
+		t.Fatalf("got %d debug directories, want 1", len(dirs))
+	}
+	runDir := filepath.Join(debugDir, dirs[0].Name())
+
+	entries, _ := os.ReadDir(runDir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	expected := []string{
+		"1-planner-request.json", "1-planner-response.json", "1-planner-system.md", "1-planner-user.md",
+		"2-writer-request.json", "2-writer-response.md", "2-writer-system.md", "2-writer-user.md",
+		"source.md",
+	}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("debug files = %v, want %v", names, expected)
+	}
+
+	source, _ := os.ReadFile(filepath.Join(runDir, "source.md"))
+	if !strings.Contains(string(source), "Fetched source") {
+		t.Errorf("source.md = %q", source)
+	}
+	request, _ := os.ReadFile(filepath.Join(runDir, "1-planner-request.json"))
+	if strings.Contains(string(request), "sk-ant-secret") || !strings.Contains(string(request), "1234") {
+		t.Errorf("API key not redacted in request.json: %s", request)
+	}
+}
+
+func TestRedactKey(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"abc":                "***",
+		"sk-ant-secret-1234": "********1234",
+	}
+	for key, expected := range tests {
+		if result := redactKey(key); result != expected {
+			t.Errorf("redactKey(%q) = %q, want %q", key, result, expected)
+		}
+	}
+}
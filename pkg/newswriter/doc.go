@@ -0,0 +1,12 @@
+// Package newswriter distills web articles, PDFs and YouTube videos into
+// Markdown articles using a planner and a writer AI agent.
+//
+// The news-writer command is a thin CLI over this package. To use the
+// pipeline from Go:
+//
+//	processor, err := newswriter.NewArticleProcessor(apiKey, newswriter.WithOutputDir("articles"))
+//	if err != nil {
+//		return err
+//	}
+//	filename, err := processor.ProcessURL("https://example.com/post", false)
+package newswriter
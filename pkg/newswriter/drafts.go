@@ -0,0 +1,130 @@
+package newswriter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNotDraft is returned by ApproveDraft for files outside the draft directory
+var ErrNotDraft = errors.New("not in the draft directory")
+
+// ErrPublishedConflict is returned by ApproveDraft when the published path holds an
+// article from a different source
+var ErrPublishedConflict = errors.New("published article has a different source_url")
+
+var (
+	draftYAMLPattern      = regexp.MustCompile(`(?m)^draft:\s*true\s*$`)
+	draftTOMLPattern      = regexp.MustCompile(`(?m)^draft\s*=\s*true\s*$`)
+	unpublishedPattern    = regexp.MustCompile(`(?m)^published:\s*false\s*\n`)
+	frontmatterEndPattern = regexp.MustCompile(`(?m)^(---|\+\+\+)\s*$`)
+)
+
+// ApproveDraft moves a draft from Settings.DraftDirectory to the same path under
+// Settings.OutputDirectory, marking it draft: false, together with any saved
+// source. The manifest entry, if any, is pointed at the published file. Returns
+// the published filename. A published article is only replaced by a draft of the
+// same source_url.
+func (c *Config) ApproveDraft(path string) (string, error) {
+	rel, err := relativeTo(c.Settings.DraftDirectory, path)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(c.Settings.OutputDirectory, rel)
+
+	draft, err := readArticleFile(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(target); err == nil {
+		published, err := readArticleFile(target)
+		if err != nil || published.SourceURL != draft.SourceURL {
+			return "", fmt.Errorf("%w: %s", ErrPublishedConflict, target)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading draft: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("creating directory: %w", err)
+	}
+	if err := os.WriteFile(target, publishDraft(data), 0644); err != nil {
+		return "", fmt.Errorf("writing article: %w", err)
+	}
+
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	targetBase := strings.TrimSuffix(target, filepath.Ext(target))
+	for _, ext := range []string{".source.md", ".source.pdf"} {
+		if err := os.Rename(base+ext, targetBase+ext); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("moving source: %w", err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("removing draft: %w", err)
+	}
+
+	if err := c.renameInManifest(path, target); err != nil {
+		return target, err
+	}
+	return target, nil
+}
+
+// relativeTo returns path relative to dir, or ErrNotDraft when path is outside dir
+func relativeTo(dir, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving draft directory: %w", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving draft: %w", err)
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil || !filepath.IsLocal(rel) || rel == "." {
+		return "", fmt.Errorf("%w: %s", ErrNotDraft, path)
+	}
+	return rel, nil
+}
+
+// publishDraft clears the draft flag in the frontmatter of an article, leaving the body alone
+func publishDraft(data []byte) []byte {
+	content := string(data)
+	end := len(content)
+	if loc := frontmatterEndPattern.FindAllStringIndex(content, 2); len(loc) == 2 {
+		end = loc[1][0]
+	}
+	frontmatter := content[:end]
+	frontmatter = draftYAMLPattern.ReplaceAllString(frontmatter, "draft: false")
+	frontmatter = draftTOMLPattern.ReplaceAllString(frontmatter, "draft = false")
+	frontmatter = unpublishedPattern.ReplaceAllString(frontmatter, "")
+	return []byte(frontmatter + content[end:])
+}
+
+// renameInManifest points the manifest entry recorded for from at to
+func (c *Config) renameInManifest(from, to string) error {
+	m, err := loadManifest(c.manifestPath())
+	if err != nil {
+		return err
+	}
+	absFrom, _ := filepath.Abs(from)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.URLs {
+		if entry.Filename == "" {
+			continue
+		}
+		if abs, _ := filepath.Abs(entry.Filename); abs != absFrom {
+			continue
+		}
+		entry.Filename = to
+		m.URLs[key] = entry
+		return m.save()
+	}
+	return nil
+}
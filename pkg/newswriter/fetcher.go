@@ -0,0 +1,445 @@
+package newswriter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ContentResult represents the result of fetching content
+type ContentResult struct {
+	Text       string      // Markdown text content (for HTML pages)
+	FileID     string      // File ID (for PDFs)
+	SourceFile string      // Temporary local copy of a binary source (PDFs), kept when Settings.SaveSource is set
+	Links      []string    // Absolute outbound links found in the cleaned source (HTML pages), deduplicated
+	Kind       ContentKind // Set by the handler that produced the result; empty when unknown
+	SourceDate time.Time   // When the source was published, from page metadata; zero when unknown
+
+	FetchedAt  time.Time // When the source was fetched
+	HTTPStatus int       // Status of the GET response; 0 for local files
+	FinalURL   string    // URL the GET request ended at after redirects; empty for local files
+
+	// Response validators, stored for --refresh's conditional fetches only once the article is saved
+	ETag         string
+	LastModified string
+}
+
+// ContentKind is the kind of source a ContentResult came from. It selects the
+// templates_by_type writer prompt and is recorded as source_type in frontmatter.
+type ContentKind string
+
+// Content kinds set by the built-in handlers
+const (
+	KindYouTube ContentKind = "youtube" // Transcript or captions of a YouTube video
+	KindPDF     ContentKind = "pdf"     // PDF uploaded to the Files API
+	KindHTML    ContentKind = "html"    // Web page (or local HTML file) converted to markdown
+	KindText    ContentKind = "text"    // Local Markdown or plain text document
+)
+
+// Fetcher fetches and converts the content of a URL; ContentFetcher is the default implementation
+type Fetcher interface {
+	FetchContentContext(ctx context.Context, url string) (*ContentResult, error)
+}
+
+// fetcherUserAgent identifies fetches and link checks; some sites refuse requests
+// without a User-Agent
+const fetcherUserAgent = "news-writer/1.0 (+https://github.com/aktagon/news-writer)"
+
+// ErrContentTooLarge is returned when a response exceeds Settings.MaxContentBytes
+var ErrContentTooLarge = errors.New("content exceeds maximum size")
+
+// ErrNoindex is returned when Settings.SkipNoindex is set and the page asks not to be indexed
+var ErrNoindex = errors.New("page is marked noindex")
+
+// ErrNoContent is returned when an HTML page converts to no (or too little) text, e.g. a JavaScript-only app
+var ErrNoContent = errors.New("no extractable content")
+
+// ErrNotModified is returned by a conditional fetch when the server answers 304 Not Modified
+var ErrNotModified = errors.New("content not modified")
+
+type conditionalFetchKey struct{}
+
+// withConditionalFetch marks ctx so ContentFetcher sends If-None-Match/If-Modified-Since
+// from the validators stored on the last fetch, returning ErrNotModified on a 304
+func withConditionalFetch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, conditionalFetchKey{}, true)
+}
+
+func isConditionalFetch(ctx context.Context) bool {
+	conditional, _ := ctx.Value(conditionalFetchKey{}).(bool)
+	return conditional
+}
+
+type contentKindKey struct{}
+
+// withContentKind records the kind of the fetched source for agent calls without the content at hand
+func withContentKind(ctx context.Context, kind ContentKind) context.Context {
+	return context.WithValue(ctx, contentKindKey{}, kind)
+}
+
+// contentKindFrom returns the kind set with withContentKind, or ""
+func contentKindFrom(ctx context.Context) ContentKind {
+	kind, _ := ctx.Value(contentKindKey{}).(ContentKind)
+	return kind
+}
+
+type contentTypeKey struct{}
+
+// withContentType makes ContentFetcher select the handler as if the server sent contentType,
+// for sources with a wrong Content-Type header
+func withContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contentTypeKey{}, contentType)
+}
+
+// forcedContentType returns the content type set with withContentType, or ""
+func forcedContentType(ctx context.Context) string {
+	contentType, _ := ctx.Value(contentTypeKey{}).(string)
+	return contentType
+}
+
+// ContentFetcher handles fetching and processing content from URLs
+type ContentFetcher struct {
+	handlers        []ContentHandler
+	client          *http.Client
+	useHeadRequest  bool
+	maxContentBytes int64
+	bandwidth       *bandwidthLimiter // Shared by all fetches; nil when Settings.MaxBytesPerSecond is zero
+	skipNoindex     bool
+	cacheDir        string            // Where ETag/Last-Modified validators are stored; empty disables them
+	disableCache    bool              // Don't send stored validators (fresh ones are still stored)
+	consentCookies  map[string]string // Cookie header per domain, from Settings.HTML.ConsentCookies
+	handlerFallback bool              // Try the next matching handler when the selected one fails
+}
+
+// NewContentFetcher creates a new content fetcher with default handlers
+func NewContentFetcher(apiKey string, settings *Settings) *ContentFetcher {
+	if settings == nil {
+		settings = &Settings{}
+	}
+
+	f := &ContentFetcher{
+		client:          &http.Client{},
+		useHeadRequest:  settings.UseHeadRequest,
+		maxContentBytes: settings.MaxContentBytes,
+		bandwidth:       newBandwidthLimiter(settings.MaxBytesPerSecond),
+		skipNoindex:     settings.SkipNoindex,
+		cacheDir:        settings.CacheDirectory,
+		disableCache:    settings.DisableCache,
+		consentCookies:  settings.HTML.ConsentCookies,
+		handlerFallback: settings.HandlerFallback,
+	}
+
+	// Register handlers (most specific first)
+	f.AddHandler(&YouTubeHandler{
+		captionFallback: settings.YouTube.CaptionFallback,
+		disableCache:    settings.DisableCache,
+		cacheDir:        settings.CacheDirectory,
+
+		minTranscriptChars: settings.YouTube.MinTranscriptChars,
+		stripPatterns:      settings.YouTube.StripPatterns,
+	})
+	pdf := &PDFHandler{apiKey: apiKey, keepSource: settings.SaveSource}
+	if baseURL := settings.Agents.BaseURL; baseURL != "" {
+		pdf.client, _ = newBaseURLClient(baseURL) // NewAgentManager reports an invalid URL
+	}
+	if n := settings.PDF.MaxConcurrentUploads; n > 0 {
+		pdf.uploads = make(chan struct{}, n)
+	}
+	f.AddHandler(pdf)
+	f.AddHandler(&TextHandler{})
+	f.AddHandler(&HTMLHandler{
+		converter:       newMarkdownConverter(settings),
+		removeSelectors: settings.HTML.RemoveSelectors,
+		skipNoindex:     settings.SkipNoindex,
+		minContentChars: settings.HTML.MinContentChars,
+	}) // fallback
+
+	return f
+}
+
+// AddHandler adds a content handler to the chain
+func (f *ContentFetcher) AddHandler(handler ContentHandler) {
+	f.handlers = append(f.handlers, handler)
+}
+
+// FetchContent fetches and processes content using handler chain
+func (f *ContentFetcher) FetchContent(url string) (*ContentResult, error) {
+	return f.FetchContentContext(context.Background(), url)
+}
+
+// FetchContentContext fetches and processes content, aborting when ctx is done
+func (f *ContentFetcher) FetchContentContext(ctx context.Context, url string) (*ContentResult, error) {
+	// Pick the handler and check the size from headers before downloading
+	var selected ContentHandler
+	forced := forcedContentType(ctx)
+	if f.useHeadRequest && forced == "" && !isLocalFile(url) {
+		handler, err := f.selectHandlerWithHead(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		selected = handler
+	}
+
+	// Local files skip the HTTP layer and go straight to the handler for their extension
+	local := isLocalFile(url)
+	var resp *http.Response
+	var err error
+	if local {
+		resp, err = openLocalFile(url)
+	} else {
+		resp, err = f.get(ctx, url)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := f.checkContentLength(url, resp); err != nil {
+		return nil, err
+	}
+
+	if f.skipNoindex && hasNoindex(resp.Header.Get("X-Robots-Tag")) {
+		return nil, fmt.Errorf("%w: X-Robots-Tag on %s", ErrNoindex, url)
+	}
+
+	// Buffer the body once so handlers can inspect it in CanHandle and still read it in full in Handle
+	body, err := f.readBody(ctx, url, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	rewind := func() { resp.Body = io.NopCloser(bytes.NewReader(body)) }
+
+	if forced != "" {
+		debugf(ctx, "Treating %s as %s (server sent %q)", url, forced, resp.Header.Get("Content-Type"))
+		resp.Header.Set("Content-Type", forced)
+	} else {
+		sniffContentType(ctx, url, resp, body)
+	}
+
+	candidates := f.matchingHandlers(url, resp, selected, rewind)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no handler found for %s", url)
+	}
+
+	var result *ContentResult
+	for i, handler := range candidates {
+		rewind()
+		if h, ok := handler.(ContextHandler); ok {
+			result, err = h.HandleContext(ctx, url, resp)
+		} else {
+			result, err = handler.Handle(url, resp)
+		}
+		if err == nil {
+			break
+		}
+		if i == len(candidates)-1 || !isRecoverableHandlerError(err) {
+			return nil, err
+		}
+		logf(ctx, "→ %s failed for %s (%v), trying %s", handlerName(handler), url, err, handlerName(candidates[i+1]))
+	}
+
+	result.FetchedAt = time.Now()
+	if !local {
+		result.HTTPStatus = resp.StatusCode
+		if resp.Request != nil {
+			result.FinalURL = resp.Request.URL.String()
+		}
+		result.ETag = resp.Header.Get("ETag")
+		result.LastModified = resp.Header.Get("Last-Modified")
+	}
+	return result, nil
+}
+
+// matchingHandlers returns the handler to use for url, starting with selected when the
+// HEAD request picked one. With handler fallback it returns every matching handler, in
+// chain order, to try until one succeeds.
+func (f *ContentFetcher) matchingHandlers(url string, resp *http.Response, selected ContentHandler, rewind func()) []ContentHandler {
+	var matching []ContentHandler
+	if selected != nil {
+		matching = append(matching, selected)
+	}
+	for _, handler := range f.handlers {
+		if len(matching) > 0 && !f.handlerFallback {
+			break
+		}
+		if handler == selected {
+			continue
+		}
+		rewind()
+		if handler.CanHandle(url, resp) {
+			matching = append(matching, handler)
+		}
+	}
+	return matching
+}
+
+// isRecoverableHandlerError reports whether another handler may succeed where one failed:
+// not when the fetch was cancelled, the content is too large or the URL is skipped on purpose
+func isRecoverableHandlerError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrContentTooLarge) {
+		return false
+	}
+	return outcomeStatus(err) == StatusError
+}
+
+// handlerName names a handler in log messages, e.g. "PDFHandler"
+func handlerName(handler ContentHandler) string {
+	name := fmt.Sprintf("%T", handler)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// newRequest creates a request for url with the fetcher's User-Agent and the consent
+// cookie configured for its domain
+func (f *ContentFetcher) newRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fetcherUserAgent)
+	f.setConsentCookie(req)
+	return req, nil
+}
+
+// get sends the GET request for url, conditional when ctx asks for it, and returns the
+// response when it is 200 OK; callers close its body
+func (f *ContentFetcher) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := f.newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", url, err)
+	}
+	if f.cacheDir != "" && !f.disableCache && isConditionalFetch(ctx) {
+		if v := loadValidators(f.cacheDir, url); v != nil {
+			if v.ETag != "" {
+				req.Header.Set("If-None-Match", v.ETag)
+			}
+			if v.LastModified != "" {
+				req.Header.Set("If-Modified-Since", v.LastModified)
+			}
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotModified, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &HTTPError{StatusCode: resp.StatusCode, URL: url}
+	}
+	return resp, nil
+}
+
+// selectHandlerWithHead issues a HEAD request to pick a handler and enforce the size limit.
+// It returns a nil handler when the server doesn't support HEAD, so the caller falls back to GET.
+func (f *ContentFetcher) selectHandlerWithHead(ctx context.Context, url string) (ContentHandler, error) {
+	req, err := f.newRequest(ctx, http.MethodHead, url)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", url, err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		debugf(ctx, "HEAD %s failed, falling back to GET: %v", url, err)
+		return nil, nil
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		debugf(ctx, "HEAD %s returned %d, falling back to GET", url, resp.StatusCode)
+		return nil, nil
+	}
+
+	if err := f.checkContentLength(url, resp); err != nil {
+		return nil, err
+	}
+
+	if isGenericContentType(resp.Header.Get("Content-Type")) {
+		debugf(ctx, "HEAD %s has no specific Content-Type, selecting the handler after GET", url)
+		return nil, nil
+	}
+
+	for _, handler := range f.handlers {
+		if handler.CanHandle(url, resp) {
+			return handler, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// sniffLen is how much of the body http.DetectContentType looks at
+const sniffLen = 512
+
+// readBody reads the whole response body at no more than the configured bandwidth,
+// failing with ErrContentTooLarge past the configured maximum even when the server
+// declared no Content-Length
+func (f *ContentFetcher) readBody(ctx context.Context, url string, body io.Reader) ([]byte, error) {
+	body = f.bandwidth.reader(ctx, body)
+	if f.maxContentBytes > 0 {
+		body = io.LimitReader(body, f.maxContentBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if f.maxContentBytes > 0 && int64(len(data)) > f.maxContentBytes {
+		return nil, fmt.Errorf("%w: %s is over %d bytes", ErrContentTooLarge, url, f.maxContentBytes)
+	}
+	return data, nil
+}
+
+// sniffContentType sets a missing or generic Content-Type from the first bytes of the body
+func sniffContentType(ctx context.Context, url string, resp *http.Response, body []byte) {
+	if !isGenericContentType(resp.Header.Get("Content-Type")) {
+		return
+	}
+	if detected := http.DetectContentType(body[:min(len(body), sniffLen)]); !isGenericContentType(detected) {
+		debugf(ctx, "Sniffed %s as %s (server sent %q)", url, detected, resp.Header.Get("Content-Type"))
+		resp.Header.Set("Content-Type", detected)
+	}
+}
+
+// isGenericContentType reports whether a Content-Type says nothing about the format
+func isGenericContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "", "application/octet-stream", "binary/octet-stream", "application/unknown":
+		return true
+	}
+	return false
+}
+
+// checkContentLength rejects responses whose declared length exceeds the configured maximum
+func (f *ContentFetcher) checkContentLength(url string, resp *http.Response) error {
+	if f.maxContentBytes > 0 && resp.ContentLength > f.maxContentBytes {
+		return fmt.Errorf("%w: %s is %d bytes (limit %d)", ErrContentTooLarge, url, resp.ContentLength, f.maxContentBytes)
+	}
+	return nil
+}
+
+// hasNoindex reports whether a robots directive list (meta content or X-Robots-Tag) contains noindex or none
+func hasNoindex(directives string) bool {
+	for _, directive := range strings.Split(strings.ToLower(directives), ",") {
+		// X-Robots-Tag may be scoped to a user agent, e.g. "googlebot: noindex"
+		if i := strings.LastIndex(directive, ":"); i >= 0 {
+			directive = directive[i+1:]
+		}
+		switch strings.TrimSpace(directive) {
+		case "noindex", "none":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,708 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Mock handler for testing
+type mockHandler struct {
+	canHandleResult bool
+	handleResult    *ContentResult
+	handleError     error
+}
+
+func (m *mockHandler) CanHandle(url string, resp *http.Response) bool {
+	return m.canHandleResult
+}
+
+func (m *mockHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	return m.handleResult, m.handleError
+}
+
+func TestNewContentFetcher(t *testing.T) {
+	apiKey := "test-key"
+
+	fetcher := NewContentFetcher(apiKey, nil)
+
+	if fetcher == nil {
+		t.Fatal("NewContentFetcher() returned nil")
+	}
+
+	if fetcher.client == nil {
+		t.Error("NewContentFetcher() did not initialize HTTP client")
+	}
+
+	if len(fetcher.handlers) == 0 {
+		t.Error("NewContentFetcher() did not register any handlers")
+	}
+
+	expectedHandlerCount := 4 // YouTube, PDF, Text, HTML
+	if len(fetcher.handlers) != expectedHandlerCount {
+		t.Errorf("NewContentFetcher() registered %d handlers, want %d",
+			len(fetcher.handlers), expectedHandlerCount)
+	}
+}
+
+func TestAddHandler(t *testing.T) {
+	fetcher := &ContentFetcher{}
+	initialCount := len(fetcher.handlers)
+
+	mockH := &mockHandler{canHandleResult: true}
+	fetcher.AddHandler(mockH)
+
+	if len(fetcher.handlers) != initialCount+1 {
+		t.Errorf("AddHandler() handlers count = %d, want %d",
+			len(fetcher.handlers), initialCount+1)
+	}
+
+	lastHandler := fetcher.handlers[len(fetcher.handlers)-1]
+	if lastHandler != mockH {
+		t.Error("AddHandler() did not add handler to the end of the chain")
+	}
+}
+
+func TestFetchContentHTTPError(t *testing.T) {
+	// Create test server that returns 404
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	fetcher := &ContentFetcher{
+		client: server.Client(),
+	}
+
+	result, err := fetcher.FetchContent(server.URL)
+
+	if result != nil {
+		t.Error("FetchContent() should return nil result on HTTP error")
+	}
+
+	if err == nil {
+		t.Fatal("FetchContent() should return error on HTTP 404")
+	}
+
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Errorf("FetchContent() should return HTTPError, got %T", err)
+	} else {
+		if httpErr.StatusCode != http.StatusNotFound {
+			t.Errorf("HTTPError.StatusCode = %d, want %d",
+				httpErr.StatusCode, http.StatusNotFound)
+		}
+		if httpErr.URL != server.URL {
+			t.Errorf("HTTPError.URL = %q, want %q", httpErr.URL, server.URL)
+		}
+	}
+}
+
+func TestFetchContentHandlerChain(t *testing.T) {
+	// Create test server that returns HTML
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("<h1>Test HTML</h1>"))
+	}))
+	defer server.Close()
+
+	// Create mock handlers to test chain order
+	handler1 := &mockHandler{
+		canHandleResult: false, // This handler won't handle
+	}
+
+	handler2 := &mockHandler{
+		canHandleResult: true, // This handler will handle
+		handleResult:    &ContentResult{Text: "handler2 result"},
+	}
+
+	handler3 := &mockHandler{
+		canHandleResult: true, // This handler would handle but won't be reached
+		handleResult:    &ContentResult{Text: "handler3 result"},
+	}
+
+	fetcher := &ContentFetcher{
+		client:   server.Client(),
+		handlers: []ContentHandler{handler1, handler2, handler3},
+	}
+
+	result, err := fetcher.FetchContent(server.URL)
+
+	if err != nil {
+		t.Fatalf("FetchContent() error = %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("FetchContent() returned nil result")
+	}
+
+	if result.Text != "handler2 result" {
+		t.Errorf("FetchContent() result.Text = %q, want %q",
+			result.Text, "handler2 result")
+	}
+
+	if !strings.Contains(result.Text, "handler2") {
+		t.Error("Wrong handler was used - should use first matching handler")
+	}
+}
+
+func TestFetchContentHandlerFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("some content"))
+	}))
+	defer server.Close()
+
+	uploadErr := errors.New("upload failed")
+	parseErr := errors.New("parsing HTML failed")
+	tests := []struct {
+		name     string
+		fallback bool
+		handlers []ContentHandler
+		wantText string
+		wantErr  error
+	}{
+		{
+			name:     "disabled",
+			handlers: []ContentHandler{&mockHandler{canHandleResult: true, handleError: uploadErr}, &mockHandler{canHandleResult: true, handleResult: &ContentResult{Text: "html"}}},
+			wantErr:  uploadErr,
+		},
+		{
+			name:     "next matching handler",
+			fallback: true,
+			handlers: []ContentHandler{
+				&mockHandler{canHandleResult: true, handleError: uploadErr},
+				&mockHandler{canHandleResult: false, handleResult: &ContentResult{Text: "not matching"}},
+				&mockHandler{canHandleResult: true, handleResult: &ContentResult{Text: "html"}},
+			},
+			wantText: "html",
+		},
+		{
+			name:     "all fail returns last error",
+			fallback: true,
+			handlers: []ContentHandler{&mockHandler{canHandleResult: true, handleError: uploadErr}, &mockHandler{canHandleResult: true, handleError: parseErr}},
+			wantErr:  parseErr,
+		},
+		{
+			name:     "deliberate skip is final",
+			fallback: true,
+			handlers: []ContentHandler{&mockHandler{canHandleResult: true, handleError: ErrTranscriptsDisabled}, &mockHandler{canHandleResult: true, handleResult: &ContentResult{Text: "html"}}},
+			wantErr:  ErrTranscriptsDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &ContentFetcher{client: server.Client(), handlers: tt.handlers, handlerFallback: tt.fallback}
+			result, err := fetcher.FetchContent(server.URL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("FetchContent() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || result.Text != tt.wantText {
+				t.Errorf("FetchContent() = %+v, %v, want text %q", result, err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestFetchContentNoMatchingHandler(t *testing.T) {
+	// Create test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("some content"))
+	}))
+	defer server.Close()
+
+	// Create handlers that won't match
+	handler1 := &mockHandler{canHandleResult: false}
+	handler2 := &mockHandler{canHandleResult: false}
+
+	fetcher := &ContentFetcher{
+		client:   server.Client(),
+		handlers: []ContentHandler{handler1, handler2},
+	}
+
+	result, err := fetcher.FetchContent(server.URL)
+
+	if result != nil {
+		t.Error("FetchContent() should return nil when no handler matches")
+	}
+
+	if err == nil {
+		t.Fatal("FetchContent() should return error when no handler matches")
+	}
+
+	expectedMsg := "no handler found for " + server.URL
+	if err.Error() != expectedMsg {
+		t.Errorf("FetchContent() error = %q, want %q", err.Error(), expectedMsg)
+	}
+}
+
+func TestFetchContentContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fetcher := &ContentFetcher{
+		client:   server.Client(),
+		handlers: []ContentHandler{&mockHandler{canHandleResult: true}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := fetcher.FetchContentContext(ctx, server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchContentContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchContentHeadRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		headAllow bool
+		maxBytes  int64
+		wantGet   bool
+		wantIs    error
+	}{
+		{"head selects handler", true, 0, true, nil},
+		{"head rejects oversized content", true, 5, false, ErrContentTooLarge},
+		{"falls back to get without head support", false, 0, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotGet := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead && !tt.headAllow {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				if r.Method == http.MethodGet {
+					gotGet = true
+				}
+				w.Header().Set("Content-Type", "application/pdf")
+				w.Write([]byte("0123456789"))
+			}))
+			defer server.Close()
+
+			fetcher := &ContentFetcher{
+				client:          server.Client(),
+				useHeadRequest:  true,
+				maxContentBytes: tt.maxBytes,
+				handlers: []ContentHandler{
+					&mockHandler{canHandleResult: true, handleResult: &ContentResult{Text: "ok"}},
+				},
+			}
+
+			_, err := fetcher.FetchContent(server.URL)
+
+			if tt.wantIs != nil {
+				if !errors.Is(err, tt.wantIs) {
+					t.Errorf("FetchContent() error = %v, want %v", err, tt.wantIs)
+				}
+			} else if err != nil {
+				t.Fatalf("FetchContent() error = %v", err)
+			}
+
+			if gotGet != tt.wantGet {
+				t.Errorf("GET issued = %v, want %v", gotGet, tt.wantGet)
+			}
+		})
+	}
+}
+
+func TestHasNoindex(t *testing.T) {
+	tests := []struct {
+		directives string
+		expected   bool
+	}{
+		{"noindex", true},
+		{"NOINDEX, nofollow", true},
+		{"none", true},
+		{"googlebot: noindex", true},
+		{"index, follow", false},
+		{"nofollow", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if result := hasNoindex(tt.directives); result != tt.expected {
+			t.Errorf("hasNoindex(%q) = %v, want %v", tt.directives, result, tt.expected)
+		}
+	}
+}
+
+func TestFetchContentSkipNoindex(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		body        string
+		skipNoindex bool
+		wantNoindex bool
+	}{
+		{"header noindex", "noindex", "<p>Hi</p>", true, true},
+		{"meta noindex", "", `<head><meta name="Robots" content="noindex,nofollow"></head><p>Hi</p>`, true, true},
+		{"indexable page", "", `<head><meta name="robots" content="index"></head><p>Hi</p>`, true, false},
+		{"option disabled", "noindex", `<meta name="robots" content="noindex"><p>Hi</p>`, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("X-Robots-Tag", tt.header)
+				}
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			settings := &Settings{SkipNoindex: tt.skipNoindex}
+			fetcher := NewContentFetcher("test-key", settings)
+			fetcher.client = server.Client()
+
+			_, err := fetcher.FetchContent(server.URL)
+			if errors.Is(err, ErrNoindex) != tt.wantNoindex {
+				t.Errorf("FetchContent() error = %v, want noindex %v", err, tt.wantNoindex)
+			}
+		})
+	}
+}
+
+func TestFetchContentConditional(t *testing.T) {
+	var lastIfNoneMatch, lastIfModifiedSince string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIfNoneMatch = r.Header.Get("If-None-Match")
+		lastIfModifiedSince = r.Header.Get("If-Modified-Since")
+		if lastIfNoneMatch == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 00:00:00 GMT")
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>Hello</p>"))
+	}))
+	defer server.Close()
+
+	settings := &Settings{CacheDirectory: t.TempDir()}
+	fetcher := NewContentFetcher("test-key", settings)
+	fetcher.client = server.Client()
+
+	result, err := fetcher.FetchContent(server.URL)
+	if err != nil {
+		t.Fatalf("first fetch error = %v", err)
+	}
+	if result.ETag != `"v1"` || result.LastModified != "Wed, 01 Jan 2025 00:00:00 GMT" {
+		t.Fatalf("validators = %q, %q", result.ETag, result.LastModified)
+	}
+	if loadValidators(settings.CacheDirectory, server.URL) != nil {
+		t.Fatal("validators stored by the fetch, before any article was saved")
+	}
+	saveValidators(settings.CacheDirectory, server.URL, result.ETag, result.LastModified)
+
+	// Plain fetches never send stored validators
+	if _, err := fetcher.FetchContent(server.URL); err != nil || lastIfNoneMatch != "" {
+		t.Fatalf("unconditional fetch error = %v, If-None-Match = %q", err, lastIfNoneMatch)
+	}
+
+	_, err = fetcher.FetchContentContext(withConditionalFetch(context.Background()), server.URL+"/")
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("conditional fetch error = %v, want ErrNotModified", err)
+	}
+	if lastIfModifiedSince != "Wed, 01 Jan 2025 00:00:00 GMT" {
+		t.Errorf("If-Modified-Since = %q", lastIfModifiedSince)
+	}
+
+	settings.DisableCache = true
+	fetcher = NewContentFetcher("test-key", settings)
+	fetcher.client = server.Client()
+	if _, err := fetcher.FetchContentContext(withConditionalFetch(context.Background()), server.URL); err != nil {
+		t.Errorf("fetch with cache disabled error = %v, want full fetch", err)
+	}
+}
+
+// contentTypeHandler handles responses whose Content-Type contains contentType
+type contentTypeHandler struct{ contentType string }
+
+func (h *contentTypeHandler) CanHandle(url string, resp *http.Response) bool {
+	return strings.Contains(resp.Header.Get("Content-Type"), h.contentType)
+}
+
+func (h *contentTypeHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &ContentResult{Text: h.contentType + " " + string(body)}, nil
+}
+
+func TestFetchContentForcedContentType(t *testing.T) {
+	heads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads++
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0x00, 0x01, 0x02}) // Not recognizable by sniffing
+	}))
+	defer server.Close()
+
+	fetcher := &ContentFetcher{
+		client:         server.Client(),
+		useHeadRequest: true,
+		handlers: []ContentHandler{
+			&contentTypeHandler{contentType: "application/pdf"},
+			&mockHandler{canHandleResult: true, handleResult: &ContentResult{Text: "fallback"}},
+		},
+	}
+
+	result, err := fetcher.FetchContent(server.URL)
+	if err != nil || result.Text != "fallback" {
+		t.Fatalf("FetchContent() = %v, %v, want fallback handler", result, err)
+	}
+
+	heads = 0
+	result, err = fetcher.FetchContentContext(withContentType(context.Background(), "application/pdf"), server.URL)
+	if err != nil {
+		t.Fatalf("FetchContentContext() error = %v", err)
+	}
+	if !strings.HasPrefix(result.Text, "application/pdf") {
+		t.Errorf("FetchContentContext() result %q, want application/pdf handler", result.Text)
+	}
+	if heads != 0 {
+		t.Errorf("HEAD requests = %d, want none when the content type is forced", heads)
+	}
+}
+
+func TestFetchContentSniffsGenericContentType(t *testing.T) {
+	pdf := "%PDF-1.7\n" + strings.Repeat("x", 2000) + "\n%%EOF"
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"pdf without content type", "", pdf, "application/pdf " + pdf},
+		{"pdf as octet-stream", "application/octet-stream", pdf, "application/pdf " + pdf},
+		{"html as octet-stream", "application/octet-stream", "<html><p>Hi</p></html>", "fallback"},
+		{"declared type trusted", "text/plain", pdf, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{tt.contentType} // Stop net/http sniffing it for us
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			fetcher := &ContentFetcher{
+				client: server.Client(),
+				handlers: []ContentHandler{
+					&contentTypeHandler{contentType: "application/pdf"},
+					&mockHandler{canHandleResult: true, handleResult: &ContentResult{Text: "fallback"}},
+				},
+			}
+
+			result, err := fetcher.FetchContent(server.URL)
+			if err != nil {
+				t.Fatalf("FetchContent() error = %v", err)
+			}
+			if result.Text != tt.want {
+				t.Errorf("FetchContent() = %.40q..., want %.40q...", result.Text, tt.want)
+			}
+		})
+	}
+}
+
+// peekingHandler reads the whole body in CanHandle and only accepts bodies starting with prefix
+type peekingHandler struct{ prefix string }
+
+func (h *peekingHandler) CanHandle(url string, resp *http.Response) bool {
+	body, _ := io.ReadAll(resp.Body)
+	return strings.HasPrefix(string(body), h.prefix)
+}
+
+func (h *peekingHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	body, err := io.ReadAll(resp.Body)
+	return &ContentResult{Text: h.prefix + ": " + string(body)}, err
+}
+
+func TestFetchContentHandlersShareBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("BETA full body"))
+	}))
+	defer server.Close()
+
+	fetcher := &ContentFetcher{
+		client:   server.Client(),
+		handlers: []ContentHandler{&peekingHandler{prefix: "ALPHA"}, &peekingHandler{prefix: "BETA"}},
+	}
+
+	result, err := fetcher.FetchContent(server.URL)
+	if err != nil {
+		t.Fatalf("FetchContent() error = %v", err)
+	}
+	if result.Text != "BETA: BETA full body" {
+		t.Errorf("FetchContent() = %q, want the second handler with the full body", result.Text)
+	}
+}
+
+func TestFetchContentFetchMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("BETA body"))
+	}))
+	defer server.Close()
+
+	fetcher := &ContentFetcher{
+		client:   server.Client(),
+		handlers: []ContentHandler{&peekingHandler{prefix: "BETA"}},
+	}
+
+	before := time.Now()
+	result, err := fetcher.FetchContent(server.URL + "/old")
+	if err != nil {
+		t.Fatalf("FetchContent() error = %v", err)
+	}
+	if result.HTTPStatus != http.StatusOK || result.FinalURL != server.URL+"/new" || result.FetchedAt.Before(before) {
+		t.Errorf("FetchContent() status = %d, final URL = %q, fetched at %v; want 200, %s/new, after %v", result.HTTPStatus, result.FinalURL, result.FetchedAt, server.URL, before)
+	}
+}
+
+func TestFetchContentMaxBytesWithoutContentLength(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>" + strings.Repeat("x", 100) + "</p>"))
+		w.(http.Flusher).Flush() // Chunked, no Content-Length
+		w.Write([]byte(strings.Repeat("y", 100)))
+	}))
+	defer server.Close()
+
+	fetcher := NewContentFetcher("test-key", &Settings{MaxContentBytes: 150})
+	fetcher.client = server.Client()
+
+	if _, err := fetcher.FetchContent(server.URL); !errors.Is(err, ErrContentTooLarge) {
+		t.Errorf("FetchContent() error = %v, want ErrContentTooLarge", err)
+	}
+}
+
+func TestFetchContentMaxBytesPerSecond(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>" + strings.Repeat("x", 2000) + "</p>"))
+	}))
+	defer server.Close()
+
+	fetcher := NewContentFetcher("test-key", &Settings{MaxBytesPerSecond: 20000})
+	fetcher.client = server.Client()
+
+	// Two concurrent fetches share the cap: ~4KB at 20KB/s takes at least ~200ms
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fetcher.FetchContent(server.URL); err != nil {
+				t.Errorf("FetchContent() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("fetches took %v, want at least 150ms at 20000 bytes/s", elapsed)
+	}
+}
+
+func TestFetchContentConsentCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if cookie, err := r.Cookie("CONSENT"); err == nil && cookie.Value == "YES" {
+			w.Write([]byte("<p>The article.</p>"))
+			return
+		}
+		w.Write([]byte(`<script>__tcfapi("getTCData", 2, cb)</script><p>Accept cookies to continue</p>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewContentFetcher("test-key", &Settings{})
+	fetcher.client = server.Client()
+	if _, err := fetcher.FetchContent(server.URL); !errors.Is(err, ErrConsentWall) {
+		t.Fatalf("FetchContent() without cookie error = %v, want ErrConsentWall", err)
+	}
+
+	settings := &Settings{}
+	settings.HTML.ConsentCookies = map[string]string{"127.0.0.1": "CONSENT=YES"}
+	fetcher = NewContentFetcher("test-key", settings)
+	fetcher.client = server.Client()
+	result, err := fetcher.FetchContent(server.URL)
+	if err != nil {
+		t.Fatalf("FetchContent() with cookie error = %v", err)
+	}
+	if !strings.Contains(result.Text, "The article.") {
+		t.Errorf("Text = %q, want the article", result.Text)
+	}
+}
+
+func TestFetchContentLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	fetcher := NewContentFetcher("", nil)
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		kind   ContentKind
+	}{
+		{"markdown", write("notes.md", "# Notes\n\nLocal markdown body"), "Local markdown body", KindText},
+		{"text", write("notes.txt", "Plain text body"), "Plain text body", KindText},
+		{"html", write("page.html", "<html><body><article><p>Local HTML body</p></article></body></html>"), "Local HTML body", KindHTML},
+		{"file url", "file://" + write("url.txt", "Body from file URL"), "Body from file URL", KindText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := fetcher.FetchContent(tt.source)
+			if err != nil {
+				t.Fatalf("FetchContent() error = %v", err)
+			}
+			if !strings.Contains(result.Text, tt.want) || result.Kind != tt.kind {
+				t.Errorf("FetchContent() = %q (kind %q), want containing %q (kind %q)", result.Text, result.Kind, tt.want, tt.kind)
+			}
+		})
+	}
+
+	if _, err := fetcher.FetchContent(write("empty.txt", "  \n")); !errors.Is(err, ErrNoContent) {
+		t.Errorf("FetchContent() on empty file error = %v, want ErrNoContent", err)
+	}
+	if _, err := fetcher.FetchContent(filepath.Join(dir, "missing.pdf")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("FetchContent() on missing file error = %v, want os.ErrNotExist", err)
+	}
+}
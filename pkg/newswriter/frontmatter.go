@@ -0,0 +1,461 @@
+package newswriter
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter dialects selectable with Settings.FrontmatterDialect
+const (
+	DialectYAML     = "yaml" // The news-writer fields as YAML (default)
+	DialectHugoYAML = "hugo-yaml"
+	DialectHugoTOML = "hugo-toml"
+	DialectJekyll   = "jekyll"
+	DialectZola     = "zola"
+)
+
+// defaultFrontmatterTemplate renders DialectYAML
+const defaultFrontmatterTemplate = `---
+title: "{{.Title}}"
+date: {{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}
+draft: {{.Draft}}
+categories: [{{range $i, $cat := .Categories}}{{if $i}}, {{end}}"{{$cat}}"{{end}}]
+tags: [{{range $i, $tag := .Tags}}{{if $i}}, {{end}}"{{$tag}}"{{end}}]
+planner_model: "{{.PlannerModel}}"
+writer_model: "{{.WriterModel}}"
+{{- if .PlannerPromptVariant}}
+planner_prompt_variant: "{{.PlannerPromptVariant}}"
+{{- end}}
+{{- if .PromptVersion}}
+prompt_version: "{{.PromptVersion}}"
+{{- end}}
+{{- if .Mode}}
+mode: "{{.Mode}}"
+{{- end}}
+deck: "{{.Deck}}"
+{{- if .MetaDescription}}
+meta_description: "{{.MetaDescription}}"
+{{- end}}
+{{- if .Keywords}}
+keywords: [{{range $i, $kw := .Keywords}}{{if $i}}, {{end}}"{{$kw}}"{{end}}]
+{{- end}}
+{{- if .Tone}}
+tone: "{{.Tone}}"
+{{- end}}
+{{- if .Audience}}
+audience: "{{.Audience}}"
+{{- end}}
+source_url: "{{.SourceURL}}"
+{{- if .OriginalURL}}
+original_url: "{{.OriginalURL}}"
+{{- end}}
+source_domain: "{{.SourceDomain}}"
+{{- if .SourceType}}
+source_type: "{{.SourceType}}"
+{{- end}}
+{{- if .Segment}}
+segment: {{.Segment}}
+{{- end}}
+{{- if not .SourceDate.IsZero}}
+source_date: {{.SourceDate.Format "2006-01-02T15:04:05Z07:00"}}
+{{- end}}
+{{- if not .FetchedAt.IsZero}}
+fetched_at: {{.FetchedAt.Format "2006-01-02T15:04:05Z07:00"}}
+{{- end}}
+{{- if .HTTPStatus}}
+http_status: {{.HTTPStatus}}
+{{- end}}
+{{- if .FinalURL}}
+final_url: "{{.FinalURL}}"
+{{- end}}
+{{- if .SourceLinks}}
+source_links: [{{range $i, $link := .SourceLinks}}{{if $i}}, {{end}}"{{$link}}"{{end}}]
+{{- end}}
+{{- if .Extra}}
+{{.Extra}}
+{{- end}}
+---
+
+{{.Content}}`
+
+// dialectFrontmatterTemplates render the SSG dialects. Fields the generator understands
+// (title, date, draft, description, taxonomies) use its names; the remaining news-writer
+// fields follow as custom parameters where the generator exposes them to templates.
+const dialectFrontmatterTemplates = `
+{{- define "yaml-params"}}
+deck: {{quote .Deck}}
+planner_model: {{quote .PlannerModel}}
+writer_model: {{quote .WriterModel}}
+{{- if .PlannerPromptVariant}}
+planner_prompt_variant: {{quote .PlannerPromptVariant}}
+{{- end}}
+{{- if .PromptVersion}}
+prompt_version: {{quote .PromptVersion}}
+{{- end}}
+{{- if .Mode}}
+mode: {{quote .Mode}}
+{{- end}}
+{{- if .Tone}}
+tone: {{quote .Tone}}
+{{- end}}
+{{- if .Audience}}
+audience: {{quote .Audience}}
+{{- end}}
+source_url: {{quote .SourceURL}}
+{{- if .OriginalURL}}
+original_url: {{quote .OriginalURL}}
+{{- end}}
+source_domain: {{quote .SourceDomain}}
+{{- if .SourceType}}
+source_type: {{quote (print .SourceType)}}
+{{- end}}
+{{- if .Segment}}
+segment: {{.Segment}}
+{{- end}}
+{{- if not .SourceDate.IsZero}}
+source_date: {{.SourceDate.Format "2006-01-02T15:04:05Z07:00"}}
+{{- end}}
+{{- if not .FetchedAt.IsZero}}
+fetched_at: {{.FetchedAt.Format "2006-01-02T15:04:05Z07:00"}}
+{{- end}}
+{{- if .HTTPStatus}}
+http_status: {{.HTTPStatus}}
+{{- end}}
+{{- if .FinalURL}}
+final_url: {{quote .FinalURL}}
+{{- end}}
+{{- if .SourceLinks}}
+source_links: {{list .SourceLinks}}
+{{- end}}
+{{- if .Extra}}
+{{.Extra}}
+{{- end}}
+{{- end}}
+
+{{- define "toml-params"}}
+deck = {{quote .Deck}}
+planner_model = {{quote .PlannerModel}}
+writer_model = {{quote .WriterModel}}
+{{- if .PlannerPromptVariant}}
+planner_prompt_variant = {{quote .PlannerPromptVariant}}
+{{- end}}
+{{- if .PromptVersion}}
+prompt_version = {{quote .PromptVersion}}
+{{- end}}
+{{- if .Mode}}
+mode = {{quote .Mode}}
+{{- end}}
+{{- if .Tone}}
+tone = {{quote .Tone}}
+{{- end}}
+{{- if .Audience}}
+audience = {{quote .Audience}}
+{{- end}}
+source_url = {{quote .SourceURL}}
+{{- if .OriginalURL}}
+original_url = {{quote .OriginalURL}}
+{{- end}}
+source_domain = {{quote .SourceDomain}}
+{{- if .SourceType}}
+source_type = {{quote (print .SourceType)}}
+{{- end}}
+{{- if .Segment}}
+segment = {{.Segment}}
+{{- end}}
+{{- if not .SourceDate.IsZero}}
+source_date = {{.SourceDate.Format "2006-01-02T15:04:05Z07:00"}}
+{{- end}}
+{{- if not .FetchedAt.IsZero}}
+fetched_at = {{.FetchedAt.Format "2006-01-02T15:04:05Z07:00"}}
+{{- end}}
+{{- if .HTTPStatus}}
+http_status = {{.HTTPStatus}}
+{{- end}}
+{{- if .FinalURL}}
+final_url = {{quote .FinalURL}}
+{{- end}}
+{{- if .SourceLinks}}
+source_links = {{list .SourceLinks}}
+{{- end}}
+{{- if .Extra}}
+{{.Extra}}
+{{- end}}
+{{- end}}
+
+{{- define "hugo-yaml"}}---
+title: {{quote .Title}}
+date: {{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}
+draft: {{.Draft}}
+description: {{quote .Description}}
+categories: {{list .Categories}}
+tags: {{list .Tags}}
+{{- if .Keywords}}
+keywords: {{list .Keywords}}
+{{- end}}
+{{- template "yaml-params" .}}
+---
+
+{{.Content}}
+{{- end}}
+
+{{- define "hugo-toml"}}+++
+title = {{quote .Title}}
+date = {{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}
+draft = {{.Draft}}
+description = {{quote .Description}}
+categories = {{list .Categories}}
+tags = {{list .Tags}}
+{{- if .Keywords}}
+keywords = {{list .Keywords}}
+{{- end}}
+{{- template "toml-params" .}}
++++
+
+{{.Content}}
+{{- end}}
+
+{{- define "jekyll"}}---
+layout: post
+title: {{quote .Title}}
+date: {{.CreatedAt.Format "2006-01-02 15:04:05 -0700"}}
+{{- if .Draft}}
+published: false
+{{- end}}
+description: {{quote .Description}}
+categories: {{list .Categories}}
+tags: {{list .Tags}}
+{{- if .Keywords}}
+keywords: {{list .Keywords}}
+{{- end}}
+{{- template "yaml-params" .}}
+---
+
+{{.Content}}
+{{- end}}
+
+{{- define "zola"}}+++
+title = {{quote .Title}}
+date = {{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}
+draft = {{.Draft}}
+description = {{quote .Description}}
+
+[taxonomies]
+categories = {{list .Categories}}
+tags = {{list .Tags}}
+
+[extra]
+{{- if .Keywords}}
+keywords = {{list .Keywords}}
+{{- end}}
+{{- template "toml-params" .}}
++++
+
+{{.Content}}
+{{- end}}`
+
+var frontmatterTemplates = template.Must(template.New("article").Funcs(template.FuncMap{
+	"quote": quoteFrontmatter,
+	"list": func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = quoteFrontmatter(v)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+}).Parse(dialectFrontmatterTemplates))
+
+// frontmatterDialects lists the valid Settings.FrontmatterDialect values
+var frontmatterDialects = []string{DialectYAML, DialectHugoYAML, DialectHugoTOML, DialectJekyll, DialectZola}
+
+// isTOMLDialect reports whether dialect writes TOML frontmatter between +++ lines
+func isTOMLDialect(dialect string) bool {
+	return dialect == DialectHugoTOML || dialect == DialectZola
+}
+
+// dialectFrontmatterKeys are rendered by the SSG dialects on top of coreFrontmatterKeys
+var dialectFrontmatterKeys = map[string]bool{"description": true, "layout": true, "published": true}
+
+// quoteFrontmatter quotes s as a double-quoted string valid in both YAML and TOML
+func quoteFrontmatter(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// renderExtraFrontmatter renders extra frontmatter with keys sorted alphabetically, so
+// regenerated files only differ where values do. Keys the dialect renders itself are ignored.
+func renderExtraFrontmatter(extra map[string]any, dialect string) (string, error) {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if coreFrontmatterKeys[key] || (dialect != DialectYAML && dialectFrontmatterKeys[key]) {
+			log.Printf("Warning: extra frontmatter key %q conflicts with a core field, ignoring", key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out strings.Builder
+	for _, key := range keys {
+		if isTOMLDialect(dialect) {
+			value, err := tomlValue(extra[key])
+			if err != nil {
+				return "", fmt.Errorf("rendering frontmatter %q: %w", key, err)
+			}
+			fmt.Fprintf(&out, "%s = %s\n", tomlKey(key), value)
+			continue
+		}
+		value, err := yaml.Marshal(map[string]any{key: extra[key]})
+		if err != nil {
+			return "", fmt.Errorf("rendering frontmatter %q: %w", key, err)
+		}
+		out.Write(value)
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey returns key bare when TOML allows it, quoted otherwise
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return quoteFrontmatter(key)
+}
+
+// tomlValue encodes a value decoded from YAML settings as an inline TOML value
+func tomlValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return quoteFrontmatter(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int64, uint64:
+		return fmt.Sprint(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case []string:
+		values := make([]any, len(v))
+		for i, item := range v {
+			values[i] = item
+		}
+		return tomlValue(values)
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			value, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			values[i] = value
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			value, err := tomlValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			fields[i] = tomlKey(key) + " = " + value
+		}
+		return "{" + strings.Join(fields, ", ") + "}", nil
+	default:
+		return "", fmt.Errorf("unsupported TOML value %T", v)
+	}
+}
+
+// existingArticle is what a frontmatter-only refresh keeps of an article on disk,
+// and the source URL existing articles are found by
+type existingArticle struct {
+	Body        string
+	Date        time.Time // Zero when the date can't be parsed
+	WriterModel string
+	SourceURL   string
+}
+
+var (
+	frontmatterDatePattern        = regexp.MustCompile(`(?m)^date\s*[:=]\s*"?([^"\n]+?)"?\s*$`)
+	frontmatterWriterModelPattern = regexp.MustCompile(`(?m)^writer_model\s*[:=]\s*"([^"]*)"`)
+	frontmatterSourceURLPattern   = regexp.MustCompile(`(?m)^source_url\s*[:=]\s*("(?:[^"\\\n]|\\.)*")\s*$`)
+)
+
+// frontmatterDateLayouts are the date formats the frontmatter dialects write
+var frontmatterDateLayouts = []string{"2006-01-02T15:04:05Z07:00", "2006-01-02 15:04:05 -0700"}
+
+// readArticleFile splits an article written by renderArticle into its frontmatter
+// fields and body. Both YAML (---) and TOML (+++) frontmatter are supported.
+func readArticleFile(filename string) (*existingArticle, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading article: %w", err)
+	}
+	content := string(data)
+
+	delimiter := "---"
+	if strings.HasPrefix(content, "+++") {
+		delimiter = "+++"
+	}
+	if !strings.HasPrefix(content, delimiter) {
+		return nil, fmt.Errorf("reading article %s: no frontmatter", filename)
+	}
+	frontmatter, body, ok := strings.Cut(content[len(delimiter):], "\n"+delimiter+"\n")
+	if !ok {
+		return nil, fmt.Errorf("reading article %s: unterminated frontmatter", filename)
+	}
+
+	existing := &existingArticle{Body: strings.TrimPrefix(body, "\n")}
+	if m := frontmatterDatePattern.FindStringSubmatch(frontmatter); m != nil {
+		for _, layout := range frontmatterDateLayouts {
+			if date, err := time.Parse(layout, m[1]); err == nil {
+				existing.Date = date
+				break
+			}
+		}
+	}
+	if m := frontmatterWriterModelPattern.FindStringSubmatch(frontmatter); m != nil {
+		existing.WriterModel = m[1]
+	}
+	if m := frontmatterSourceURLPattern.FindStringSubmatch(frontmatter); m != nil {
+		// quoteFrontmatter escapes are a subset of Go's; the default template doesn't escape
+		if url, err := strconv.Unquote(m[1]); err == nil {
+			existing.SourceURL = url
+		} else {
+			existing.SourceURL = m[1][1 : len(m[1])-1]
+		}
+	}
+	return existing, nil
+}
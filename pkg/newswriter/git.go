@@ -0,0 +1,92 @@
+package newswriter
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// autoCommit stages new and changed files under the output directory and commits
+// them with a summary message, pushing when Settings.Git.Push is set. It does
+// nothing when the output directory isn't inside a git work tree.
+func (p *ArticleProcessor) autoCommit() error {
+	dir := p.config.Settings.OutputDirectory
+
+	if out, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(out) != "true" {
+		log.Printf("→ Skipping auto-commit: %s is not in a git repository", dir)
+		return nil
+	}
+
+	if _, err := runGit(dir, "add", "-A", "--", "."); err != nil {
+		return err
+	}
+
+	status, err := runGit(dir, "diff", "--cached", "--name-status", "--", ".")
+	if err != nil {
+		return err
+	}
+	message := commitMessage(status)
+	if message == "" {
+		log.Printf("→ Skipping auto-commit: no changes in %s", dir)
+		return nil
+	}
+
+	if _, err := runGit(dir, "commit", "-m", message, "--", "."); err != nil {
+		return err
+	}
+	log.Printf("✓ Committed: %s", message)
+
+	if p.config.Settings.Git.Push {
+		if _, err := runGit(dir, "push"); err != nil {
+			return err
+		}
+		log.Printf("✓ Pushed")
+	}
+	return nil
+}
+
+// commitMessage summarizes `git diff --name-status` output, e.g. "news-writer: 2 added, 1 updated"
+func commitMessage(nameStatus string) string {
+	var added, updated, deleted int
+	for _, line := range strings.Split(strings.TrimSpace(nameStatus), "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'A':
+			added++
+		case 'D':
+			deleted++
+		default:
+			updated++
+		}
+	}
+
+	var parts []string
+	for _, c := range []struct {
+		n    int
+		verb string
+	}{{added, "added"}, {updated, "updated"}, {deleted, "deleted"}} {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.n, c.verb))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "news-writer: " + strings.Join(parts, ", ")
+}
+
+// runGit runs git in dir and returns its stdout
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
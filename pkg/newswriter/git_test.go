@@ -0,0 +1,76 @@
+package newswriter
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"", ""},
+		{"A\ta.md\nA\tb.md\nM\tc.md\n", "news-writer: 2 added, 1 updated"},
+		{"D\told.md\n", "news-writer: 1 deleted"},
+	}
+	for _, tt := range tests {
+		if got := commitMessage(tt.status); got != tt.want {
+			t.Errorf("commitMessage(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestAutoCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	t.Run("not a repository", func(t *testing.T) {
+		p := &ArticleProcessor{config: &Config{Settings: &Settings{OutputDirectory: t.TempDir()}}}
+		if err := p.autoCommit(); err != nil {
+			t.Errorf("autoCommit() error = %v", err)
+		}
+	})
+
+	t.Run("commits output directory", func(t *testing.T) {
+		repo := t.TempDir()
+		if _, err := runGit(repo, "init", "-q"); err != nil {
+			t.Fatal(err)
+		}
+		outputDir := filepath.Join(repo, "articles")
+		os.MkdirAll(outputDir, 0755)
+		os.WriteFile(filepath.Join(outputDir, "a.md"), []byte("a"), 0644)
+		os.WriteFile(filepath.Join(repo, "unrelated.txt"), []byte("x"), 0644)
+
+		p := &ArticleProcessor{config: &Config{Settings: &Settings{OutputDirectory: outputDir}}}
+		if err := p.autoCommit(); err != nil {
+			t.Fatalf("autoCommit() error = %v", err)
+		}
+
+		log, _ := runGit(repo, "log", "--format=%s")
+		if strings.TrimSpace(log) != "news-writer: 1 added" {
+			t.Errorf("log = %q", log)
+		}
+		status, _ := runGit(repo, "status", "--porcelain")
+		if strings.TrimSpace(status) != "?? unrelated.txt" {
+			t.Errorf("status = %q, want only unrelated.txt untracked", status)
+		}
+
+		// A second run with no changes makes no commit
+		if err := p.autoCommit(); err != nil {
+			t.Fatalf("autoCommit() error = %v", err)
+		}
+		count, _ := runGit(repo, "rev-list", "--count", "HEAD")
+		if strings.TrimSpace(count) != "1" {
+			t.Errorf("commit count = %q, want 1", count)
+		}
+	})
+}
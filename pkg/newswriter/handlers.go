@@ -0,0 +1,718 @@
+package newswriter
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/aktagon/llmkit/anthropic"
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+// HTTPError represents an HTTP error with status code
+type HTTPError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d for %s", e.StatusCode, e.URL)
+}
+
+// Transcript API errors mapped from known status codes
+var (
+	ErrTranscriptsDisabled = errors.New("transcripts disabled for video")
+	ErrRateLimited         = errors.New("transcript API rate limited")
+	ErrUnauthorized        = errors.New("transcript API key rejected")
+	ErrNoCaptions          = errors.New("no caption tracks available for video")
+	ErrTranscriptTooShort  = errors.New("transcript too short")
+)
+
+// ContentHandler processes URLs based on response inspection. The fetcher buffers the
+// body, so CanHandle may read resp.Body and Handle still receives it from the start.
+type ContentHandler interface {
+	CanHandle(url string, resp *http.Response) bool
+	Handle(url string, resp *http.Response) (*ContentResult, error)
+}
+
+// ContextHandler is implemented by handlers that make requests of their own; the fetcher
+// calls HandleContext instead of Handle so cancellation and per_url_timeout apply to them
+type ContextHandler interface {
+	HandleContext(ctx context.Context, url string, resp *http.Response) (*ContentResult, error)
+}
+
+// Global rate limiter for YouTube API calls
+var (
+	youtubeMutex     sync.Mutex
+	lastYouTubeCall  time.Time
+	youtubeCallDelay = 2 * time.Second // Minimum delay between API calls
+	debugEnabled     bool
+
+	// Serializes fetches per video ID so concurrent requests share one API call
+	transcriptLocks keyedMutex
+)
+
+// SetDebugMode enables or disables debug logging
+func SetDebugMode(enabled bool) {
+	debugEnabled = enabled
+}
+
+// youtubeTimedTextURL is YouTube's caption track endpoint used by the caption fallback
+var youtubeTimedTextURL = "https://www.youtube.com/api/timedtext"
+
+// YouTubeHandler handles YouTube videos
+type YouTubeHandler struct {
+	captionFallback    bool   // Fetch YouTube caption tracks when the transcript API fails
+	disableCache       bool   // Ignore cached transcripts (fresh ones are still cached)
+	cacheDir           string // Cache root; defaults to .cache
+	minTranscriptChars int    // Shorter transcripts (e.g. music videos) are ErrTranscriptTooShort
+
+	stripPatterns []string // Regexes removed from transcripts, e.g. "Transcribed by ..." boilerplate
+}
+
+func (h *YouTubeHandler) CanHandle(url string, resp *http.Response) bool {
+	return strings.Contains(url, "youtube.com/watch") ||
+		strings.Contains(url, "youtu.be/")
+}
+
+func (h *YouTubeHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	return h.HandleContext(context.Background(), url, resp)
+}
+
+// HandleContext fetches the transcript, aborting the transcript and caption requests when ctx is done
+func (h *YouTubeHandler) HandleContext(ctx context.Context, url string, resp *http.Response) (*ContentResult, error) {
+	result, err := h.fetchTranscript(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cleaned on use rather than before caching, so pattern changes apply to cached transcripts
+	result.Text, err = cleanTranscript(result.Text, h.stripPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	// The watch page carries the publish date in its microdata
+	if resp != nil {
+		if doc, err := goquery.NewDocumentFromReader(resp.Body); err == nil {
+			result.SourceDate = sourceDate(doc)
+		}
+	}
+
+	// Near-empty transcripts yield nonsense articles
+	if n := utf8.RuneCountInString(strings.TrimSpace(result.Text)); n == 0 || n < h.minTranscriptChars {
+		return nil, fmt.Errorf("%w: %d characters, minimum is %d", ErrTranscriptTooShort, n, max(h.minTranscriptChars, 1))
+	}
+	return result, nil
+}
+
+// strippedMarker replaces transcript strip pattern matches until lines are cleaned up,
+// so lines that only held boilerplate are dropped instead of left blank
+const strippedMarker = "\x00"
+
+// cleanTranscript removes matches of patterns from a transcript, then drops caption
+// lines that repeat the line before them and collapses runs of blank lines
+func cleanTranscript(transcript string, patterns []string) (string, error) {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid transcript strip pattern: %w", err)
+		}
+		transcript = re.ReplaceAllLiteralString(transcript, strippedMarker)
+	}
+
+	var lines []string
+	previous := ""
+	for _, line := range strings.Split(transcript, "\n") {
+		stripped := strings.Contains(line, strippedMarker)
+		trimmed := strings.TrimSpace(strings.ReplaceAll(line, strippedMarker, ""))
+		switch {
+		case trimmed == "" && stripped:
+		case trimmed == "":
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+		case trimmed != previous:
+			previous = trimmed
+			lines = append(lines, trimmed)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// fetchTranscript fetches the transcript from the transcript API, falling back to caption tracks
+func (h *YouTubeHandler) fetchTranscript(ctx context.Context, url string) (*ContentResult, error) {
+	// Load settings from environment
+	apiKey := os.Getenv("YOUTUBE_TRANSCRIPT_API_KEY")
+	apiURL := os.Getenv("YOUTUBE_TRANSCRIPT_API_URL")
+
+	if apiKey == "" || apiURL == "" {
+		if h.captionFallback {
+			return h.handleCaptions(ctx, url, fmt.Errorf("YouTube API configuration missing"))
+		}
+		return nil, fmt.Errorf("YouTube API configuration missing: set YOUTUBE_TRANSCRIPT_API_KEY and YOUTUBE_TRANSCRIPT_API_URL")
+	}
+
+	cacheDir := h.cacheDir
+	if cacheDir == "" {
+		cacheDir = DefaultCacheDirectory
+	}
+
+	transcript, err := getTranscript(ctx, url, apiKey, apiURL, cacheDir, !h.disableCache)
+	if err != nil {
+		if h.captionFallback && ctx.Err() == nil {
+			return h.handleCaptions(ctx, url, err)
+		}
+		return nil, fmt.Errorf("fetching YouTube transcript: %w", err)
+	}
+
+	return &ContentResult{Text: transcript, Kind: KindYouTube}, nil
+}
+
+// handleCaptions fetches YouTube's own caption tracks after the transcript API failed
+func (h *YouTubeHandler) handleCaptions(ctx context.Context, url string, apiErr error) (*ContentResult, error) {
+	logf(ctx, "→ Transcript API failed (%v), trying caption tracks", apiErr)
+
+	videoID, err := extractVideoID(url)
+	if err != nil {
+		return nil, fmt.Errorf("extracting video ID: %w", err)
+	}
+
+	captions, err := fetchCaptions(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching YouTube captions: %w (transcript API: %w)", err, apiErr)
+	}
+
+	return &ContentResult{Text: captions, Kind: KindYouTube}, nil
+}
+
+// uploadFile uploads a file to the Anthropic Files API; replaced in tests
+var uploadFile = anthropic.UploadFile
+
+// PDFHandler handles PDF content
+type PDFHandler struct {
+	apiKey     string
+	keepSource bool          // Keep the downloaded PDF for the caller (ContentResult.SourceFile)
+	uploads    chan struct{} // Limits concurrent uploads when non-nil
+	client     *http.Client  // Uploads to agents.base_url when non-nil
+}
+
+func (h *PDFHandler) CanHandle(url string, resp *http.Response) bool {
+	// Check URL extension first
+	if strings.HasSuffix(strings.ToLower(url), ".pdf") {
+		return true
+	}
+
+	// Check content-type header
+	contentType := resp.Header.Get("Content-Type")
+	return strings.Contains(contentType, "application/pdf")
+}
+
+func (h *PDFHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	return h.HandleContext(context.Background(), url, resp)
+}
+
+// HandleContext downloads and uploads the PDF, giving up waiting for an upload slot when ctx is done
+func (h *PDFHandler) HandleContext(ctx context.Context, url string, resp *http.Response) (*ContentResult, error) {
+	// Download PDF content to a temporary file
+	tempFile, err := os.CreateTemp("", "pdf-*.pdf")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(tempFile.Name()) // Clean up temp file
+		}
+	}()
+	defer tempFile.Close()
+
+	// Copy PDF content from response to temp file
+	_, err = io.Copy(tempFile, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading PDF content: %w", err)
+	}
+
+	// Close the file so it can be opened by UploadFile
+	tempFile.Close()
+
+	// Upload PDF file to Anthropic for processing
+	release, err := acquireSlot(ctx, h.uploads)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for an upload slot: %w", err)
+	}
+	defer release()
+	upload := uploadFile
+	if h.client != nil {
+		upload = func(filePath, apiKey string) (*types.File, error) {
+			return uploadFileWithClient(h.client, filePath, apiKey)
+		}
+	}
+	file, err := upload(tempFile.Name(), h.apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("uploading PDF file: %w", err)
+	}
+
+	result := &ContentResult{FileID: file.ID, Kind: KindPDF}
+	if h.keepSource {
+		keep = true
+		result.SourceFile = tempFile.Name()
+	}
+
+	return result, nil
+}
+
+// HTMLHandler handles regular HTML content (fallback)
+type HTMLHandler struct {
+	converter       *md.Converter
+	removeSelectors []string // CSS selectors removed from the DOM before conversion
+	skipNoindex     bool     // Reject pages with <meta name="robots" content="noindex">
+	minContentChars int      // Reject pages whose converted text is shorter; zero rejects only empty pages
+}
+
+func (h *HTMLHandler) CanHandle(url string, resp *http.Response) bool {
+	return true // Always handles as fallback
+}
+
+func (h *HTMLHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing HTML: %w", err)
+	}
+
+	if h.skipNoindex {
+		noindex := false
+		doc.Find("meta[name]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+			if strings.EqualFold(s.AttrOr("name", ""), "robots") && hasNoindex(s.AttrOr("content", "")) {
+				noindex = true
+			}
+			return !noindex
+		})
+		if noindex {
+			return nil, fmt.Errorf("%w: meta robots on %s", ErrNoindex, url)
+		}
+	}
+
+	// Resolve relative links against the final URL (after redirects)
+	pageURL := url
+	if resp.Request != nil && resp.Request.URL != nil {
+		pageURL = resp.Request.URL.String()
+	}
+	absolutizeURLs(doc, pageURL)
+
+	// Look for consent markers before remove_selectors strips banners from the page
+	consentDoc := doc
+	if len(h.removeSelectors) > 0 {
+		consentDoc = goquery.CloneDocument(doc)
+	}
+	for _, selector := range h.removeSelectors {
+		doc.Find(selector).Remove()
+	}
+
+	links := extractLinks(doc, pageURL)
+	published := sourceDate(doc)
+	markdown := h.converter.Convert(doc.Selection)
+
+	// Count text with whitespace collapsed so layout-only output counts as empty
+	chars := utf8.RuneCountInString(strings.Join(strings.Fields(markdown), " "))
+	if chars < consentWallMaxChars {
+		if marker := consentMarker(consentDoc, markdown); marker != "" {
+			return nil, fmt.Errorf("%w: %q on %s (set html.consent_cookies for the domain to get past it)", ErrConsentWall, marker, url)
+		}
+	}
+	if chars == 0 || chars < h.minContentChars {
+		return nil, fmt.Errorf("%w: %d characters extracted from %s", ErrNoContent, chars, url)
+	}
+
+	return &ContentResult{Text: markdown, Links: links, Kind: KindHTML, SourceDate: published}, nil
+}
+
+// extractLinks returns the http(s) anchors in doc in document order, without fragments,
+// duplicates or links back to pageURL. Links must already be absolute.
+func extractLinks(doc *goquery.Document, pageURL string) []string {
+	seen := map[string]bool{normalizeURL(pageURL): true}
+	var links []string
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		link, err := url.Parse(s.AttrOr("href", ""))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return
+		}
+		link.Fragment = ""
+		link.RawFragment = ""
+
+		key := normalizeURL(link.String())
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		links = append(links, link.String())
+	})
+	return links
+}
+
+// sourceDateSelectors find a page's publish date, most specific first: Open Graph
+// article metadata, schema.org microdata (YouTube watch pages), then <time datetime>
+var sourceDateSelectors = []struct {
+	selector, attr string
+}{
+	{`meta[property="article:published_time"]`, "content"},
+	{`meta[property="og:article:published_time"]`, "content"},
+	{`meta[name="article:published_time"]`, "content"},
+	{`meta[itemprop="datePublished"]`, "content"},
+	{`meta[itemprop="uploadDate"]`, "content"},
+	{`time[datetime]`, "datetime"},
+}
+
+// sourceDateLayouts are the date formats accepted in publish date metadata
+var sourceDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// sourceDate returns the publish date declared in doc, or the zero time when it has none
+func sourceDate(doc *goquery.Document) time.Time {
+	for _, s := range sourceDateSelectors {
+		var published time.Time
+		doc.Find(s.selector).EachWithBreak(func(i int, sel *goquery.Selection) bool {
+			published = parseSourceDate(sel.AttrOr(s.attr, ""))
+			return published.IsZero()
+		})
+		if !published.IsZero() {
+			return published
+		}
+	}
+	return time.Time{}
+}
+
+func parseSourceDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range sourceDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// absolutizeURLs rewrites relative and protocol-relative href/src attributes to absolute URLs
+func absolutizeURLs(doc *goquery.Document, pageURL string) {
+	base, err := url.Parse(pageURL)
+	if err != nil || !base.IsAbs() {
+		return
+	}
+
+	for _, attr := range []string{"href", "src"} {
+		doc.Find("[" + attr + "]").Each(func(i int, s *goquery.Selection) {
+			raw := strings.TrimSpace(s.AttrOr(attr, ""))
+			if raw == "" || strings.HasPrefix(raw, "#") {
+				return
+			}
+
+			ref, err := url.Parse(raw)
+			if err != nil || (ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https") {
+				return // Leave mailto:, data:, javascript: etc. alone
+			}
+
+			s.SetAttr(attr, base.ResolveReference(ref).String())
+		})
+	}
+}
+
+// newMarkdownConverter builds the HTML to markdown converter from Settings.Markdown
+func newMarkdownConverter(settings *Settings) *md.Converter {
+	opts := settings.Markdown
+	converter := md.NewConverter(opts.Domain, true, &md.Options{
+		HeadingStyle:   opts.HeadingStyle,
+		CodeBlockStyle: opts.CodeBlockStyle,
+		Fence:          opts.Fence,
+	})
+
+	if opts.StripImages {
+		converter.AddRules(md.Rule{
+			Filter: []string{"img"},
+			Replacement: func(content string, selec *goquery.Selection, options *md.Options) *string {
+				return md.String("")
+			},
+		})
+	}
+
+	if opts.StripLinks {
+		converter.AddRules(md.Rule{
+			Filter: []string{"a"},
+			Replacement: func(content string, selec *goquery.Selection, options *md.Options) *string {
+				return md.String(content)
+			},
+		})
+	}
+
+	return converter
+}
+
+// YouTube transcript functions
+
+// getTranscript returns the transcript for a video, reading the cache first when readCache is set
+func getTranscript(ctx context.Context, videoURL, apiKey, apiURL, cacheDir string, readCache bool) (string, error) {
+	videoID, err := extractVideoID(videoURL)
+	if err != nil {
+		return "", fmt.Errorf("extracting video ID: %w", err)
+	}
+
+	// Concurrent fetches of the same video wait here and then hit the cache
+	unlock := transcriptLocks.Lock(videoID)
+	defer unlock()
+
+	// Check cache
+	cachePath := filepath.Join(cacheDir, "youtube", videoID)
+	if readCache {
+		if content, err := os.ReadFile(cachePath); err == nil {
+			return string(content), nil
+		}
+	} else {
+		debugf(ctx, "Cache disabled, fetching fresh transcript for %s", videoID)
+	}
+
+	// Fetch with retries (increased from 3 to 5 for rate limit handling)
+	transcript, err := fetchTranscriptWithRetries(ctx, videoID, apiKey, apiURL, 5)
+	if err != nil {
+		return "", err
+	}
+
+	// Cache result
+	if err := writeFileAtomic(cachePath, []byte(transcript)); err != nil {
+		logf(ctx, "Warning: caching transcript for %s: %v", videoID, err)
+	}
+
+	return transcript, nil
+}
+
+func extractVideoID(videoURL string) (string, error) {
+	parsedURL, err := url.Parse(videoURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Validate YouTube domain
+	if !strings.Contains(parsedURL.Host, "youtube.com") && !strings.Contains(parsedURL.Host, "youtu.be") {
+		return "", fmt.Errorf("not a YouTube URL")
+	}
+
+	// Handle youtu.be URLs
+	if strings.Contains(parsedURL.Host, "youtu.be") {
+		return strings.TrimPrefix(parsedURL.Path, "/"), nil
+	}
+
+	// Handle youtube.com URLs
+	videoID := parsedURL.Query().Get("v")
+	if videoID == "" {
+		return "", fmt.Errorf("no video ID found in URL")
+	}
+	return videoID, nil
+}
+
+func fetchTranscriptWithRetries(ctx context.Context, videoID, apiKey, apiURL string, retries int) (string, error) {
+	var lastErr error
+	for i := 0; i < retries; i++ {
+		transcript, err := fetchTranscript(ctx, videoID, apiKey, apiURL)
+		if err == nil {
+			return transcript, nil
+		}
+		lastErr = err
+
+		// Check for rate limit errors (either HTTP 429 or API service 429)
+		isRateLimit := errors.Is(err, ErrRateLimited)
+		// Also check for 429 errors reported by the transcript service
+		if strings.Contains(err.Error(), "too many 429 error responses") ||
+			strings.Contains(err.Error(), "429") {
+			isRateLimit = true
+		}
+
+		if isRateLimit && i < retries-1 {
+			// Exponential backoff with jitter: 2^i + random(0-1) seconds
+			backoff := time.Duration(1<<uint(i)) * time.Second
+			jitter := time.Duration(float64(time.Second) * 0.5 * (1.0 + float64(i)))
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(backoff + jitter):
+			}
+			continue
+		}
+
+		// For non-rate-limit errors, don't retry
+		if !isRateLimit {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("exceeded max retries after %d attempts: %w", retries, lastErr)
+}
+
+func fetchTranscript(ctx context.Context, videoID, apiKey, apiURL string) (string, error) {
+	// Rate limit YouTube API calls
+	youtubeMutex.Lock()
+	timeSinceLastCall := time.Since(lastYouTubeCall)
+	if timeSinceLastCall < youtubeCallDelay {
+		time.Sleep(youtubeCallDelay - timeSinceLastCall)
+	}
+	lastYouTubeCall = time.Now()
+	youtubeMutex.Unlock()
+
+	videoURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	q := req.URL.Query()
+	q.Add("url", videoURL)
+	q.Add("api_key", apiKey)
+	q.Add("text", "true")
+	req.URL.RawQuery = q.Encode()
+
+	client := &http.Client{
+		Timeout: 30 * time.Second, // Add timeout to prevent hanging
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Debug logging for response
+	debugf(ctx, "YouTube transcript API response: status=%d", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", transcriptStatusError(resp.StatusCode, videoURL)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// Debug logging for first 100 chars of body
+	bodyStr := string(body)
+	preview := bodyStr
+	if len(preview) > 100 {
+		preview = preview[:100]
+	}
+	debugf(ctx, "YouTube transcript API body (first 100 chars): %q", preview)
+
+	return bodyStr, nil
+}
+
+// transcriptStatusError maps a non-200 transcript API status to a typed error
+func transcriptStatusError(statusCode int, videoURL string) error {
+	httpErr := &HTTPError{StatusCode: statusCode, URL: videoURL}
+	switch statusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrTranscriptsDisabled, httpErr)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimited, httpErr)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %w", ErrUnauthorized, httpErr)
+	default:
+		return httpErr
+	}
+}
+
+// captionTrackList is the response of the timedtext track listing
+type captionTrackList struct {
+	Tracks []struct {
+		LangCode string `xml:"lang_code,attr"`
+		Name     string `xml:"name,attr"`
+	} `xml:"track"`
+}
+
+// captionTranscript is a single timedtext caption track
+type captionTranscript struct {
+	Texts []string `xml:"text"`
+}
+
+// fetchCaptions downloads a caption track for the video and returns its plain text
+func fetchCaptions(ctx context.Context, videoID string) (string, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	// List available caption tracks
+	var list captionTrackList
+	if err := getTimedText(ctx, client, url.Values{"type": {"list"}, "v": {videoID}}, &list); err != nil {
+		return "", fmt.Errorf("listing caption tracks: %w", err)
+	}
+	if len(list.Tracks) == 0 {
+		return "", ErrNoCaptions
+	}
+
+	// Prefer English, otherwise take the first track
+	track := list.Tracks[0]
+	for _, t := range list.Tracks {
+		if t.LangCode == "en" || strings.HasPrefix(t.LangCode, "en-") {
+			track = t
+			break
+		}
+	}
+	debugf(ctx, "YouTube caption track selected: lang=%s name=%q", track.LangCode, track.Name)
+
+	var transcript captionTranscript
+	params := url.Values{"v": {videoID}, "lang": {track.LangCode}}
+	if track.Name != "" {
+		params.Set("name", track.Name)
+	}
+	if err := getTimedText(ctx, client, params, &transcript); err != nil {
+		return "", fmt.Errorf("fetching caption track: %w", err)
+	}
+
+	var lines []string
+	for _, text := range transcript.Texts {
+		line := strings.TrimSpace(html.UnescapeString(text))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", ErrNoCaptions
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// getTimedText requests the timedtext endpoint and decodes the XML response into v
+func getTimedText(ctx context.Context, client *http.Client, params url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", youtubeTimedTextURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{StatusCode: resp.StatusCode, URL: youtubeTimedTextURL}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// An empty body means YouTube has no captions for the request
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return ErrNoCaptions
+	}
+
+	return xml.Unmarshal(body, v)
+}
@@ -0,0 +1,776 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+func TestExtractVideoID(t *testing.T) {
+	tests := []struct {
+		name     string
+		videoURL string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "youtube.com watch URL",
+			videoURL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			expected: "dQw4w9WgXcQ",
+			wantErr:  false,
+		},
+		{
+			name:     "youtu.be short URL",
+			videoURL: "https://youtu.be/dQw4w9WgXcQ",
+			expected: "dQw4w9WgXcQ",
+			wantErr:  false,
+		},
+		{
+			name:     "youtu.be with query params",
+			videoURL: "https://youtu.be/i0P56Pm1Q3U?si=r_78flhyOFGnX58f",
+			expected: "i0P56Pm1Q3U",
+			wantErr:  false,
+		},
+		{
+			name:     "invalid URL",
+			videoURL: "not-a-url",
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "non-youtube URL",
+			videoURL: "https://example.com/watch?v=abc123",
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name:     "youtube URL without video ID",
+			videoURL: "https://www.youtube.com/channel/UC123",
+			expected: "",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := extractVideoID(tt.videoURL)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("extractVideoID() expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("extractVideoID() unexpected error: %v", err)
+				return
+			}
+
+			if result != tt.expected {
+				t.Errorf("extractVideoID() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFetchTranscript(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseStatus int
+		wantErr        bool
+		wantIs         error
+	}{
+		{
+			name:           "successful transcript (200)",
+			responseStatus: http.StatusOK,
+			wantErr:        false,
+		},
+		{
+			name:           "missing url parameter (400)",
+			responseStatus: http.StatusBadRequest,
+			wantErr:        true,
+		},
+		{
+			name:           "invalid API key (401)",
+			responseStatus: http.StatusUnauthorized,
+			wantErr:        true,
+			wantIs:         ErrUnauthorized,
+		},
+		{
+			name:           "transcripts disabled (404)",
+			responseStatus: http.StatusNotFound,
+			wantErr:        true,
+			wantIs:         ErrTranscriptsDisabled,
+		},
+		{
+			name:           "rate limited (429)",
+			responseStatus: http.StatusTooManyRequests,
+			wantErr:        true,
+			wantIs:         ErrRateLimited,
+		},
+		{
+			name:           "server error (500)",
+			responseStatus: http.StatusInternalServerError,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create mock server
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.responseStatus)
+				if tt.responseStatus == http.StatusOK {
+					w.Write([]byte("Mock transcript content"))
+				} else {
+					w.Write([]byte("Error message"))
+				}
+			}))
+			defer server.Close()
+
+			result, err := fetchTranscript(context.Background(), "dQw4w9WgXcQ", "test-key", server.URL)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("fetchTranscript() expected error for status %d, got nil", tt.responseStatus)
+				}
+				if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+					t.Errorf("fetchTranscript() error = %v, want %v", err, tt.wantIs)
+				}
+				var httpErr *HTTPError
+				if err != nil && !errors.As(err, &httpErr) {
+					t.Errorf("fetchTranscript() error should wrap HTTPError, got %T", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("fetchTranscript() unexpected error: %v", err)
+				return
+			}
+
+			if result == "" {
+				t.Error("fetchTranscript() expected non-empty result")
+			}
+		})
+	}
+}
+
+func TestYouTubeHandler_Handle_MissingConfig(t *testing.T) {
+	handler := &YouTubeHandler{}
+
+	// Save and clear environment variables
+	originalKey := os.Getenv("YOUTUBE_TRANSCRIPT_API_KEY")
+	originalURL := os.Getenv("YOUTUBE_TRANSCRIPT_API_URL")
+
+	os.Unsetenv("YOUTUBE_TRANSCRIPT_API_KEY")
+	os.Unsetenv("YOUTUBE_TRANSCRIPT_API_URL")
+
+	defer func() {
+		if originalKey != "" {
+			os.Setenv("YOUTUBE_TRANSCRIPT_API_KEY", originalKey)
+		}
+		if originalURL != "" {
+			os.Setenv("YOUTUBE_TRANSCRIPT_API_URL", originalURL)
+		}
+	}()
+
+	result, err := handler.Handle("https://youtu.be/dQw4w9WgXcQ", nil)
+
+	if err == nil {
+		t.Error("Handle() expected error for missing config, got nil")
+	}
+
+	if result != nil {
+		t.Error("Handle() expected nil result for missing config")
+	}
+
+	if !strings.Contains(err.Error(), "YouTube API configuration missing") {
+		t.Errorf("Handle() error = %v, want config missing error", err)
+	}
+}
+
+func TestYouTubeHandler_Handle_MinTranscriptChars(t *testing.T) {
+	t.Setenv("YOUTUBE_TRANSCRIPT_API_KEY", "test-key")
+	t.Setenv("YOUTUBE_TRANSCRIPT_API_URL", "http://127.0.0.1:0") // Never called: transcripts are cached
+
+	tests := []struct {
+		name       string
+		transcript string
+		minChars   int
+		wantErr    bool
+	}{
+		{"long enough", "A full transcript of the talk.", 10, false},
+		{"too short", "♪ music ♪", 10, true},
+		{"empty without minimum", "  \n", 0, true},
+		{"short without minimum", "Hi", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			os.MkdirAll(filepath.Join(cacheDir, "youtube"), 0755)
+			os.WriteFile(filepath.Join(cacheDir, "youtube", "dQw4w9WgXcQ"), []byte(tt.transcript), 0644)
+			handler := &YouTubeHandler{cacheDir: cacheDir, minTranscriptChars: tt.minChars}
+
+			result, err := handler.Handle("https://youtu.be/dQw4w9WgXcQ", nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTranscriptTooShort) {
+					t.Errorf("Handle() error = %v, want ErrTranscriptTooShort", err)
+				}
+				if outcomeStatus(err) != StatusSkipped {
+					t.Errorf("outcomeStatus() = %q, want skipped", outcomeStatus(err))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if result.Text != tt.transcript {
+				t.Errorf("Handle() text = %q, want %q", result.Text, tt.transcript)
+			}
+		})
+	}
+}
+
+func TestYouTubeHandler_Handle_StripPatterns(t *testing.T) {
+	t.Setenv("YOUTUBE_TRANSCRIPT_API_KEY", "test-key")
+	t.Setenv("YOUTUBE_TRANSCRIPT_API_URL", "http://127.0.0.1:0") // Never called: transcripts are cached
+
+	transcript := "Transcribed by AutoCaptions Inc.\nwelcome to the talk\nwelcome to the talk\n[Music]\ntoday we cover Go\n\n\n\nthanks\nthanks for watching"
+	cacheDir := t.TempDir()
+	os.MkdirAll(filepath.Join(cacheDir, "youtube"), 0755)
+	os.WriteFile(filepath.Join(cacheDir, "youtube", "dQw4w9WgXcQ"), []byte(transcript), 0644)
+	handler := &YouTubeHandler{cacheDir: cacheDir, stripPatterns: []string{`(?im)^transcribed by .*$`, `\[Music\]`}}
+
+	result, err := handler.Handle("https://youtu.be/dQw4w9WgXcQ", nil)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	want := "welcome to the talk\ntoday we cover Go\n\nthanks\nthanks for watching"
+	if result.Text != want {
+		t.Errorf("Handle() text = %q, want %q", result.Text, want)
+	}
+
+	// The cache keeps the raw transcript, so edited patterns apply on the next run
+	if cached, _ := os.ReadFile(filepath.Join(cacheDir, "youtube", "dQw4w9WgXcQ")); string(cached) != transcript {
+		t.Errorf("cached transcript changed to %q", cached)
+	}
+
+	// The publish date comes from the watch page's microdata
+	page := `<html><head><meta itemprop="datePublished" content="2009-10-25T06:57:33-07:00"></head></html>`
+	result, err = handler.Handle("https://youtu.be/dQw4w9WgXcQ", &http.Response{Body: io.NopCloser(strings.NewReader(page))})
+	if err != nil || result.SourceDate.Format(time.RFC3339) != "2009-10-25T06:57:33-07:00" {
+		t.Errorf("Handle() source date = %v (%v), want 2009-10-25T06:57:33-07:00", result.SourceDate, err)
+	}
+
+	handler.stripPatterns = []string{"("}
+	if _, err := handler.Handle("https://youtu.be/dQw4w9WgXcQ", nil); err == nil || !strings.Contains(err.Error(), "invalid transcript strip pattern") {
+		t.Errorf("Handle() error = %v, want invalid pattern error", err)
+	}
+}
+
+func TestYouTubeHandler_CanHandle(t *testing.T) {
+	handler := &YouTubeHandler{}
+
+	tests := []struct {
+		name     string
+		url      string
+		expected bool
+	}{
+		{
+			name:     "youtube.com watch URL",
+			url:      "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			expected: true,
+		},
+		{
+			name:     "youtu.be short URL",
+			url:      "https://youtu.be/dQw4w9WgXcQ",
+			expected: true,
+		},
+		{
+			name:     "non-YouTube URL",
+			url:      "https://example.com/video",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := handler.CanHandle(tt.url, nil)
+			if result != tt.expected {
+				t.Errorf("CanHandle() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFetchTranscriptCanceled(t *testing.T) {
+	originalDelay := youtubeCallDelay
+	youtubeCallDelay = 0
+	defer func() { youtubeCallDelay = originalDelay }()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := fetchTranscriptWithRetries(ctx, "dQw4w9WgXcQ", "test-key", server.URL, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fetchTranscriptWithRetries() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestFetchCaptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     string
+		track    string
+		expected string
+		wantIs   error
+	}{
+		{
+			name:     "prefers English track",
+			list:     `<transcript_list><track lang_code="de" name=""/><track lang_code="en" name=""/></transcript_list>`,
+			track:    `<transcript><text start="0" dur="1">Hello &amp;amp; welcome</text><text start="1" dur="1">to the show</text></transcript>`,
+			expected: "Hello & welcome\nto the show",
+		},
+		{
+			name:   "no caption tracks",
+			list:   `<transcript_list></transcript_list>`,
+			wantIs: ErrNoCaptions,
+		},
+		{
+			name:   "empty track list response",
+			list:   "",
+			wantIs: ErrNoCaptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("type") == "list" {
+					w.Write([]byte(tt.list))
+					return
+				}
+				if r.URL.Query().Get("lang") != "en" {
+					t.Errorf("caption track lang = %q, want en", r.URL.Query().Get("lang"))
+				}
+				w.Write([]byte(tt.track))
+			}))
+			defer server.Close()
+
+			original := youtubeTimedTextURL
+			youtubeTimedTextURL = server.URL
+			defer func() { youtubeTimedTextURL = original }()
+
+			result, err := fetchCaptions(context.Background(), "dQw4w9WgXcQ")
+
+			if tt.wantIs != nil {
+				if !errors.Is(err, tt.wantIs) {
+					t.Errorf("fetchCaptions() error = %v, want %v", err, tt.wantIs)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("fetchCaptions() unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("fetchCaptions() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandleCaptionsKeepsTranscriptAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<transcript_list></transcript_list>`))
+	}))
+	defer server.Close()
+
+	original := youtubeTimedTextURL
+	youtubeTimedTextURL = server.URL
+	defer func() { youtubeTimedTextURL = original }()
+
+	handler := &YouTubeHandler{captionFallback: true}
+	apiErr := transcriptStatusError(http.StatusNotFound, "https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	_, err := handler.handleCaptions(context.Background(), "https://www.youtube.com/watch?v=dQw4w9WgXcQ", apiErr)
+
+	for _, target := range []error{ErrNoCaptions, ErrTranscriptsDisabled} {
+		if !errors.Is(err, target) {
+			t.Errorf("handleCaptions() error = %v, want %v in chain", err, target)
+		}
+	}
+	if outcomeStatus(err) != StatusSkipped {
+		t.Errorf("outcomeStatus() = %q, want skipped", outcomeStatus(err))
+	}
+}
+
+func TestNewMarkdownConverter(t *testing.T) {
+	html := `<h1>Title</h1><p>See <a href="/docs">the docs</a> <img src="/logo.png" alt="logo"></p><pre><code>x := 1</code></pre>`
+
+	tests := []struct {
+		name        string
+		configure   func(s *Settings)
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:      "defaults",
+			configure: func(s *Settings) {},
+			contains:  []string{"# Title", "[the docs](/docs)", "![logo](/logo.png)"},
+		},
+		{
+			name: "custom options",
+			configure: func(s *Settings) {
+				s.Markdown.Domain = "example.com"
+				s.Markdown.HeadingStyle = "setext"
+				s.Markdown.CodeBlockStyle = "fenced"
+				s.Markdown.Fence = "~~~"
+			},
+			contains:    []string{"Title\n=====", "(http://example.com/docs)", "~~~\nx := 1\n~~~"},
+			notContains: []string{"# Title"},
+		},
+		{
+			name: "strip links and images",
+			configure: func(s *Settings) {
+				s.Markdown.StripLinks = true
+				s.Markdown.StripImages = true
+			},
+			contains:    []string{"See the docs"},
+			notContains: []string{"](/docs)", "logo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &Settings{}
+			tt.configure(settings)
+
+			result, err := newMarkdownConverter(settings).ConvertString(html)
+			if err != nil {
+				t.Fatalf("ConvertString() error = %v", err)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("markdown missing %q:\n%s", want, result)
+				}
+			}
+			for _, unwanted := range tt.notContains {
+				if strings.Contains(result, unwanted) {
+					t.Errorf("markdown should not contain %q:\n%s", unwanted, result)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLHandlerResolvesRelativeURLs(t *testing.T) {
+	html := `<p><a href="/about">About</a> <a href="next">Next</a> <a href="//cdn.example.org/x">CDN</a>
+<a href="https://other.com/">Other</a> <a href="mailto:me@example.com">Mail</a> <a href="#top">Top</a>
+<img src="img/photo.png" alt="photo"></p>`
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/blog/post", nil)
+	resp := &http.Response{
+		Body:    io.NopCloser(strings.NewReader(html)),
+		Request: req,
+	}
+
+	handler := &HTMLHandler{converter: newMarkdownConverter(&Settings{})}
+	result, err := handler.Handle("https://example.com/redirected-from", resp)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	expected := []string{
+		"[About](https://example.com/about)",
+		"[Next](https://example.com/blog/next)",
+		"[CDN](https://cdn.example.org/x)",
+		"[Other](https://other.com/)",
+		"[Mail](mailto:me@example.com)",
+		"![photo](https://example.com/blog/img/photo.png)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Text, want) {
+			t.Errorf("markdown missing %q:\n%s", want, result.Text)
+		}
+	}
+}
+
+func TestHTMLHandlerRemoveSelectors(t *testing.T) {
+	html := `<article><p>Keep this paragraph.</p>
+<div class="newsletter-signup"><p>Subscribe now!</p></div>
+<aside id="promo">Buy our stuff</aside></article>`
+
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(html))}
+
+	handler := &HTMLHandler{
+		converter:       newMarkdownConverter(&Settings{}),
+		removeSelectors: []string{".newsletter-signup", "aside#promo"},
+	}
+	result, err := handler.Handle("https://example.com/post", resp)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if !strings.Contains(result.Text, "Keep this paragraph.") {
+		t.Errorf("markdown missing article content:\n%s", result.Text)
+	}
+	for _, unwanted := range []string{"Subscribe now!", "Buy our stuff"} {
+		if strings.Contains(result.Text, unwanted) {
+			t.Errorf("markdown should not contain %q:\n%s", unwanted, result.Text)
+		}
+	}
+}
+
+func TestHTMLHandlerLinks(t *testing.T) {
+	html := `<article><p>See <a href="/docs#intro">the docs</a>, <a href="https://other.com/paper">a paper</a>
+and <a href="https://OTHER.com/paper/">the same paper</a>.</p>
+<p><a href="#top">Top</a> <a href="https://example.com/post">this page</a> <a href="mailto:me@example.com">mail</a></p>
+<div class="related"><a href="https://example.com/unrelated">Related</a></div></article>`
+
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(html))}
+	handler := &HTMLHandler{converter: newMarkdownConverter(&Settings{}), removeSelectors: []string{".related"}}
+
+	result, err := handler.Handle("https://example.com/post", resp)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	want := []string{"https://example.com/docs", "https://other.com/paper"}
+	if strings.Join(result.Links, " ") != strings.Join(want, " ") {
+		t.Errorf("Links = %v, want %v", result.Links, want)
+	}
+}
+
+func TestHTMLHandlerSourceDate(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"open graph", `<head><meta property="article:published_time" content="2024-05-01T09:30:00+02:00"></head><p>Body</p>`, "2024-05-01T09:30:00+02:00"},
+		{"og prefix", `<head><meta property="og:article:published_time" content="2024-05-01"></head><p>Body</p>`, "2024-05-01T00:00:00Z"},
+		{"time element", `<article><time datetime="2023-12-24T18:00:00Z">Christmas Eve</time><p>Body</p></article>`, "2023-12-24T18:00:00Z"},
+		{"metadata before time element", `<head><meta property="article:published_time" content="2024-05-01T00:00:00Z"></head><time datetime="2020-01-01">old</time><p>Body</p>`, "2024-05-01T00:00:00Z"},
+		{"unparsable", `<time datetime="last week">last week</time><p>Body</p>`, ""},
+		{"none", `<p>Body</p>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Body: io.NopCloser(strings.NewReader(tt.html))}
+			handler := &HTMLHandler{converter: newMarkdownConverter(&Settings{})}
+
+			result, err := handler.Handle("https://example.com/post", resp)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			got := ""
+			if !result.SourceDate.IsZero() {
+				got = result.SourceDate.Format(time.RFC3339)
+			}
+			if got != tt.want {
+				t.Errorf("SourceDate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLHandlerNoContent(t *testing.T) {
+	tests := []struct {
+		name            string
+		html            string
+		minContentChars int
+		wantErr         bool
+	}{
+		{"javascript-only app", `<html><body><div id="root"></div><script>render()</script></body></html>`, 0, true},
+		{"whitespace only", "<div>\n   \n</div>", 0, true},
+		{"short text allowed", "<p>Hi</p>", 0, false},
+		{"below minimum", "<p>Loading...</p>", 50, true},
+		{"above minimum", "<p>" + strings.Repeat("Real content. ", 5) + "</p>", 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Body: io.NopCloser(strings.NewReader(tt.html))}
+			handler := &HTMLHandler{converter: newMarkdownConverter(&Settings{}), minContentChars: tt.minContentChars}
+
+			_, err := handler.Handle("https://example.com/app", resp)
+			if errors.Is(err, ErrNoContent) != tt.wantErr {
+				t.Errorf("Handle() error = %v, want ErrNoContent %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetTranscriptCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fresh transcript"))
+	}))
+	defer server.Close()
+
+	originalDelay := youtubeCallDelay
+	youtubeCallDelay = 0
+	defer func() { youtubeCallDelay = originalDelay }()
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(t.TempDir())
+
+	cachePath := filepath.Join(".cache", "youtube", "dQw4w9WgXcQ")
+	os.MkdirAll(filepath.Dir(cachePath), 0755)
+	os.WriteFile(cachePath, []byte("cached transcript"), 0644)
+
+	videoURL := "https://youtu.be/dQw4w9WgXcQ"
+
+	result, err := getTranscript(context.Background(), videoURL, "test-key", server.URL, ".cache", true)
+	if err != nil || result != "cached transcript" {
+		t.Errorf("getTranscript() with cache = %q, %v, want cached transcript", result, err)
+	}
+
+	result, err = getTranscript(context.Background(), videoURL, "test-key", server.URL, ".cache", false)
+	if err != nil || result != "fresh transcript" {
+		t.Errorf("getTranscript() without cache = %q, %v, want fresh transcript", result, err)
+	}
+
+	cached, _ := os.ReadFile(cachePath)
+	if string(cached) != "fresh transcript" {
+		t.Errorf("cache = %q, want fresh entry written", cached)
+	}
+}
+
+func TestGetTranscriptConcurrentFetchesDeduplicate(t *testing.T) {
+	var mu sync.Mutex
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		w.Write([]byte("transcript"))
+	}))
+	defer server.Close()
+
+	originalDelay := youtubeCallDelay
+	youtubeCallDelay = 0
+	defer func() { youtubeCallDelay = originalDelay }()
+
+	cacheDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := getTranscript(context.Background(), "https://youtu.be/concurrent1", "test-key", server.URL, cacheDir, true)
+			if err != nil || result != "transcript" {
+				t.Errorf("getTranscript() = %q, %v", result, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits != 1 {
+		t.Errorf("transcript API called %d times, want 1", hits)
+	}
+
+	// No temp files left behind next to the cache entry
+	files, _ := os.ReadDir(filepath.Join(cacheDir, "youtube"))
+	if len(files) != 1 {
+		t.Errorf("cache directory has %d files, want 1", len(files))
+	}
+}
+
+func TestPDFHandlerMaxConcurrentUploads(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+	oldUpload := uploadFile
+	uploadFile = func(filePath, apiKey string) (*types.File, error) {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return &types.File{ID: "file-1"}, nil
+	}
+	defer func() { uploadFile = oldUpload }()
+
+	handler := &PDFHandler{apiKey: "test-key", uploads: make(chan struct{}, 2)}
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := &http.Response{Body: io.NopCloser(strings.NewReader("%PDF-1.7"))}
+			if _, err := handler.Handle("https://example.com/paper.pdf", resp); err != nil {
+				t.Errorf("Handle() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("peak concurrent uploads = %d, want 2", peak)
+	}
+}
+
+func TestPDFHandlerUploadSlotCanceled(t *testing.T) {
+	handler := &PDFHandler{apiKey: "test-key", uploads: make(chan struct{}, 1)}
+	handler.uploads <- struct{}{} // Held by another URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("%PDF-1.7"))}
+	if _, err := handler.HandleContext(ctx, "https://example.com/paper.pdf", resp); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HandleContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestHTMLHandlerConsentWall(t *testing.T) {
+	article := "<article>" + strings.Repeat("<p>Real reporting on the story at hand. </p>", 60) + "</article>"
+	tests := []struct {
+		name            string
+		html            string
+		removeSelectors []string
+		wantErr         bool
+	}{
+		{"tcf api", `<script>window.__tcfapi("addEventListener", 2, cb)</script><p>Loading</p>`, nil, true},
+		{"cmp container", `<div id="onetrust-consent-sdk"><p>Manage preferences</p></div>`, nil, true},
+		{"phrase", `<h1>Before you continue</h1><p>Please accept cookies to continue reading.</p>`, nil, true},
+		{"removed banner still detected", `<div id="CybotCookiebotDialog">Allow all</div><p>Hi</p>`, []string{"#CybotCookiebotDialog"}, true},
+		{"article with banner", `<div id="onetrust-consent-sdk">Accept</div>` + article, nil, false},
+		{"plain page", "<p>Hi</p>", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Body: io.NopCloser(strings.NewReader(tt.html))}
+			handler := &HTMLHandler{converter: newMarkdownConverter(&Settings{}), removeSelectors: tt.removeSelectors}
+
+			_, err := handler.Handle("https://example.de/news", resp)
+			if errors.Is(err, ErrConsentWall) != tt.wantErr {
+				t.Errorf("Handle() error = %v, want ErrConsentWall %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package newswriter
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// jsonlRecord is one line of --jsonl output
+type jsonlRecord struct {
+	URL      string           `json:"url"`
+	Status   ProcessingStatus `json:"status"`
+	Filename string           `json:"filename,omitempty"`
+	Stage    string           `json:"stage,omitempty"` // Failed pipeline stage
+	Error    string           `json:"error,omitempty"`
+	Article  *Article         `json:"article,omitempty"`
+}
+
+// writeJSONL writes the outcome of processing url to p.jsonlOut as a single JSON line.
+// Skipped sources and URLs whose existing article was kept have no article.
+func (p *ArticleProcessor) writeJSONL(url, filename string, article *Article, err error) {
+	record := jsonlRecord{URL: url, Status: outcomeStatus(err), Filename: filename, Article: article}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	switch {
+	case record.Status == StatusError:
+		record.Stage = failureStage(err)
+	case err == nil && article == nil:
+		record.Status = StatusSkipped
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Warning: encoding JSON line for %s: %v", url, err)
+		return
+	}
+
+	p.jsonlMu.Lock()
+	defer p.jsonlMu.Unlock()
+	if _, err := p.jsonlOut.Write(append(line, '\n')); err != nil {
+		log.Printf("Warning: writing JSON line for %s: %v", url, err)
+	}
+}
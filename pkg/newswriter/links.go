@@ -0,0 +1,153 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// ErrBrokenLinks is returned when Settings.FailOnBrokenLinks is set and --check-links
+// finds broken links in a saved article
+var ErrBrokenLinks = errors.New("broken links")
+
+// Link checking defaults when the settings are zero
+const (
+	defaultLinkCheckConcurrency = 4
+	defaultLinkChecksPerSecond  = 5
+)
+
+// linkCheckTimeout bounds each link check, including a GET retry
+var linkCheckTimeout = 10 * time.Second
+
+// BrokenLink is an outbound link in a generated article that didn't resolve
+type BrokenLink struct {
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"` // HTTP status; 0 when the request failed
+	Error  string `json:"error,omitempty"`  // Why the request failed, when Status is 0
+}
+
+// String describes the link and why it is broken, e.g. "https://example.com/gone (404)"
+func (b BrokenLink) String() string {
+	if b.Status != 0 {
+		return fmt.Sprintf("%s (%d)", b.URL, b.Status)
+	}
+	return fmt.Sprintf("%s (%s)", b.URL, b.Error)
+}
+
+// markdownLinks returns the distinct absolute http(s) link destinations in content, in order
+func markdownLinks(content string) []string {
+	source := []byte(content)
+	seen := make(map[string]bool)
+	var links []string
+	add := func(link string) {
+		if (strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://")) && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	doc := markdownParser.Parse(text.NewReader(source))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Link:
+			add(string(node.Destination))
+		case *ast.AutoLink:
+			if node.AutoLinkType == ast.AutoLinkURL {
+				add(string(node.URL(source)))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// checkArticleLinks sends a HEAD request to every link in content, paced by
+// Settings.LinkChecksPerSecond and Settings.LinkCheckConcurrency across all URLs,
+// and returns the broken ones in the order they appear
+func (p *ArticleProcessor) checkArticleLinks(ctx context.Context, content string) []BrokenLink {
+	links := markdownLinks(content)
+	fetcher := p.linkFetcher()
+	results := make([]*BrokenLink, len(links))
+
+	var wg sync.WaitGroup
+	for i, link := range links {
+		release, err := acquireSlot(ctx, p.linkSlots)
+		if err != nil {
+			break
+		}
+		if p.linkLimiter != nil {
+			if err := p.linkLimiter.wait(ctx, 1); err != nil {
+				release()
+				break
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer release()
+			results[i] = fetcher.checkLink(ctx, link)
+		}()
+	}
+	wg.Wait()
+
+	var broken []BrokenLink
+	for _, result := range results {
+		if result != nil {
+			broken = append(broken, *result)
+		}
+	}
+	return broken
+}
+
+// linkFetcher returns the fetcher whose client, User-Agent and consent cookies link
+// checks and short URL expansion use: the processor's own, or a default one when
+// WithFetcher replaced it
+func (p *ArticleProcessor) linkFetcher() *ContentFetcher {
+	if fetcher, ok := p.fetcher.(*ContentFetcher); ok {
+		return fetcher
+	}
+	return NewContentFetcher("", p.config.Settings)
+}
+
+// checkLink returns the link as broken when it fails or answers with a 4xx or 5xx
+// status. Servers that don't allow HEAD are asked again with GET.
+func (f *ContentFetcher) checkLink(ctx context.Context, link string) *BrokenLink {
+	ctx, cancel := context.WithTimeout(ctx, linkCheckTimeout)
+	defer cancel()
+
+	status, err := f.linkStatus(ctx, http.MethodHead, link)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = f.linkStatus(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		return &BrokenLink{URL: link, Error: err.Error()}
+	}
+	if status >= 400 {
+		return &BrokenLink{URL: link, Status: status}
+	}
+	return nil
+}
+
+// linkStatus requests link with method, following redirects, and returns the final status
+func (f *ContentFetcher) linkStatus(ctx context.Context, method, link string) (int, error) {
+	req, err := f.newRequest(ctx, method, link)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
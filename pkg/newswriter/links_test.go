@@ -0,0 +1,65 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+func TestMarkdownLinks(t *testing.T) {
+	content := "# Title\n\nSee [the docs](https://example.com/docs) and <https://example.com/auto>.\n\n" +
+		"[Again](https://example.com/docs), [relative](/about), [mail](mailto:a@example.com).\n\n" +
+		"```\n[not a link](https://example.com/code)\n```\n"
+
+	want := []string{"https://example.com/docs", "https://example.com/auto"}
+	if got := markdownLinks(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("markdownLinks() = %v, want %v", got, want)
+	}
+}
+
+func TestProcessURLCheckLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != fetcherUserAgent {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	defer server.Close()
+
+	body := "# Linked\n\n[ok](" + server.URL + "/ok), [gone](" + server.URL + "/gone) and [no head](" + server.URL + "/no-head)."
+	for _, fail := range []bool{false, true} {
+		prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+			textResponse(`{"title": "Linked", "deck": "D", "categories": [], "tags": [], "target": {}}`),
+			textResponse(body),
+		}}
+		p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+		p.checkLinks = true
+		p.config.Settings.FailOnBrokenLinks = fail
+
+		result := p.ProcessURLResult(context.Background(), "https://example.com/post", false)
+		want := []BrokenLink{{URL: server.URL + "/gone", Status: http.StatusNotFound}}
+		if !reflect.DeepEqual(result.BrokenLinks, want) {
+			t.Errorf("BrokenLinks = %v, want %v", result.BrokenLinks, want)
+		}
+		if fail {
+			if !errors.Is(result.Error, ErrBrokenLinks) || failureStage(result.Error) != "postprocess" {
+				t.Errorf("error with fail_on_broken_links = %v, want ErrBrokenLinks at stage postprocess", result.Error)
+			}
+		} else if result.Error != nil {
+			t.Errorf("error = %v, want broken links only reported", result.Error)
+		}
+	}
+}
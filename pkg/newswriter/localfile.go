@@ -0,0 +1,103 @@
+package newswriter
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localContentTypes maps the extensions of local documents to the Content-Type that
+// selects their handler; other extensions fall back to the system MIME table and sniffing
+var localContentTypes = map[string]string{
+	".pdf":      "application/pdf",
+	".html":     "text/html; charset=utf-8",
+	".htm":      "text/html; charset=utf-8",
+	".md":       "text/markdown; charset=utf-8",
+	".markdown": "text/markdown; charset=utf-8",
+	".txt":      "text/plain; charset=utf-8",
+}
+
+// isLocalFile reports whether source names a local file (an absolute path or a
+// file:// URL) rather than a web page
+func isLocalFile(source string) bool {
+	return strings.HasPrefix(source, "file://") || filepath.IsAbs(source)
+}
+
+// localFilePath returns the filesystem path of a local source
+func localFilePath(source string) (string, error) {
+	if !strings.HasPrefix(source, "file://") {
+		return source, nil
+	}
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", source, err)
+	}
+	return parsed.Path, nil
+}
+
+// openLocalFile opens a local source as if it had been served over HTTP, with a
+// Content-Type from its extension, so the usual handlers process it
+func openLocalFile(source string) (*http.Response, error) {
+	path, err := localFilePath(source)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("opening %s: is a directory", path)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	contentType, ok := localContentTypes[ext]
+	if !ok {
+		contentType = mime.TypeByExtension(ext)
+	}
+
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        make(http.Header),
+		Body:          file,
+		ContentLength: info.Size(),
+		Request:       &http.Request{Method: http.MethodGet, URL: &url.URL{Scheme: "file", Path: path}},
+	}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp, nil
+}
+
+// TextHandler passes local Markdown and plain text files through unchanged
+type TextHandler struct{}
+
+func (h *TextHandler) CanHandle(url string, resp *http.Response) bool {
+	if !isLocalFile(url) {
+		return false
+	}
+	contentType := resp.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "text/markdown") || strings.HasPrefix(contentType, "text/plain")
+}
+
+func (h *TextHandler) Handle(url string, resp *http.Response) (*ContentResult, error) {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, fmt.Errorf("%w: %s is empty", ErrNoContent, url)
+	}
+	return &ContentResult{Text: string(data), Kind: KindText}, nil
+}
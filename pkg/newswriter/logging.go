@@ -0,0 +1,68 @@
+package newswriter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// logBuffer collects the log lines of one URL so that URLs processed concurrently
+// don't interleave; flush writes them together. Lines logged after the flush
+// are written directly.
+type logBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	flushed bool
+}
+
+type logBufferKey struct{}
+
+// withLogBuffer makes logf collect the lines logged with ctx until the returned buffer is flushed
+func withLogBuffer(ctx context.Context) (context.Context, *logBuffer) {
+	buf := &logBuffer{}
+	return context.WithValue(ctx, logBufferKey{}, buf), buf
+}
+
+// logf logs a line for the URL being processed with ctx, buffered when ctx carries a logBuffer.
+// Lines not tied to one URL (run summaries, settings warnings, shared state such as the
+// output tree walk) go straight to log.
+func logf(ctx context.Context, format string, args ...any) {
+	if buf, ok := ctx.Value(logBufferKey{}).(*logBuffer); ok {
+		buf.add(fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// debugf is logf for debug mode lines
+func debugf(ctx context.Context, format string, args ...any) {
+	if debugEnabled {
+		logf(ctx, "[DEBUG] "+format, args...)
+	}
+}
+
+func (b *logBuffer) add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.flushed {
+		log.Print(line)
+		return
+	}
+	b.lines = append(b.lines, line)
+}
+
+// flush logs the buffered lines, timestamped at the time of the flush. Callers
+// serialize flushes so the lines of different URLs stay grouped. A nil buffer is a no-op.
+func (b *logBuffer) flush() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range b.lines {
+		log.Print(line)
+	}
+	b.lines = nil
+	b.flushed = true
+}
@@ -0,0 +1,35 @@
+package newswriter
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogBuffer(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, buf := withLogBuffer(context.Background())
+	logf(ctx, "→ Planning %s", "https://example.com/a")
+	logf(context.Background(), "unbuffered")
+	logf(ctx, "✓ Saved: %s", "a.md")
+	if strings.Contains(logs.String(), "Planning") {
+		t.Fatalf("buffered lines logged before flush:\n%s", logs.String())
+	}
+
+	buf.flush()
+	logf(ctx, "after flush")
+	out := logs.String()
+	planning, saved := strings.Index(out, "→ Planning"), strings.Index(out, "✓ Saved")
+	if planning < 0 || saved < planning || strings.Index(out, "unbuffered") > planning || !strings.Contains(out, "after flush") {
+		t.Errorf("log output =\n%s\nwant the unbuffered line, then the buffered lines in order, then the line logged after the flush", out)
+	}
+
+	var nilBuffer *logBuffer
+	nilBuffer.flush()
+}
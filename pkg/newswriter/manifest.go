@@ -0,0 +1,210 @@
+package newswriter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestFile is stored in the .news-writer directory and records every URL processed
+const manifestFile = "manifest.json"
+
+// legacyManifestFile is the name the manifest had when it only tracked --changed-only
+// runs; it is migrated to manifestFile when loaded
+const legacyManifestFile = "processed.json"
+
+// manifestEntry records the last outcome of processing one URL
+type manifestEntry struct {
+	URL         string           `json:"url"`
+	Status      ProcessingStatus `json:"status"`
+	Filename    string           `json:"filename,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	ProcessedAt time.Time        `json:"processed_at"`
+}
+
+// manifest maps normalized URLs to their last outcome. It is saved after every update,
+// atomically, so it survives interrupted runs.
+type manifest struct {
+	mu   sync.Mutex
+	path string
+	URLs map[string]manifestEntry `json:"urls"`
+}
+
+// manifestPath returns manifest.json next to the settings file in use
+func (c *Config) manifestPath() string {
+	if c.Overrides != nil && c.Overrides.SettingsPath != nil {
+		return filepath.Join(filepath.Dir(*c.Overrides.SettingsPath), manifestFile)
+	}
+	return filepath.Join(findConfigDir(), manifestFile)
+}
+
+// loadManifest reads the manifest at path; a missing file is an empty manifest. A
+// processed.json in the same directory is migrated when path doesn't exist yet.
+func loadManifest(path string) (*manifest, error) {
+	m := &manifest{path: path, URLs: make(map[string]manifestEntry)}
+	source := path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		source = filepath.Join(filepath.Dir(path), legacyManifestFile)
+		data, err = os.ReadFile(source)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", source, err)
+	}
+	if m.URLs == nil {
+		m.URLs = make(map[string]manifestEntry)
+	}
+
+	if source != path {
+		log.Printf("→ Migrating %s to %s", source, path)
+		if err := m.save(); err != nil {
+			return nil, err
+		}
+		if err := os.Remove(source); err != nil {
+			log.Printf("Warning: removing %s: %v", source, err)
+		}
+	}
+	return m, nil
+}
+
+// update records the outcome of processing url and saves the manifest. A failure
+// keeps the filename of an earlier successful run.
+func (m *manifest) update(url, filename string, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := normalizeURL(url)
+	entry := manifestEntry{URL: url, Status: outcomeStatus(err), Filename: filename, ProcessedAt: time.Now()}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if entry.Filename == "" {
+		entry.Filename = m.URLs[key].Filename
+	}
+	m.URLs[key] = entry
+	return m.save()
+}
+
+// forget removes urls from the manifest and saves it
+func (m *manifest) forget(urls []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, url := range urls {
+		delete(m.URLs, normalizeURL(url))
+	}
+	return m.save()
+}
+
+// save writes the manifest to a temporary file and renames it into place; callers hold m.mu
+func (m *manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("creating manifest directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), manifestFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// diff returns the URLs without a successful or skipped outcome in the manifest, in
+// config order, and the manifest entries whose URL is no longer in the config
+func (m *manifest) diff(urls []string) (added []string, removed []manifestEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		key := normalizeURL(url)
+		current[key] = true
+		if entry, ok := m.URLs[key]; !ok || entry.Status == StatusError {
+			added = append(added, url)
+		}
+	}
+	for key, entry := range m.URLs {
+		if !current[key] {
+			removed = append(removed, entry)
+		}
+	}
+	return added, removed
+}
+
+// recordOutcome updates the manifest, if any, after processing url; failures are logged
+func (p *ArticleProcessor) recordOutcome(url, filename string, err error) {
+	if p.manifest == nil || p.dryRun || p.diffOnly {
+		return
+	}
+	if saveErr := p.manifest.update(url, filename, err); saveErr != nil {
+		log.Printf("Warning: updating manifest: %v", saveErr)
+	}
+}
+
+// processChangedURLs processes only the URLs that have no successful or skipped
+// outcome in the manifest, and forgets URLs removed from the config, deleting
+// their articles when p.deleteRemoved is set
+func (p *ArticleProcessor) processChangedURLs(urls []string) error {
+	if p.manifest == nil {
+		return fmt.Errorf("processing changed URLs: manifest is disabled")
+	}
+
+	added, removed := p.manifest.diff(urls)
+	log.Printf("→ %d new URLs, %d unchanged, %d removed since the last run (%s)", len(added), len(urls)-len(added), len(removed), p.manifest.path)
+
+	if len(removed) > 0 && !p.dryRun {
+		var forgotten []string
+		for _, entry := range removed {
+			if p.deleteRemoved && entry.Filename != "" {
+				removeArticle(entry.Filename)
+			}
+			forgotten = append(forgotten, entry.URL)
+		}
+		if err := p.manifest.forget(forgotten); err != nil {
+			return err
+		}
+	}
+
+	_, err := p.processURLs(added)
+	return err
+}
+
+// removeArticle deletes an article and any source saved next to it
+func removeArticle(filename string) {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for _, path := range []string{filename, base + ".source.md", base + ".source.pdf"} {
+		if err := os.Remove(path); err == nil {
+			log.Printf("✓ Deleted: %s", path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: deleting %s: %v", path, err)
+		}
+	}
+}
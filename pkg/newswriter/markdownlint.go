@@ -0,0 +1,192 @@
+package newswriter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// ErrInvalidMarkdown is returned when Settings.MarkdownLint is "reject" and the
+// generated article has structural markdown problems
+var ErrInvalidMarkdown = errors.New("invalid markdown")
+
+// Settings.MarkdownLint modes
+const (
+	MarkdownLintOff    = ""       // Don't lint (default)
+	MarkdownLintWarn   = "warn"   // Log the issues and keep the article
+	MarkdownLintReject = "reject" // Fail the URL with ErrInvalidMarkdown
+)
+
+var markdownParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// lintMarkdown returns the structural problems in content that tend to break static
+// site builds: unclosed code fences, table rows that don't match their header, tables
+// without a delimiter row, raw HTML, and link syntax that didn't parse as a link
+func lintMarkdown(content string) []string {
+	source := []byte(content)
+	issues := lintMarkdownLines(content)
+
+	doc := markdownParser.Parse(text.NewReader(source))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.HTMLBlock:
+			if snippet := nodeText(node.Lines(), source); !isHTMLComment(snippet) {
+				issues = append(issues, fmt.Sprintf("line %d: raw HTML %q", lineAt(source, node.Lines().At(0).Start), truncate(snippet, 40)))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			if snippet := nodeText(node.Segments, source); !isHTMLComment(snippet) {
+				issues = append(issues, fmt.Sprintf("line %d: raw HTML %q", lineAt(source, node.Segments.At(0).Start), truncate(snippet, 40)))
+			}
+		case *ast.Link:
+			if len(node.Destination) == 0 {
+				issues = append(issues, fmt.Sprintf("link %q has no URL", truncate(inlineText(node, source), 40)))
+			}
+		case *ast.Paragraph, *ast.Heading, *ast.TextBlock:
+			line := 0
+			if lines := n.Lines(); lines.Len() > 0 {
+				line = lineAt(source, lines.At(0).Start)
+			}
+			inline := inlineText(n, source)
+			if _, isParagraph := n.(*ast.Paragraph); isParagraph && strings.HasPrefix(inline, "|") && strings.Count(inline, "|") > 1 {
+				issues = append(issues, fmt.Sprintf("line %d: table is missing its |---| delimiter row", line))
+			}
+			if i := strings.Index(inline, "]("); i >= 0 {
+				issues = append(issues, fmt.Sprintf("line %d: broken link syntax near %q", line, truncate(inline[max(i-20, 0):], 40)))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
+
+// lintMarkdownLines finds unclosed code fences and table rows whose cell count differs
+// from the header, which the parser silently repairs
+func lintMarkdownLines(content string) []string {
+	var issues []string
+	var fence string // Opening fence of the code block we're in, or ""
+	fenceLine := 0
+	headerCells := 0 // Cell count of the current table's header row, or 0 outside tables
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if marker := fenceMarker(trimmed); marker != "" {
+			fence, fenceLine, headerCells = marker, i+1, 0
+			continue
+		}
+
+		if !strings.HasPrefix(trimmed, "|") {
+			headerCells = 0
+			continue
+		}
+		cells := tableCells(trimmed)
+		switch {
+		case headerCells == 0:
+			headerCells = cells
+		case cells != headerCells:
+			issues = append(issues, fmt.Sprintf("line %d: table row has %d cells, header has %d", i+1, cells, headerCells))
+		}
+	}
+	if fence != "" {
+		issues = append(issues, fmt.Sprintf("line %d: code fence %s is never closed", fenceLine, fence))
+	}
+	return issues
+}
+
+// fenceMarker returns the ``` or ~~~ run opening a fenced code block on line, or ""
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(line, c+c+c) {
+			n := len(line) - len(strings.TrimLeft(line, c))
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// tableCells counts the cells of a table row, ignoring escaped pipes and the outer ones
+func tableCells(row string) int {
+	row = strings.ReplaceAll(row, `\|`, "")
+	row = strings.TrimPrefix(row, "|")
+	row = strings.TrimSuffix(row, "|")
+	return strings.Count(row, "|") + 1
+}
+
+// inlineText concatenates the literal text under n, skipping code spans and autolinks,
+// so link syntax the parser didn't turn into a link shows up as "]("
+func inlineText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch child := c.(type) {
+		case *ast.CodeSpan, *ast.AutoLink:
+		case *ast.Text:
+			b.Write(child.Segment.Value(source))
+		default:
+			b.WriteString(inlineText(child, source))
+		}
+	}
+	return b.String()
+}
+
+// nodeText returns the source text of segments
+func nodeText(segments *text.Segments, source []byte) string {
+	var b bytes.Buffer
+	for i := range segments.Len() {
+		segment := segments.At(i)
+		b.Write(segment.Value(source))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func isHTMLComment(s string) bool {
+	return strings.HasPrefix(s, "<!--")
+}
+
+// lineAt returns the 1-based line number of offset in source
+func lineAt(source []byte, offset int) int {
+	return bytes.Count(source[:offset], []byte("\n")) + 1
+}
+
+// truncate shortens s to n runes for issue messages
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// checkMarkdown lints the article per Settings.MarkdownLint: issues are logged in
+// "warn" mode and returned as ErrInvalidMarkdown in "reject" mode
+func (p *ArticleProcessor) checkMarkdown(ctx context.Context, url string, article *Article) error {
+	mode := p.config.Settings.MarkdownLint
+	if mode == MarkdownLintOff {
+		return nil
+	}
+	issues := lintMarkdown(article.Content)
+	if len(issues) == 0 {
+		return nil
+	}
+	if mode == MarkdownLintReject {
+		return fmt.Errorf("%w: %s", ErrInvalidMarkdown, strings.Join(issues, "; "))
+	}
+	for _, issue := range issues {
+		logf(ctx, "Warning: markdown in %s: %s", url, issue)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLintMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // Substrings of the expected issues, in order
+	}{
+		{"clean", "# Title\n\nSome [link](https://example.com) and `a](b` code.\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n```go\nfmt.Println(\"<b>\")\n```\n<!-- comment -->\n", nil},
+		{"unclosed fence", "# Title\n\n```go\ncode\n", []string{"line 3: code fence ``` is never closed"}},
+		{"longer closing fence", "````\ncode\n```\n````\n", nil},
+		{"table row mismatch", "| a | b |\n|---|---|\n| 1 | 2 | 3 |\n", []string{"line 3: table row has 3 cells, header has 2"}},
+		{"escaped pipe", "| a | b |\n|---|---|\n| 1 \\| 2 | 3 |\n", nil},
+		{"missing delimiter row", "| a | b |\n| 1 | 2 |\n", []string{"table is missing its |---| delimiter row"}},
+		{"html block", "Intro\n\n<div class=\"x\">\nHi\n</div>\n", []string{`line 3: raw HTML "<div`}},
+		{"inline html", "Some <span>text</span> here\n", []string{`raw HTML "<span>"`, `raw HTML "</span>"`}},
+		{"broken link", "See [the docs](https://example.com/a b) now\n", []string{"line 1: broken link syntax"}},
+		{"empty link", "See [the docs]() now\n", []string{`link "the docs" has no URL`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := lintMarkdown(tt.content)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("lintMarkdown() = %q, want %d issues", issues, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(issues[i], want) {
+					t.Errorf("issue %d = %q, want containing %q", i, issues[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMarkdown(t *testing.T) {
+	article := &Article{Content: "```\nunclosed\n"}
+	p := newPipelineProcessor(t.TempDir(), nil, nil)
+
+	for _, mode := range []string{MarkdownLintOff, MarkdownLintWarn} {
+		p.config.Settings.MarkdownLint = mode
+		if err := p.checkMarkdown(context.Background(), "https://example.com/post", article); err != nil {
+			t.Errorf("checkMarkdown() in mode %q error = %v", mode, err)
+		}
+	}
+
+	p.config.Settings.MarkdownLint = MarkdownLintReject
+	err := p.checkMarkdown(context.Background(), "https://example.com/post", article)
+	if !errors.Is(err, ErrInvalidMarkdown) || !strings.Contains(err.Error(), "never closed") {
+		t.Errorf("checkMarkdown() error = %v, want ErrInvalidMarkdown naming the issue", err)
+	}
+}
@@ -0,0 +1,110 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// anthropicModelsURL is listed by the preflight check to verify the API key without spending tokens
+var anthropicModelsURL = "https://api.anthropic.com/v1/models"
+
+// preflightTimeout bounds each preflight request
+const preflightTimeout = 15 * time.Second
+
+// Preflight verifies the Anthropic API accepts the configured key and, when
+// YOUTUBE_TRANSCRIPT_API_KEY and YOUTUBE_TRANSCRIPT_API_URL are set, that the
+// transcript API is reachable and accepts its key. It returns every problem found.
+func (p *ArticleProcessor) Preflight(ctx context.Context) error {
+	var problems []error
+
+	client := p.agents.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if err := checkAnthropic(ctx, client, p.agents.currentKey()); err != nil {
+		problems = append(problems, err)
+	} else {
+		log.Printf("✓ Anthropic API reachable, key accepted")
+	}
+
+	apiKey := os.Getenv("YOUTUBE_TRANSCRIPT_API_KEY")
+	apiURL := os.Getenv("YOUTUBE_TRANSCRIPT_API_URL")
+	if apiKey != "" && apiURL != "" {
+		if err := checkTranscriptAPI(ctx, apiURL, apiKey); err != nil {
+			problems = append(problems, err)
+		} else {
+			log.Printf("✓ YouTube transcript API reachable, key accepted")
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// checkAnthropic lists models with the given key on client, which applies any configured base URL
+func checkAnthropic(ctx context.Context, client *http.Client, apiKey string) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, anthropicModelsURL, nil)
+	if err != nil {
+		return fmt.Errorf("anthropic preflight: %w", err)
+	}
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("anthropic preflight: cannot reach API: %w (check network access and agents.base_url)", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("anthropic preflight: API key rejected (401): check --api-key, --api-key-file or ANTHROPIC_API_KEY")
+	case http.StatusForbidden:
+		return fmt.Errorf("anthropic preflight: API key lacks permission (403): check the key's workspace and organization")
+	default:
+		return fmt.Errorf("anthropic preflight: unexpected status %d from %s", resp.StatusCode, anthropicModelsURL)
+	}
+}
+
+// checkTranscriptAPI calls the transcript API with only the key; any answer other than
+// 401/403 means the service is up and the key is accepted
+func checkTranscriptAPI(ctx context.Context, apiURL, apiKey string) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("transcript API preflight: invalid YOUTUBE_TRANSCRIPT_API_URL: %w", err)
+	}
+	q := req.URL.Query()
+	q.Add("api_key", apiKey)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The *url.Error text holds the full URL, api_key included
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("transcript API preflight: cannot reach %s: %w", req.URL.Host, err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("transcript API preflight: %w (%d): check YOUTUBE_TRANSCRIPT_API_KEY", ErrUnauthorized, resp.StatusCode)
+	default:
+		return nil
+	}
+}
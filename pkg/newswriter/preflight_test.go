@@ -0,0 +1,84 @@
+package newswriter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	tests := []struct {
+		name            string
+		anthropicStatus int
+		transcriptKey   string
+		errorMsgs       []string
+	}{
+		{"all healthy", http.StatusOK, "good", nil},
+		{"bad anthropic key", http.StatusUnauthorized, "good", []string{"API key rejected (401)"}},
+		{"bad transcript key", http.StatusOK, "bad", []string{"YOUTUBE_TRANSCRIPT_API_KEY"}},
+		{"both bad", http.StatusUnauthorized, "bad", []string{"API key rejected", "YOUTUBE_TRANSCRIPT_API_KEY"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anthropic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("x-api-key") != "test-key" {
+					t.Errorf("x-api-key = %q", r.Header.Get("x-api-key"))
+				}
+				w.WriteHeader(tt.anthropicStatus)
+			}))
+			defer anthropic.Close()
+			transcripts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("api_key") != "good" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusBadRequest) // No video URL given
+			}))
+			defer transcripts.Close()
+
+			oldURL := anthropicModelsURL
+			anthropicModelsURL = anthropic.URL + "/v1/models"
+			defer func() { anthropicModelsURL = oldURL }()
+			t.Setenv("YOUTUBE_TRANSCRIPT_API_KEY", tt.transcriptKey)
+			t.Setenv("YOUTUBE_TRANSCRIPT_API_URL", transcripts.URL)
+
+			p := &ArticleProcessor{agents: newTestAgentManager(&fakePrompter{})}
+			err := p.Preflight(context.Background())
+
+			if len(tt.errorMsgs) == 0 {
+				if err != nil {
+					t.Errorf("Preflight() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			for _, msg := range tt.errorMsgs {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("Preflight() error = %v, want it to contain %q", err, msg)
+				}
+			}
+			if tt.transcriptKey == "bad" && !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("Preflight() error = %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestCheckTranscriptAPIHidesKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close() // Unreachable
+
+	err := checkTranscriptAPI(context.Background(), server.URL, "secret-key")
+	if err == nil {
+		t.Fatal("checkTranscriptAPI() error = nil, want unreachable")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("checkTranscriptAPI() error leaks the API key: %v", err)
+	}
+}
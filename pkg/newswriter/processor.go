@@ -0,0 +1,1936 @@
+package newswriter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"mime"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoArticle is returned in frontmatter-only mode for URLs without an existing article to refresh
+var ErrNoArticle = errors.New("no existing article")
+
+// ErrCategoryMismatch is returned when Settings.OnlyCategories is set and the planned categories match none of them
+var ErrCategoryMismatch = errors.New("planned categories not in requested set")
+
+// ErrNoCategories is returned when Settings.RequireCategories is set and the planner
+// assigns no categories, even after being asked again
+var ErrNoCategories = errors.New("planner returned no categories")
+
+// ErrBannedContent is returned when the written article matches a Settings.BannedPatterns entry
+var ErrBannedContent = errors.New("article contains banned content")
+
+// ErrUnsafePath is returned when an article filename would be outside the output directory
+var ErrUnsafePath = errors.New("path outside output directory")
+
+// ErrAlreadyExists is the ProcessURLResult error for a URL that already has an article and isn't rewritten
+var ErrAlreadyExists = errors.New("article already exists")
+
+// ErrRunAborted is returned when Settings.AbortOnSaveError stops a run after a systemic save error
+var ErrRunAborted = errors.New("run aborted")
+
+// ErrSlugCollision is returned with slug_collision_strategy "fail" when another article already uses the slug
+var ErrSlugCollision = errors.New("slug already used by another article")
+
+// ArticleProcessor handles the main workflow
+type ArticleProcessor struct {
+	agents      *AgentManager
+	fetcher     Fetcher
+	config      *Config
+	apiKey      string
+	concurrency int  // URLs processed in parallel by ProcessURLs and ProcessURLsFromFile
+	dryRun      bool // Fetch and plan only; don't call the writer or save
+	postProcess func(*Article) error
+	review      ReviewFunc // Asked to accept each generated article before it is saved when set
+	debugDir    string     // Per-URL source, prompts and raw responses are written here when set
+	diffOut     io.Writer  // Rewrites print a unified diff against the existing article here when set
+	diffOnly    bool       // Print the diff without writing the rewritten article
+
+	jsonlMu  sync.Mutex
+	jsonlOut io.Writer // One JSON line per processed URL is written here when set
+
+	reportPath string // HTML run report written after each batch when set
+
+	manifest      *manifest // Last outcome per URL, updated after each URL when non-nil
+	quietSkips    bool      // Don't log each skipped URL, only the summary counts
+	changedOnly   bool      // ProcessURLsFromFile skips URLs the manifest records as processed
+	deleteRemoved bool      // With changedOnly, delete articles whose URL left the config
+	force         bool      // Regenerate existing articles from a full fetch and ignore changedOnly
+	refresh       bool      // Regenerate existing articles whose source changed, fetched conditionally
+	publishedDir  string    // In draft mode, the configured output directory; articles are written to the draft directory instead
+
+	frontmatterOnly bool // Re-plan existing articles and rewrite their frontmatter, keeping the body
+
+	titlesMu sync.Mutex
+	titles   []string // Titles saved so far in this run
+
+	slugsMu sync.Mutex
+	slugs   map[string]bool // Slugs in the output tree, walked once per run, plus those assigned since
+
+	sourceURLsMu sync.Mutex
+	sourceURLs   map[string]map[string]string // Output root -> source_url -> article, walked once per run, plus those saved since
+
+	items map[string]ArticleItem // Config items with per-URL overrides, keyed by normalized URL
+
+	expansionsMu sync.Mutex
+	expansions   map[string]string // Short URL -> resolved URL (itself when resolving failed), per run
+
+	plannerConcurrency int // Planner stage workers in processURLs; with writerConcurrency zero, URLs aren't staged
+	writerConcurrency  int // Writer stage workers in processURLs, fed planned articles by the planner stage
+
+	checkLinks  bool              // Check the outbound links of each saved article
+	linkSlots   chan struct{}     // Limits concurrent link checks across URLs when non-nil
+	linkLimiter *bandwidthLimiter // Paces link checks, counting requests instead of bytes, when non-nil
+}
+
+// similarTitleThreshold is the word-overlap ratio above which two titles count as duplicates
+const similarTitleThreshold = 0.8
+
+// Option configures an ArticleProcessor created by NewArticleProcessor
+type Option func(*processorOptions)
+
+type processorOptions struct {
+	overrides       *ConfigOverrides
+	outputDir       string
+	concurrency     int
+	fetcher         Fetcher
+	prompter        Prompter
+	dryRun          bool
+	postProcess     func(*Article) error
+	review          ReviewFunc
+	debugDir        string
+	diffOut         io.Writer
+	diffOnly        bool
+	jsonlOut        io.Writer
+	reportPath      string
+	force           bool
+	refresh         bool
+	draft           bool
+	checkLinks      bool
+	frontmatterOnly bool
+	absolutePaths   bool
+	manifest        bool
+	quietSkips      bool
+	changedOnly     bool
+	deleteRemoved   bool
+}
+
+// WithOverrides loads settings, prompts and template using the given overrides
+func WithOverrides(overrides *ConfigOverrides) Option {
+	return func(o *processorOptions) { o.overrides = overrides }
+}
+
+// WithOutputDir writes articles to dir instead of the configured output_directory
+func WithOutputDir(dir string) Option {
+	return func(o *processorOptions) { o.outputDir = dir }
+}
+
+// WithConcurrency processes up to n URLs in parallel; values below 1 mean sequential
+func WithConcurrency(n int) Option {
+	return func(o *processorOptions) { o.concurrency = n }
+}
+
+// WithFetcher replaces the default HTTP content fetcher
+func WithFetcher(fetcher Fetcher) Option {
+	return func(o *processorOptions) { o.fetcher = fetcher }
+}
+
+// WithPrompter replaces the Anthropic client used by the planner and writer
+func WithPrompter(prompter Prompter) Option {
+	return func(o *processorOptions) { o.prompter = prompter }
+}
+
+// WithDryRun fetches and plans each URL and reports the filename it would write,
+// without calling the writer or saving anything
+func WithDryRun(enabled bool) Option {
+	return func(o *processorOptions) { o.dryRun = enabled }
+}
+
+// WithReview asks fn to accept, edit or regenerate each generated article before it
+// is saved (see ReviewFunc). URLs are processed one at a time.
+func WithReview(fn ReviewFunc) Option {
+	return func(o *processorOptions) { o.review = fn }
+}
+
+// WithDraft writes articles with draft: true to Settings.DraftDirectory instead of the
+// output directory, for review before Config.ApproveDraft publishes them
+func WithDraft(enabled bool) Option {
+	return func(o *processorOptions) { o.draft = enabled }
+}
+
+// WithFrontmatterOnly re-runs the planner for URLs with an existing article and
+// rewrites only its frontmatter, keeping the body, date and writer model, without
+// calling the writer. URLs without an article are skipped with ErrNoArticle.
+func WithFrontmatterOnly(enabled bool) Option {
+	return func(o *processorOptions) { o.frontmatterOnly = enabled }
+}
+
+// WithCheckLinks sends a HEAD request to each outbound link of every saved article
+// and reports the broken ones; with Settings.FailOnBrokenLinks they fail the URL
+func WithCheckLinks(enabled bool) Option {
+	return func(o *processorOptions) { o.checkLinks = enabled }
+}
+
+// WithPostProcess runs fn on each generated article before it is saved. fn may
+// modify the article; returning an error aborts that URL without saving.
+func WithPostProcess(fn func(*Article) error) Option {
+	return func(o *processorOptions) { o.postProcess = fn }
+}
+
+// WithDebugDir writes each URL's fetched source, prompts (API key redacted) and raw
+// planner and writer responses to a timestamped directory under dir
+func WithDebugDir(dir string) Option {
+	return func(o *processorOptions) { o.debugDir = dir }
+}
+
+// WithDiff prints a unified diff between an existing article and its rewrite to w;
+// with only set, the rewrite is not written
+func WithDiff(w io.Writer, only bool) Option {
+	return func(o *processorOptions) {
+		o.diffOut = w
+		o.diffOnly = only
+	}
+}
+
+// WithJSONL writes one JSON object per processed URL to w as each completes,
+// holding its status and, when one was generated, the article
+func WithJSONL(w io.Writer) Option {
+	return func(o *processorOptions) { o.jsonlOut = w }
+}
+
+// WithForce regenerates every URL processed: existing articles are rewritten as with
+// rewrite set, sources are fetched in full, and WithChangedOnly is ignored so
+// ProcessURLsFromFile processes the whole config
+func WithForce(enabled bool) Option {
+	return func(o *processorOptions) { o.force = enabled }
+}
+
+// WithRefresh regenerates existing articles only when their source changed: sources are
+// fetched with the ETag/Last-Modified validators of the previous fetch, and a 304 Not
+// Modified keeps the article. An explicit rewrite always fetches in full.
+func WithRefresh(enabled bool) Option {
+	return func(o *processorOptions) { o.refresh = enabled }
+}
+
+// WithAbsolutePaths makes the article paths the processor returns, logs and records
+// in the manifest absolute, by resolving the output directory once at creation
+func WithAbsolutePaths(enabled bool) Option {
+	return func(o *processorOptions) { o.absolutePaths = enabled }
+}
+
+// WithReport writes an HTML report of each batch run to path (see WriteReport)
+func WithReport(path string) Option {
+	return func(o *processorOptions) { o.reportPath = path }
+}
+
+// WithQuietSkips suppresses the per-URL log lines for skipped URLs; the summary still counts them
+func WithQuietSkips(enabled bool) Option {
+	return func(o *processorOptions) { o.quietSkips = enabled }
+}
+
+// WithManifest records the outcome of each URL in .news-writer/manifest.json. It is
+// off by default; WithChangedOnly turns it on.
+func WithManifest(enabled bool) Option {
+	return func(o *processorOptions) { o.manifest = enabled }
+}
+
+// WithChangedOnly makes ProcessURLsFromFile process only URLs the manifest doesn't
+// record as processed or skipped. With deleteRemoved set, articles whose URL was
+// removed from the config are deleted.
+func WithChangedOnly(enabled, deleteRemoved bool) Option {
+	return func(o *processorOptions) {
+		o.changedOnly = enabled
+		o.deleteRemoved = deleteRemoved
+	}
+}
+
+// NewArticleProcessor creates a new processor with agent manager and config
+func NewArticleProcessor(apiKey string, opts ...Option) (*ArticleProcessor, error) {
+	options := processorOptions{concurrency: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	config, err := NewConfig(options.overrides)
+	if err != nil {
+		return nil, fmt.Errorf("creating config: %w", err)
+	}
+	if options.outputDir != "" {
+		config.Settings.OutputDirectory = options.outputDir
+	}
+	var publishedDir string
+	if options.draft || config.Settings.DefaultDraft {
+		publishedDir = config.Settings.OutputDirectory
+		config.Settings.OutputDirectory = config.Settings.DraftDirectory
+	}
+	if options.absolutePaths {
+		dir, err := filepath.Abs(config.Settings.OutputDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("resolving output directory: %w", err)
+		}
+		config.Settings.OutputDirectory = dir
+		if publishedDir != "" {
+			if publishedDir, err = filepath.Abs(publishedDir); err != nil {
+				return nil, fmt.Errorf("resolving output directory: %w", err)
+			}
+		}
+	}
+
+	agents, err := NewAgentManager(apiKey, config)
+	if err != nil {
+		return nil, fmt.Errorf("creating agent manager: %w", err)
+	}
+	if options.prompter != nil {
+		agents.prompter = options.prompter
+	}
+
+	fetcher := options.fetcher
+	if fetcher == nil {
+		fetcher = NewContentFetcher(apiKey, config.Settings)
+	}
+
+	// Processing order affects numeric slugs and duplicate title handling
+	if config.Settings.Deterministic && options.concurrency > 1 {
+		log.Printf("→ Deterministic mode: processing URLs one at a time (ignoring concurrency %d)", options.concurrency)
+		options.concurrency = 1
+	}
+
+	// The reviewer reads one answer at a time from the terminal
+	if options.review != nil && options.concurrency > 1 {
+		log.Printf("→ Review mode: processing URLs one at a time (ignoring concurrency %d)", options.concurrency)
+		options.concurrency = 1
+	}
+
+	// Separate planner and writer stages; an unset one gets --concurrency workers
+	plannerConcurrency, writerConcurrency := config.Settings.PlannerConcurrency, config.Settings.WriterConcurrency
+	if plannerConcurrency > 0 || writerConcurrency > 0 {
+		if config.Settings.Deterministic || options.review != nil {
+			log.Printf("→ Processing URLs one at a time: ignoring planner_concurrency and writer_concurrency")
+			plannerConcurrency, writerConcurrency = 0, 0
+		} else {
+			plannerConcurrency = cmp.Or(plannerConcurrency, max(options.concurrency, 1))
+			writerConcurrency = cmp.Or(writerConcurrency, max(options.concurrency, 1))
+		}
+	}
+
+	var linkSlots chan struct{}
+	var linkLimiter *bandwidthLimiter
+	if options.checkLinks {
+		concurrency, rate := config.Settings.LinkCheckConcurrency, config.Settings.LinkChecksPerSecond
+		if concurrency == 0 {
+			concurrency = defaultLinkCheckConcurrency
+		}
+		if rate == 0 {
+			rate = defaultLinkChecksPerSecond
+		}
+		linkSlots = stageSlots(concurrency)
+		linkLimiter = newBandwidthLimiter(int64(rate))
+	}
+
+	var m *manifest
+	if options.manifest || options.changedOnly {
+		m, err = loadManifest(config.manifestPath())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ArticleProcessor{
+		agents:             agents,
+		fetcher:            fetcher,
+		config:             config,
+		apiKey:             apiKey,
+		concurrency:        max(options.concurrency, 1),
+		dryRun:             options.dryRun,
+		postProcess:        options.postProcess,
+		review:             options.review,
+		debugDir:           options.debugDir,
+		diffOut:            options.diffOut,
+		diffOnly:           options.diffOnly,
+		jsonlOut:           options.jsonlOut,
+		reportPath:         options.reportPath,
+		manifest:           m,
+		quietSkips:         options.quietSkips,
+		changedOnly:        options.changedOnly,
+		deleteRemoved:      options.deleteRemoved,
+		force:              options.force,
+		refresh:            options.refresh,
+		publishedDir:       publishedDir,
+		plannerConcurrency: plannerConcurrency,
+		writerConcurrency:  writerConcurrency,
+		checkLinks:         options.checkLinks,
+		frontmatterOnly:    options.frontmatterOnly,
+		linkSlots:          linkSlots,
+		linkLimiter:        linkLimiter,
+	}, nil
+}
+
+// ProcessURLsFromFile processes all URLs from a config file
+func (p *ArticleProcessor) ProcessURLsFromFile(configPath string) error {
+	urls, err := p.loadURLsFromFile(configPath)
+	if err != nil {
+		return fmt.Errorf("loading URLs: %w", err)
+	}
+
+	log.Printf("Processing %d URLs from %s", len(urls), configPath)
+	if p.changedOnly && p.force {
+		log.Printf("→ Forcing regeneration of all URLs, ignoring changed-only")
+	} else if p.changedOnly {
+		return p.processChangedURLs(urls)
+	}
+	_, err = p.processURLs(urls)
+	return err
+}
+
+// ProcessURLs validates and processes URLs given directly (e.g. from the command line)
+func (p *ArticleProcessor) ProcessURLs(urls []string) error {
+	config := &URLConfig{}
+	for _, url := range urls {
+		config.Items = append(config.Items, ArticleItem{URL: strings.TrimSpace(url)})
+	}
+
+	if err := p.validateConfig(config, "command line"); err != nil {
+		return fmt.Errorf("validating URLs: %w", err)
+	}
+
+	var trimmed []string
+	for _, item := range config.Items {
+		trimmed = append(trimmed, item.URL)
+	}
+	trimmed = dedupeURLs(trimmed)
+
+	log.Printf("Processing %d URLs from command line", len(trimmed))
+	_, err := p.processURLs(trimmed)
+	return err
+}
+
+// processURLs processes the URLs, up to p.concurrency at a time or in planner and
+// writer stages when they are set, logs a summary and returns the outcome for each
+// URL in completion order
+func (p *ArticleProcessor) processURLs(urls []string) ([]ProcessingResult, error) {
+	// Expanded before processing, so a short link and its destination are deduplicated
+	urls, originals := p.expandURLs(urls)
+
+	var results []ProcessingResult
+	successful := 0
+	failed := 0
+	skipped := 0
+	existing := 0
+	noTranscript := 0
+	shortTranscript := 0
+	failedByStage := make(map[string]int)
+	systemic := 0
+	noCategories := 0
+	var brokenLinks []string // "article: link (status)" for the summary
+
+	var (
+		mu       sync.Mutex
+		abortErr error // First systemic save error when Settings.AbortOnSaveError is set
+		quit     bool  // The reviewer chose to stop the run
+	)
+	staged := p.writerConcurrency > 0
+
+	// newContext returns the context to process url in. Concurrent URLs log into a
+	// buffer, flushed with their outcome so their lines stay grouped.
+	newContext := func(url string) (context.Context, *logBuffer) {
+		ctx := context.Background()
+		if original, ok := originals[url]; ok {
+			ctx = withOriginalURL(ctx, original)
+		}
+		if (p.concurrency > 1 || staged) && !p.config.Settings.StreamLogs {
+			return withLogBuffer(ctx)
+		}
+		return ctx, nil
+	}
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return abortErr != nil || quit
+	}
+	// done logs and counts the outcome of a URL
+	done := func(ctx context.Context, logs *logBuffer, result ProcessingResult) {
+		url, filename, err := result.URL, result.Filename, result.Error
+
+		mu.Lock()
+		defer mu.Unlock()
+		logs.flush()
+		for _, link := range result.BrokenLinks {
+			brokenLinks = append(brokenLinks, fmt.Sprintf("%s: %s", url, link))
+		}
+		if errors.Is(err, ErrAlreadyExists) {
+			skipped++
+			existing++
+		} else if errors.Is(err, ErrTranscriptsDisabled) {
+			p.logSkip(ctx, "→ Skipping (transcripts disabled): %s", url)
+			skipped++
+			noTranscript++
+		} else if errors.Is(err, ErrNoCaptions) {
+			p.logSkip(ctx, "→ Skipping (no captions): %s", url)
+			skipped++
+			noTranscript++
+		} else if errors.Is(err, ErrTranscriptTooShort) {
+			p.logSkip(ctx, "→ Skipping (transcript too short): %s", url)
+			skipped++
+			shortTranscript++
+		} else if errors.Is(err, ErrNoindex) {
+			p.logSkip(ctx, "→ Skipping (noindex): %s", url)
+			skipped++
+		} else if errors.Is(err, ErrNoContent) {
+			p.logSkip(ctx, "→ Skipping (no extractable content): %s", url)
+			skipped++
+		} else if errors.Is(err, ErrConsentWall) {
+			p.logSkip(ctx, "→ Skipping (consent wall): %s", url)
+			skipped++
+		} else if errors.Is(err, ErrCategoryMismatch) || errors.Is(err, ErrNoArticle) {
+			p.logSkip(ctx, "→ Skipping (%v): %s", err, url)
+			skipped++
+		} else if errors.Is(err, ErrNoCategories) {
+			log.Printf("✗ Failed (no categories planned): %s", url)
+			failed++
+			failedByStage[failureStage(err)]++
+			noCategories++
+		} else if errors.Is(err, ErrReviewQuit) {
+			log.Printf("→ Quit during review: %s", url)
+			quit = true
+		} else if isSystemicSaveError(err) {
+			log.Printf("✗ Failed (systemic, later saves will likely fail too): %s - %v", url, err)
+			failed++
+			failedByStage[failureStage(err)]++
+			systemic++
+			if p.config.Settings.AbortOnSaveError && abortErr == nil {
+				abortErr = err
+			}
+		} else if err != nil {
+			log.Printf("✗ Failed: %s - %v", url, err)
+			failed++
+			failedByStage[failureStage(err)]++
+		} else {
+			log.Printf("✓ %s -> %s", url, filename)
+			successful++
+		}
+		results = append(results, result)
+	}
+
+	var started int
+	if staged {
+		started = p.runStages(urls, newContext, stopped, done)
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, max(p.concurrency, 1))
+		for _, url := range urls {
+			sem <- struct{}{}
+			if stopped() {
+				<-sem
+				break
+			}
+			started++
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				ctx, logs := newContext(url)
+				done(ctx, logs, p.ProcessURLResult(ctx, url, false))
+			}()
+		}
+		wg.Wait()
+	}
+
+	log.Printf("Complete: %d successful, %d failed, %d skipped (%d existing, %d without transcripts, %d with too short transcripts)",
+		successful, failed, skipped, existing, noTranscript, shortTranscript)
+	if failed > 0 {
+		log.Printf("Failures by stage: fetch=%d plan=%d write=%d postprocess=%d save=%d other=%d",
+			failedByStage["fetch"], failedByStage["plan"], failedByStage["write"], failedByStage["postprocess"], failedByStage["save"], failedByStage["other"])
+	}
+	if noCategories > 0 {
+		log.Printf("Planned without categories: %d (review the categories list or planner prompt)", noCategories)
+	}
+	if systemic > 0 {
+		log.Printf("Systemic save errors (disk full, read-only or permission denied): %d", systemic)
+	}
+	if len(brokenLinks) > 0 {
+		log.Printf("Broken links: %d", len(brokenLinks))
+		for _, link := range brokenLinks {
+			log.Printf("  %s", link)
+		}
+	}
+	if abortErr != nil {
+		log.Printf("✗ Aborted after a systemic save error; %d URLs not processed", len(urls)-started)
+	}
+	if quit {
+		log.Printf("→ Quit during review; %d URLs not processed", len(urls)-started)
+	}
+	if err := p.WriteReport(results); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if p.reportPath != "" {
+		log.Printf("✓ Report: %s", p.reportPath)
+	}
+
+	if p.config.Settings.Git.AutoCommit && successful > 0 && !p.dryRun && !p.diffOnly {
+		if err := p.autoCommit(); err != nil {
+			return results, fmt.Errorf("auto-commit: %w", err)
+		}
+	}
+	if abortErr != nil {
+		return results, fmt.Errorf("%w: %w", ErrRunAborted, abortErr)
+	}
+	return results, nil
+}
+
+// runStages processes urls in two stages connected by a channel: p.plannerConcurrency
+// workers fetch and plan each URL and hand the planned articles to p.writerConcurrency
+// workers that write and save them, so planning runs ahead while articles are written.
+// URLs that need no writing are done by the planner stage. It stops starting URLs
+// once stopped reports true and returns how many it started.
+func (p *ArticleProcessor) runStages(urls []string, newContext func(string) (context.Context, *logBuffer), stopped func() bool, done func(context.Context, *logBuffer, ProcessingResult)) int {
+	type plannedURL struct {
+		run   *urlRun
+		logs  *logBuffer
+		write writeStep
+	}
+	pending := make(chan string)
+	planned := make(chan plannedURL, p.plannerConcurrency) // How far planning runs ahead of the writers
+
+	var planners, writers sync.WaitGroup
+	for range max(p.plannerConcurrency, 1) {
+		planners.Add(1)
+		go func() {
+			defer planners.Done()
+			for url := range pending {
+				ctx, logs := newContext(url)
+				run := p.beginURL(ctx, url)
+				filename, article, write, err := p.planURL(run.ctx, run.target, false)
+				if write == nil {
+					done(run.ctx, logs, p.finishURL(run, filename, article, err))
+					continue
+				}
+				planned <- plannedURL{run: run, logs: logs, write: write}
+			}
+		}()
+	}
+	for range p.writerConcurrency {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for item := range planned {
+				filename, article, err := item.write()
+				done(item.run.ctx, item.logs, p.finishURL(item.run, filename, article, err))
+			}
+		}()
+	}
+
+	started := 0
+	for _, url := range urls {
+		if stopped() {
+			break
+		}
+		pending <- url
+		started++
+	}
+	close(pending)
+	planners.Wait()
+	close(planned)
+	writers.Wait()
+	return started
+}
+
+// ProcessURL processes a single URL
+func (p *ArticleProcessor) ProcessURL(url string, rewrite bool) (string, error) {
+	return p.ProcessURLContext(context.Background(), url, rewrite)
+}
+
+// ProcessURLContext processes a single URL, stopping when ctx is done or the per-URL timeout expires
+func (p *ArticleProcessor) ProcessURLContext(ctx context.Context, url string, rewrite bool) (string, error) {
+	result := p.ProcessURLResult(ctx, url, rewrite)
+	if errors.Is(result.Error, ErrAlreadyExists) {
+		return result.Filename, nil
+	}
+	return result.Filename, result.Error
+}
+
+// ProcessURLResult processes a single URL like ProcessURLContext and reports its outcome.
+// URLs skipped on purpose, including ones with an existing article (ErrAlreadyExists),
+// have Status StatusSkipped.
+func (p *ArticleProcessor) ProcessURLResult(ctx context.Context, url string, rewrite bool) ProcessingResult {
+	run := p.beginURL(ctx, url)
+	filename, article, write, err := p.planURL(run.ctx, run.target, rewrite)
+	if write != nil {
+		filename, article, err = write()
+	}
+	return p.finishURL(run, filename, article, err)
+}
+
+// urlRun is a URL on its way through the pipeline, from expansion to its recorded outcome
+type urlRun struct {
+	url    string // As given, reported in ProcessingResult
+	target string // Fetched and processed: url, or where the short URL url expands to
+	source string // Recorded in the manifest and JSONL: url, or the short URL processURLs expanded it from
+	ctx    context.Context
+	cancel context.CancelFunc // Ends the per-URL timeout
+	start  time.Time
+	meter  *usageMeter
+}
+
+// beginURL expands url and starts its usage meter and per-URL timeout
+func (p *ArticleProcessor) beginURL(ctx context.Context, url string) *urlRun {
+	run := &urlRun{url: url, target: url, source: url, start: time.Now(), meter: &usageMeter{}}
+	ctx = withUsageMeter(ctx, run.meter)
+	if original := originalURLFrom(ctx); original != "" {
+		run.source = original // Expanded by processURLs
+	} else if expanded := p.expandURL(ctx, url); expanded != url {
+		ctx = withOriginalURL(ctx, url)
+		run.target = expanded
+	}
+
+	run.ctx, run.cancel = ctx, func() {}
+	if timeout := p.config.Settings.PerURLTimeout; timeout > 0 {
+		run.ctx, run.cancel = context.WithTimeout(ctx, timeout)
+	}
+	return run
+}
+
+// finishURL ends run, records its outcome and reports it. The article is nil unless
+// one was generated; an existing article is ErrAlreadyExists.
+func (p *ArticleProcessor) finishURL(run *urlRun, filename string, article *Article, err error) ProcessingResult {
+	run.cancel()
+	p.recordOutcome(run.source, filename, err)
+	if p.jsonlOut != nil {
+		p.writeJSONL(run.source, filename, article, err)
+	}
+
+	result := ProcessingResult{
+		URL:      run.url,
+		Status:   outcomeStatus(err),
+		Filename: filename,
+		Error:    err,
+		Duration: time.Since(run.start),
+		Usage:    run.meter.total(),
+	}
+	if article != nil {
+		result.Title = article.Title
+		result.Categories = article.Categories
+		result.WordCount = len(strings.Fields(article.Content))
+		result.BrokenLinks = article.BrokenLinks
+	}
+	return result
+}
+
+// logSkip logs why a URL is skipped, unless p.quietSkips is set
+func (p *ArticleProcessor) logSkip(ctx context.Context, format string, args ...any) {
+	if !p.quietSkips {
+		logf(ctx, format, args...)
+	}
+}
+
+// writeStep writes and saves the articles the planner stage planned for a URL and
+// returns the first one generated, with its filename
+type writeStep func() (string, *Article, error)
+
+// planURL runs the pipeline for one URL up to and including planning. It returns the
+// write step that finishes the URL, or, when nothing is left to write, the outcome:
+// the article generated, or nil when the URL was skipped, left unchanged or only dry
+// run. An existing article is ErrAlreadyExists, returned with its filename.
+func (p *ArticleProcessor) planURL(ctx context.Context, url string, rewrite bool) (filename string, article *Article, write writeStep, err error) {
+	// Check if article already exists; a split source is found by its first segment
+	existingFile := p.findExistingFile(url)
+	split := false
+	if existingFile == "" && p.config.Settings.SplitLongSources {
+		existingFile = p.findExistingFile(segmentURLKey(url, 1))
+		split = existingFile != ""
+	}
+	if existingFile != "" && !rewrite && !p.force && !p.frontmatterOnly && !p.refresh {
+		p.logSkip(ctx, "→ Skipping existing: %s", existingFile)
+		return existingFile, nil, nil, fmt.Errorf("%w: %s", ErrAlreadyExists, existingFile)
+	}
+	if existingFile == "" && p.frontmatterOnly {
+		return "", nil, nil, fmt.Errorf("%w: %s", ErrNoArticle, url)
+	}
+
+	if p.debugDir != "" {
+		recorder, err := newDebugRecorder(p.debugDir, url)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		ctx = withDebugRecorder(ctx, recorder)
+	}
+
+	item := p.item(ctx, url)
+	if item.ContentType != "" {
+		ctx = withContentType(ctx, item.ContentType)
+	}
+	if item.Notes != "" {
+		ctx = withWriterNotes(ctx, item.Notes)
+	}
+
+	if domain, override := p.config.sourceOverride(p.extractDomain(url)); override != nil {
+		debugf(ctx, "Using source overrides for %s", domain)
+		ctx = withSourceOverride(ctx, override)
+	}
+
+	// Fetch content conditionally when refreshing an existing article. Explicit rewrites,
+	// forced runs and frontmatter-only runs regenerate unchanged sources too.
+	fetchCtx := ctx
+	if existingFile != "" && p.refresh && !rewrite && !p.force && !p.frontmatterOnly {
+		fetchCtx = withConditionalFetch(ctx)
+	}
+	content, err := p.fetcher.FetchContentContext(fetchCtx, url)
+	if errors.Is(err, ErrNotModified) {
+		logf(ctx, "→ Unchanged since last fetch, keeping: %s", existingFile)
+		return existingFile, nil, nil, nil
+	}
+	if err != nil {
+		return "", nil, nil, &FetchError{URL: url, Err: err}
+	}
+	if recorder := debugRecorderFrom(ctx); recorder != nil {
+		recorder.recordSource(url, content)
+	}
+	if content.SourceFile != "" {
+		defer func() { write = cleanupAfter(write, func() { os.Remove(content.SourceFile) }) }()
+	}
+	defer func() { write = p.saveValidatorsAfter(ctx, url, content, write) }()
+	ctx = withContentKind(ctx, content.Kind)
+
+	// Long sources become one article per topic with split_long_sources
+	if p.frontmatterOnly && split {
+		filename, article, err := p.refreshSegments(ctx, url, content)
+		return filename, article, nil, err
+	}
+	if !p.frontmatterOnly {
+		segments, err := p.splitSource(ctx, url, content)
+		if err != nil {
+			return "", nil, nil, &PlanError{URL: url, Err: err}
+		}
+		if len(segments) > 0 {
+			return p.planSegments(ctx, url, segments, rewrite)
+		}
+		if split {
+			p.warnStaleSegments(ctx, url, 2) // The first segment's file is rewritten below
+		}
+	}
+	filename, write, err = p.planArticle(ctx, url, url, existingFile, content)
+	return filename, nil, write, err
+}
+
+// cleanupAfter returns write followed by cleanup, or runs cleanup right away when
+// there is nothing left to write
+func cleanupAfter(write writeStep, cleanup func()) writeStep {
+	if write == nil {
+		cleanup()
+		return nil
+	}
+	return func() (string, *Article, error) {
+		defer cleanup()
+		return write()
+	}
+}
+
+// saveValidatorsAfter returns write followed by storing the response validators of
+// content for url, so a later --refresh only gets 304 Not Modified once the article
+// is saved. Without a write step, or in dry and diff-only runs, nothing is stored.
+func (p *ArticleProcessor) saveValidatorsAfter(ctx context.Context, url string, content *ContentResult, write writeStep) writeStep {
+	cacheDir := p.config.Settings.CacheDirectory
+	if write == nil || cacheDir == "" || p.dryRun || p.diffOnly || (content.ETag == "" && content.LastModified == "") {
+		return write
+	}
+	return func() (string, *Article, error) {
+		filename, article, err := write()
+		if err == nil {
+			if err := saveValidators(cacheDir, url, content.ETag, content.LastModified); err != nil {
+				debugf(ctx, "Failed to store validators for %s: %v", url, err)
+			}
+		}
+		return filename, article, err
+	}
+}
+
+// planAndWrite plans, writes and saves the article for content fetched from url,
+// like planArticle followed by its write step
+func (p *ArticleProcessor) planAndWrite(ctx context.Context, url, key, existingFile string, content *ContentResult) (string, *Article, error) {
+	filename, write, err := p.planArticle(ctx, url, key, existingFile, content)
+	if write == nil {
+		return filename, nil, err
+	}
+	return write()
+}
+
+// planArticle plans the article for content fetched from url, the whole source or one
+// segment of it, and returns the step that writes and saves it. key identifies the
+// article in its filename hash: the URL, or the segment key for segments. A dry run
+// returns the filename the article would be written to and no write step.
+func (p *ArticleProcessor) planArticle(ctx context.Context, url, key, existingFile string, content *ContentResult) (string, writeStep, error) {
+	// Generate metadata using planner agent
+	metadata, err := p.agents.PlanMetadata(ctx, url, content)
+	if err != nil {
+		return "", nil, &PlanError{URL: url, Err: err}
+	}
+
+	// Skip off-topic sources before the writer call
+	if only := p.config.Settings.OnlyCategories; len(only) > 0 && !matchesCategories(metadata.Categories, only) {
+		return "", nil, fmt.Errorf("%w: %s", ErrCategoryMismatch, strings.Join(metadata.Categories, ", "))
+	}
+
+	if p.dryRun {
+		filename := existingFile
+		if filename == "" {
+			filename, err = p.resolveFilename(key, &Article{Title: metadata.Title, Categories: metadata.Categories, OriginalURL: originalURLFrom(ctx)})
+			if err != nil {
+				return "", nil, &SaveError{URL: url, Err: err}
+			}
+		}
+		logf(ctx, "→ Dry run: %q [%s] would be written to %s", metadata.Title, strings.Join(metadata.Categories, ", "), p.draftFilename(filename))
+		return filename, nil, nil
+	}
+
+	return "", func() (string, *Article, error) {
+		return p.writeArticle(ctx, url, key, existingFile, content, metadata)
+	}, nil
+}
+
+// writeArticle writes, post-processes and saves the article planned with metadata
+func (p *ArticleProcessor) writeArticle(ctx context.Context, url, key, existingFile string, content *ContentResult, metadata *FrontmatterMetadata) (string, *Article, error) {
+	// A frontmatter-only refresh keeps the existing body and skips the writer
+	var article *Article
+	var err error
+	if p.frontmatterOnly {
+		article, err = p.refreshFrontmatter(ctx, url, existingFile, content, metadata)
+		if err != nil {
+			return "", nil, &SaveError{URL: url, Filename: existingFile, Err: err}
+		}
+	}
+
+	// Generate article with single AI call, plus any revisions for banned patterns,
+	// again for as long as the reviewer asks for a regeneration
+	for !p.frontmatterOnly {
+		article, err = p.generateArticle(ctx, url, content, metadata)
+		if err == nil {
+			err = p.enforceBannedPatterns(ctx, article)
+		}
+		if err != nil {
+			return "", nil, &WriteError{URL: url, Err: err}
+		}
+
+		if err := p.checkMarkdown(ctx, url, article); err != nil {
+			return "", nil, &WriteError{URL: url, Err: err}
+		}
+
+		regenerate, err := p.reviewArticle(url, article)
+		if err != nil {
+			return "", nil, err
+		}
+		if !regenerate {
+			break
+		}
+	}
+
+	p.checkDuplicateTitle(ctx, article)
+
+	if p.postProcess != nil {
+		if err := p.postProcess(article); err != nil {
+			return "", nil, &PostProcessError{URL: url, Err: err}
+		}
+	}
+
+	// The kept body of a frontmatter-only refresh already has its attribution
+	if tmpl := p.config.Settings.AttributionTemplate; tmpl != "" && !p.frontmatterOnly {
+		article.Content = appendAttribution(article.Content, tmpl, article)
+	}
+
+	// Generate filename
+	filename := existingFile
+	if filename == "" {
+		filename, err = p.resolveFilename(key, article)
+		if err != nil {
+			return "", nil, &SaveError{URL: url, Err: err}
+		}
+	}
+
+	if p.diffOut != nil && existingFile != "" {
+		if err := p.writeDiff(filename, article); err != nil {
+			return "", nil, &SaveError{URL: url, Filename: filename, Err: err}
+		}
+		if p.diffOnly {
+			logf(ctx, "→ Diff only, not writing: %s", filename)
+			return filename, article, nil
+		}
+	}
+
+	// Save article, in draft mode next to the other drafts even when rewriting a published one
+	filename = p.draftFilename(filename)
+	err = p.saveArticle(filename, article)
+	if err != nil {
+		return "", nil, &SaveError{URL: url, Filename: filename, Err: err}
+	}
+
+	if p.config.Settings.SaveSource {
+		if err := p.saveSource(filename, content); err != nil {
+			return "", nil, &SaveError{URL: url, Filename: filename, Err: err}
+		}
+	}
+
+	p.recordTitle(article.Title)
+	logf(ctx, "✓ Saved: %s", filename)
+
+	if p.checkLinks {
+		article.BrokenLinks = p.checkArticleLinks(ctx, article.Content)
+		for _, link := range article.BrokenLinks {
+			logf(ctx, "Warning: broken link in %s: %s", filename, link)
+		}
+		if len(article.BrokenLinks) > 0 && p.config.Settings.FailOnBrokenLinks {
+			return filename, article, &PostProcessError{URL: url, Err: fmt.Errorf("%w: %d in %s", ErrBrokenLinks, len(article.BrokenLinks), filename)}
+		}
+	}
+	return filename, article, nil
+}
+
+// stageSlots returns a semaphore for n concurrent calls, or nil when n is zero
+func stageSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// acquireSlot waits for a free slot in slots, or until ctx is done, and returns the
+// function that frees it; a nil slots never waits
+func acquireSlot(ctx context.Context, slots chan struct{}) (func(), error) {
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// attributionPlaceholders are the variables available in Settings.AttributionTemplate
+var attributionPlaceholders = []string{"{source_url}", "{source_domain}", "{date}"}
+
+// appendAttribution renders the attribution template for article and appends it to
+// content, first removing a footer rendered from the same template so rewrites don't
+// append it twice
+func appendAttribution(content, tmpl string, article *Article) string {
+	footer := strings.NewReplacer(
+		"{source_url}", article.SourceURL,
+		"{source_domain}", article.SourceDomain,
+		"{date}", article.CreatedAt.Format("2006-01-02"),
+	).Replace(tmpl)
+
+	pattern := regexp.QuoteMeta(strings.TrimSpace(tmpl))
+	for _, placeholder := range attributionPlaceholders {
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta(placeholder), ".*?")
+	}
+	existing := regexp.MustCompile(`\s*` + pattern + `\s*$`)
+	content = existing.ReplaceAllString(content, "")
+
+	return strings.TrimRight(content, "\n") + "\n\n" + strings.TrimSpace(footer) + "\n"
+}
+
+// checkDuplicateTitle warns when the article's title closely matches one saved
+// earlier in this run, and with duplicate_titles "disambiguate" appends the source domain
+func (p *ArticleProcessor) checkDuplicateTitle(ctx context.Context, article *Article) {
+	p.titlesMu.Lock()
+	defer p.titlesMu.Unlock()
+
+	for _, seen := range p.titles {
+		if titleSimilarity(seen, article.Title) < similarTitleThreshold {
+			continue
+		}
+		if p.config.Settings.DuplicateTitles == "disambiguate" {
+			disambiguated := fmt.Sprintf("%s (%s)", article.Title, article.SourceDomain)
+			logf(ctx, "→ Title %q is similar to %q, renaming to %q", article.Title, seen, disambiguated)
+			article.Title = disambiguated
+		} else {
+			logf(ctx, "Warning: title %q is similar to %q from this run", article.Title, seen)
+		}
+		break
+	}
+}
+
+// recordTitle adds the title of a saved article to those checkDuplicateTitle compares
+// against; titles of URLs that fail before saving aren't recorded
+func (p *ArticleProcessor) recordTitle(title string) {
+	p.titlesMu.Lock()
+	defer p.titlesMu.Unlock()
+	p.titles = append(p.titles, title)
+}
+
+// titleSimilarity returns the Jaccard overlap of the titles' lowercase words
+func titleSimilarity(a, b string) float64 {
+	wordsA := titleWords(a)
+	wordsB := titleWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// enforceBannedPatterns fails when the article matches a banned pattern, or with
+// banned_action "revise" asks the writer for one revision and checks again
+func (p *ArticleProcessor) enforceBannedPatterns(ctx context.Context, article *Article) error {
+	patterns := p.config.Settings.BannedPatterns
+	pattern, match, err := findBannedPattern(article.Content, patterns)
+	if err != nil || pattern == "" {
+		return err
+	}
+
+	if p.config.Settings.BannedAction != "revise" {
+		return fmt.Errorf("%w: pattern %q matched %q", ErrBannedContent, pattern, match)
+	}
+
+	logf(ctx, "→ Banned pattern %q matched %q, asking writer to revise", pattern, match)
+	instruction := fmt.Sprintf("Remove or rephrase every passage matching the regular expression %q, such as %q. Keep everything else unchanged.", pattern, match)
+	revised, err := p.agents.Revise(ctx, article.Content, instruction)
+	if err != nil {
+		return err
+	}
+
+	pattern, match, err = findBannedPattern(revised, patterns)
+	if err != nil {
+		return err
+	}
+	if pattern != "" {
+		return fmt.Errorf("%w after revision: pattern %q matched %q", ErrBannedContent, pattern, match)
+	}
+
+	article.Content = revised
+	return nil
+}
+
+// findBannedPattern returns the first pattern matching content and the matched text
+func findBannedPattern(content string, patterns []string) (string, string, error) {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid banned pattern: %w", err)
+		}
+		if match := re.FindString(content); match != "" {
+			return pattern, match, nil
+		}
+	}
+	return "", "", nil
+}
+
+// matchesCategories reports whether any planned category equals a wanted one or
+// falls under it (e.g. "Development" matches "Development/Programming"), ignoring case
+func matchesCategories(planned, wanted []string) bool {
+	for _, category := range planned {
+		category = strings.ToLower(strings.TrimSpace(category))
+		for _, w := range wanted {
+			w = strings.ToLower(strings.TrimSpace(w))
+			if category == w || strings.HasPrefix(category, w+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// saveSource writes the fetched source next to the article (slug-hash.source.md or .source.pdf)
+func (p *ArticleProcessor) saveSource(filename string, content *ContentResult) error {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	if content.SourceFile != "" {
+		data, err := os.ReadFile(content.SourceFile)
+		if err != nil {
+			return fmt.Errorf("reading source file: %w", err)
+		}
+		if err := os.WriteFile(base+".source.pdf", data, 0644); err != nil {
+			return fmt.Errorf("writing source file: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(base+".source.md", []byte(content.Text), 0644); err != nil {
+		return fmt.Errorf("writing source file: %w", err)
+	}
+	return nil
+}
+
+// ArticleItem represents a single article URL in the configuration
+type ArticleItem struct {
+	URL         string `yaml:"url"`
+	Skip        bool   `yaml:"skip"`         // Keep the URL in the config without processing it
+	Slug        string `yaml:"slug"`         // Used instead of the slug generated from the title
+	Filename    string `yaml:"filename"`     // Path relative to the output directory, used instead of year/month/slug
+	ContentType string `yaml:"content_type"` // Selects the content handler instead of the response's Content-Type, e.g. "application/pdf"
+	Notes       string `yaml:"notes"`        // Guidance for the writer the source doesn't contain, e.g. "focus on the security implications"
+}
+
+// URLConfig represents the YAML configuration structure for URL loading
+type URLConfig struct {
+	Items []ArticleItem `yaml:"items"`
+}
+
+// loadConfig loads configuration from YAML file
+func (ap *ArticleProcessor) loadConfig(configPath string) (*URLConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var config URLConfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("parsing YAML configuration: %w", err)
+	}
+
+	// Validate configuration structure
+	if err := ap.validateConfig(&config, configPath); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// validateConfig validates the loaded configuration structure
+func (ap *ArticleProcessor) validateConfig(config *URLConfig, configPath string) error {
+	if len(config.Items) == 0 {
+		return fmt.Errorf("configuration is wrong. Example:\nitems:\n  - url: \"https://example.com/article1\"")
+	}
+
+	// Validate each item has a URL
+	for i, item := range config.Items {
+		url := strings.TrimSpace(item.URL)
+		if url == "" {
+			return fmt.Errorf("item %d has empty URL", i+1)
+		}
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("item %d has invalid URL: %s", i+1, url)
+		}
+		if item.Slug != "" && item.Filename != "" {
+			return fmt.Errorf("item %d sets both slug and filename", i+1)
+		}
+		if item.Slug != "" && generateSlug(item.Slug) == "" {
+			return fmt.Errorf("item %d has invalid slug: %q", i+1, item.Slug)
+		}
+		if item.Filename != "" && !filepath.IsLocal(strings.TrimSuffix(item.Filename, ".md")) {
+			return fmt.Errorf("item %d filename must be a relative path inside the output directory: %q", i+1, item.Filename)
+		}
+		if item.ContentType != "" {
+			if _, _, err := mime.ParseMediaType(item.ContentType); err != nil {
+				return fmt.Errorf("item %d has invalid content_type %q: %w", i+1, item.ContentType, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadURLs loads and validates the URL list from a YAML file, directory or glob pattern
+func LoadURLs(configPath string) ([]string, error) {
+	return (&ArticleProcessor{}).loadURLsFromFile(configPath)
+}
+
+// loadURLsFromFile loads URLs from a YAML file, or merges them from every YAML
+// file matched by a directory or glob pattern
+func (p *ArticleProcessor) loadURLsFromFile(configPath string) ([]string, error) {
+	files, err := resolveConfigFiles(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	skipped := 0
+	items := make(map[string]ArticleItem)
+	targets := make(map[string]string) // Overridden slug or filename -> URL, to detect collisions
+	for _, file := range files {
+		config, err := p.loadConfig(file)
+		if err != nil {
+			if len(files) > 1 {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			return nil, err
+		}
+		for _, item := range config.Items {
+			if item.Skip {
+				skipped++
+				continue
+			}
+			item.URL = strings.TrimSpace(item.URL)
+			urls = append(urls, item.URL)
+
+			if target := itemTarget(item); target != "" {
+				if other, ok := targets[target]; ok && normalizeURL(other) != normalizeURL(item.URL) {
+					return nil, fmt.Errorf("%s: %s and %s have the same output filename %q", file, other, item.URL, target)
+				}
+				targets[target] = item.URL
+			}
+			if item.Slug != "" || item.Filename != "" || item.ContentType != "" || item.Notes != "" {
+				items[normalizeURL(item.URL)] = item
+			}
+		}
+	}
+
+	if len(files) > 1 {
+		log.Printf("→ Loaded %d URLs from %d config files", len(urls), len(files))
+	}
+	if skipped > 0 {
+		log.Printf("→ Skipping %d URLs marked skip: true", skipped)
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no valid URLs found in %s", configPath)
+	}
+	p.items = items
+	return dedupeURLs(urls), nil
+}
+
+// item returns the config item for url, or for the short URL it was expanded from
+func (p *ArticleProcessor) item(ctx context.Context, url string) ArticleItem {
+	if original := originalURLFrom(ctx); original != "" {
+		url = original
+	}
+	return p.items[normalizeURL(url)]
+}
+
+// itemTarget returns the normalized filename or slug an item overrides, or "" when it sets neither
+func itemTarget(item ArticleItem) string {
+	switch {
+	case item.Filename != "":
+		return "filename:" + filepath.ToSlash(filepath.Clean(strings.TrimSuffix(item.Filename, ".md")))
+	case item.Slug != "":
+		return "slug:" + generateSlug(item.Slug)
+	}
+	return ""
+}
+
+// resolveConfigFiles expands a config path into the YAML files it refers to:
+// a directory yields its *.yaml and *.yml files, a glob pattern its matches,
+// and anything else is returned as-is
+func resolveConfigFiles(configPath string) ([]string, error) {
+	if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+		var files []string
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(configPath, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no YAML config files in directory %s", configPath)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	if !strings.ContainsAny(configPath, "*?[") {
+		return []string{configPath}, nil
+	}
+
+	files, err := filepath.Glob(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config pattern %q: %w", configPath, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files match %s", configPath)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// dedupeURLs removes URLs that normalize to an earlier entry, keeping the first occurrence
+func dedupeURLs(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	var unique []string
+	for _, u := range urls {
+		key := normalizeURL(u)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, u)
+	}
+
+	if duplicates := len(urls) - len(unique); duplicates > 0 {
+		log.Printf("→ Collapsed %d duplicate URLs", duplicates)
+	}
+	return unique
+}
+
+// normalizeURL returns a comparison key for a URL: lowercase scheme and host,
+// no default port, fragment or trailing slash
+func normalizeURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return strings.TrimSpace(rawURL)
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Host)
+	if (parsed.Scheme == "http" && strings.HasSuffix(host, ":80")) || (parsed.Scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	parsed.RawPath = strings.TrimSuffix(parsed.RawPath, "/")
+
+	return parsed.String()
+}
+
+// generateArticle creates an article using the AgentManager
+func (p *ArticleProcessor) generateArticle(ctx context.Context, url string, content *ContentResult, metadata *FrontmatterMetadata) (*Article, error) {
+	// Use AgentManager to write the article with configured prompts
+	articleContent, err := p.agents.Write(ctx, content, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("AI generation failed: %w", err)
+	}
+	return p.newArticle(ctx, url, content, metadata, articleContent)
+}
+
+// refreshFrontmatter builds the article for a frontmatter-only run from the new plan
+// and the body of the existing article, keeping its date and writer model
+func (p *ArticleProcessor) refreshFrontmatter(ctx context.Context, url, existingFile string, content *ContentResult, metadata *FrontmatterMetadata) (*Article, error) {
+	existing, err := readArticleFile(existingFile)
+	if err != nil {
+		return nil, err
+	}
+	article, err := p.newArticle(ctx, url, content, metadata, existing.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !existing.Date.IsZero() {
+		article.CreatedAt = existing.Date
+	}
+	if existing.WriterModel != "" {
+		article.WriterModel = existing.WriterModel
+	}
+	logf(ctx, "→ Refreshing frontmatter, keeping the body of %s", existingFile)
+	return article, nil
+}
+
+// newArticle assembles the article for url from the plan and the written body
+func (p *ArticleProcessor) newArticle(ctx context.Context, url string, content *ContentResult, metadata *FrontmatterMetadata, articleContent string) (*Article, error) {
+	// Get model info from agents
+	plannerModel, writerModel := p.agents.GetModelInfo()
+
+	// Extract domain from URL
+	sourceDomain := p.extractDomain(url)
+
+	promptVersion, err := p.config.PromptVersion(sourceDomain, metadata.PromptVariant, content.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	article := &Article{
+		Title:        metadata.Title,
+		SourceURL:    url,
+		SourceDomain: sourceDomain,
+		Content:      articleContent,
+		CreatedAt:    time.Now(),
+		Draft:        p.publishedDir != "",
+		Categories:   metadata.Categories,
+		Tags:         metadata.Tags,
+		PlannerModel: plannerModel,
+		WriterModel:  writerModel,
+		Deck:         metadata.Deck,
+
+		MetaDescription: metadata.MetaDescription,
+		Keywords:        metadata.Keywords,
+
+		PlannerPromptVariant: metadata.PromptVariant,
+		PromptVersion:        promptVersion,
+		ExtraFrontmatter:     maps.Clone(p.config.Settings.ExtraFrontmatter),
+
+		OriginalURL: originalURLFrom(ctx),
+		SourceType:  content.Kind,
+		Segment:     segmentFrom(ctx),
+	}
+	if p.config.summaryMode() {
+		article.Mode = ModeSummary
+	}
+	if limit := p.config.Settings.MaxSourceLinks; limit > 0 && len(content.Links) > 0 {
+		article.SourceLinks = content.Links[:min(limit, len(content.Links))]
+	}
+	if p.config.Settings.IncludeTargetInFrontmatter {
+		article.Tone = metadata.Target.Tone
+		article.Audience = metadata.Target.Audience
+	}
+	if p.config.Settings.IncludeSourceDate {
+		article.SourceDate = content.SourceDate
+	}
+	if p.config.Settings.IncludeFetchMetadata {
+		article.FetchedAt = content.FetchedAt
+		article.HTTPStatus = content.HTTPStatus
+		article.FinalURL = content.FinalURL
+	}
+	return article, nil
+}
+
+// extractTitle extracts the first # heading from markdown content
+func (p *ArticleProcessor) extractTitle(content string) string {
+	lines := strings.Split(content, "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		}
+	}
+	return ""
+}
+
+// extractDomain extracts the domain from a URL
+func (p *ArticleProcessor) extractDomain(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsedURL.Host
+}
+
+// generateFilename creates a hash-based filename in the article directory for categories
+func (p *ArticleProcessor) generateFilename(url, title string, categories []string) string {
+	slug := p.titleSlug(title)
+	hash := p.generateURLHash(url)
+
+	return filepath.Join(p.articleDir(url, categories), fmt.Sprintf("%s-%s.md", slug, hash))
+}
+
+// articleDir returns (and creates) the directory new articles from url are written to:
+// year/month subdirectories (unless date_subdirs is off), under the primary category's
+// directory with category_subdirs, under the directory of the first matching output rule
+func (p *ArticleProcessor) articleDir(url string, categories []string) string {
+	outputDir := p.config.Settings.OutputDirectory
+	if rule := p.outputRule(url, categories); rule != nil {
+		outputDir = filepath.Join(outputDir, filepath.FromSlash(rule.Directory))
+	}
+	if p.config.Settings.CategorySubdirs {
+		outputDir = filepath.Join(outputDir, p.categoryDir(categories))
+	}
+	if date := p.config.Settings.DateSubdirs; date == nil || *date {
+		now := time.Now()
+		outputDir = filepath.Join(outputDir, now.Format("2006"), now.Format("01"))
+	}
+
+	// Ensure output directory exists
+	os.MkdirAll(outputDir, 0755)
+
+	return outputDir
+}
+
+// outputRule returns the first output rule matching url's domain and the planned
+// categories, or nil; a rule with both a domain and a category must match both
+func (p *ArticleProcessor) outputRule(url string, categories []string) *OutputRule {
+	host := strings.ToLower(p.extractDomain(url))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for i, rule := range p.config.Settings.OutputRules {
+		domain := strings.ToLower(rule.Domain)
+		if domain != "" && host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		if rule.Category != "" && !matchesCategories(categories, []string{rule.Category}) {
+			continue
+		}
+		return &p.config.Settings.OutputRules[i]
+	}
+	return nil
+}
+
+// categoryDir returns the directory name for the primary (first) category. Slugifying
+// keeps names like "../x" or "A/B" to a single path element.
+func (p *ArticleProcessor) categoryDir(categories []string) string {
+	if len(categories) > 0 {
+		if slug := generateSlug(categories[0]); slug != "" {
+			return slug
+		}
+	}
+	return "uncategorized"
+}
+
+// includeHashInFilename reports whether filenames carry the URL hash (the default)
+func (p *ArticleProcessor) includeHashInFilename() bool {
+	include := p.config.Settings.IncludeHashInFilename
+	return include == nil || *include
+}
+
+// resolveFilename returns the filename for a new article. By default it is the slug
+// plus URL hash; with include_hash_in_filename off it is the bare slug, and a slug
+// already used anywhere in the output tree is resolved per slug_collision_strategy:
+// "numeric" appends -2, -3, ...; "content-hash" appends a hash of the article body;
+// "fail" returns ErrSlugCollision. A config item's slug or filename replaces the
+// generated name; without the hash, an existing file there is ErrSlugCollision.
+// Filenames outside the output directory are ErrUnsafePath.
+func (p *ArticleProcessor) resolveFilename(url string, article *Article) (string, error) {
+	filename, err := p.newFilename(url, article)
+	if err != nil {
+		return "", err
+	}
+	if err := p.checkInOutputDir(filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// newFilename implements resolveFilename before the output directory check
+func (p *ArticleProcessor) newFilename(url string, article *Article) (string, error) {
+	if base := p.itemFilename(url, article.OriginalURL, article.Categories); base != "" {
+		if p.includeHashInFilename() {
+			return fmt.Sprintf("%s-%s.md", base, p.generateURLHash(url)), nil
+		}
+		filename := base + ".md"
+		if _, err := os.Stat(filename); err == nil {
+			return "", fmt.Errorf("%w: %s", ErrSlugCollision, filename)
+		}
+		return filename, nil
+	}
+
+	if p.includeHashInFilename() {
+		return p.generateFilename(url, article.Title, article.Categories), nil
+	}
+
+	p.slugsMu.Lock()
+	defer p.slugsMu.Unlock()
+
+	if p.slugs == nil {
+		p.slugs = p.existingSlugs()
+	}
+	taken := p.slugs
+
+	slug := p.titleSlug(article.Title)
+	if taken[slug] {
+		switch p.config.Settings.SlugCollisionStrategy {
+		case "fail":
+			return "", fmt.Errorf("%w: %s", ErrSlugCollision, slug)
+		case "content-hash":
+			sum := sha256.Sum256([]byte(article.Content))
+			slug = fmt.Sprintf("%s-%x", slug, sum[:4])
+		}
+	}
+	for base, n := slug, 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	taken[slug] = true // Not reused before it is saved
+
+	return filepath.Join(p.articleDir(url, article.Categories), slug+".md"), nil
+}
+
+// itemFilename returns the path, without URL hash or extension, set for url by its config
+// item's filename or slug, or "" when it has neither. Items are looked up by originalURL
+// when the URL was expanded from it. A slug goes in the article directory
+// for categories. Directories are created as needed.
+func (p *ArticleProcessor) itemFilename(url, originalURL string, categories []string) string {
+	if originalURL != "" {
+		url = originalURL
+	}
+	item, ok := p.items[normalizeURL(url)]
+	switch {
+	case !ok:
+		return ""
+	case item.Filename != "":
+		base := filepath.Join(p.config.Settings.OutputDirectory, strings.TrimSuffix(item.Filename, ".md"))
+		os.MkdirAll(filepath.Dir(base), 0755)
+		return base
+	case item.Slug != "":
+		return filepath.Join(p.articleDir(url, categories), generateSlug(item.Slug))
+	}
+	return ""
+}
+
+// existingSlugs returns the base names of all articles in the output tree and, in
+// draft mode, the published tree, so drafts don't take a published article's slug
+func (p *ArticleProcessor) existingSlugs() map[string]bool {
+	slugs := make(map[string]bool)
+	roots := []string{p.config.Settings.OutputDirectory}
+	if p.publishedDir != "" {
+		roots = append(roots, p.publishedDir)
+	}
+	for _, root := range roots {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := d.Name()
+			if !d.IsDir() && strings.HasSuffix(name, ".md") && !strings.HasSuffix(name, ".source.md") {
+				slugs[strings.TrimSuffix(name, ".md")] = true
+			}
+			return nil
+		})
+	}
+	return slugs
+}
+
+// titleSlug returns the filename slug for title, "untitled" when it has no slug characters
+// (e.g. "..."), so filenames are never empty or hidden
+func (p *ArticleProcessor) titleSlug(title string) string {
+	if slug := generateSlug(title); slug != "" {
+		return slug
+	}
+	return "untitled"
+}
+
+// checkInOutputDir rejects filenames outside the output directory, should a path
+// component ever escape sanitizing
+func (p *ArticleProcessor) checkInOutputDir(filename string) error {
+	rel, err := filepath.Rel(p.config.Settings.OutputDirectory, filename)
+	if err != nil || !filepath.IsLocal(rel) || rel == "." {
+		return fmt.Errorf("%w: %s", ErrUnsafePath, filename)
+	}
+	return nil
+}
+
+// generateSlug creates a URL-safe slug from title
+func generateSlug(title string) string {
+	// Convert to lowercase and replace spaces/special chars with hyphens
+	slug := strings.ToLower(title)
+	slug = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(slug, "-")
+	slug = strings.Trim(slug, "-")
+
+	// Limit length
+	if len(slug) > 50 {
+		slug = slug[:50]
+	}
+
+	return slug
+}
+
+// generateURLHash creates a short hash of the URL
+func (p *ArticleProcessor) generateURLHash(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return fmt.Sprintf("%x", hash)[:8]
+}
+
+// findExistingFile finds an existing article file by URL (recursively)
+func (p *ArticleProcessor) findExistingFile(url string) string {
+	roots := []string{p.config.Settings.OutputDirectory}
+	if p.publishedDir != "" {
+		roots = append(roots, p.publishedDir) // Drafts first, then published articles
+	}
+	for _, root := range roots {
+		if existingFile := p.findExistingFileIn(root, url); existingFile != "" {
+			return existingFile
+		}
+	}
+	return ""
+}
+
+// findExistingFileIn finds the article for url under outputDir
+func (p *ArticleProcessor) findExistingFileIn(outputDir, url string) string {
+	urlHash := p.generateURLHash(url)
+	suffix := fmt.Sprintf("-%s.md", urlHash)
+
+	var existingFile string
+
+	// Walk the directory tree
+	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, suffix) {
+			existingFile = path
+			return filepath.SkipDir // Stop searching once found
+		}
+		return nil
+	})
+
+	if err != nil && !(p.publishedDir != "" && errors.Is(err, fs.ErrNotExist)) {
+		log.Printf("Error walking directory: %v", err)
+	}
+
+	if existingFile == "" && !p.includeHashInFilename() {
+		existingFile = p.findBySourceURL(outputDir, url)
+	}
+
+	return existingFile
+}
+
+// draftFilename maps a published article to the same path under the draft directory
+// in draft mode; other filenames are returned unchanged
+func (p *ArticleProcessor) draftFilename(filename string) string {
+	if p.publishedDir == "" || p.checkInOutputDir(filename) == nil {
+		return filename
+	}
+	rel, err := filepath.Rel(p.publishedDir, filename)
+	if err != nil || !filepath.IsLocal(rel) {
+		return filename
+	}
+	draft := filepath.Join(p.config.Settings.OutputDirectory, rel)
+	os.MkdirAll(filepath.Dir(draft), 0755)
+	return draft
+}
+
+// findBySourceURL finds an article whose frontmatter source_url is url, for filenames
+// without the URL hash. The frontmatter under outputDir is read once per run.
+func (p *ArticleProcessor) findBySourceURL(outputDir, url string) string {
+	p.sourceURLsMu.Lock()
+	defer p.sourceURLsMu.Unlock()
+
+	if p.sourceURLs == nil {
+		p.sourceURLs = make(map[string]map[string]string)
+	}
+	index, ok := p.sourceURLs[outputDir]
+	if !ok {
+		index = existingSourceURLs(outputDir)
+		p.sourceURLs[outputDir] = index
+	}
+	return index[url]
+}
+
+// recordSourceURL adds a saved article to the source_url index of the output root it is in
+func (p *ArticleProcessor) recordSourceURL(filename, url string) {
+	p.sourceURLsMu.Lock()
+	defer p.sourceURLsMu.Unlock()
+
+	for root, index := range p.sourceURLs {
+		if rel, err := filepath.Rel(root, filename); err == nil && filepath.IsLocal(rel) {
+			index[url] = filename
+		}
+	}
+}
+
+// existingSourceURLs maps the source_url of each article under outputDir to its file,
+// keeping the first article found for a URL
+func existingSourceURLs(outputDir string) map[string]string {
+	index := make(map[string]string)
+	filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".source.md") {
+			return nil
+		}
+		existing, err := readArticleFile(path)
+		if err != nil || existing.SourceURL == "" {
+			return nil
+		}
+		if _, ok := index[existing.SourceURL]; !ok {
+			index[existing.SourceURL] = path
+		}
+		return nil
+	})
+	return index
+}
+
+// coreFrontmatterKeys are rendered by the article template in a fixed order and can't be overridden by extras
+var coreFrontmatterKeys = map[string]bool{
+	"title": true, "date": true, "draft": true, "categories": true, "tags": true,
+	"planner_model": true, "writer_model": true, "planner_prompt_variant": true, "prompt_version": true, "deck": true,
+	"meta_description": true, "keywords": true, "tone": true, "audience": true,
+	"source_url": true, "original_url": true, "source_domain": true, "source_links": true, "source_type": true, "segment": true, "mode": true,
+	"source_date": true, "fetched_at": true, "http_status": true, "final_url": true,
+}
+
+// writeDiff writes the unified diff between the article on disk and its rewrite to p.diffOut
+func (p *ArticleProcessor) writeDiff(filename string, article *Article) error {
+	existing, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("reading existing article: %w", err)
+	}
+	rendered, err := p.renderArticle(article)
+	if err != nil {
+		return err
+	}
+
+	diff := unifiedDiff(filename, filename+" (rewrite)", string(existing), string(rendered))
+	if diff == "" {
+		_, err = fmt.Fprintf(p.diffOut, "No changes: %s\n", filename)
+	} else {
+		_, err = io.WriteString(p.diffOut, diff)
+	}
+	return err
+}
+
+// saveArticle saves the article to a file
+func (p *ArticleProcessor) saveArticle(filename string, article *Article) error {
+	rendered, err := p.renderArticle(article)
+	if err != nil {
+		return err
+	}
+
+	// Ensure directory exists
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	if err := os.WriteFile(filename, rendered, 0644); err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+	p.recordSourceURL(filename, article.SourceURL)
+	return nil
+}
+
+// frontmatterDialect returns Settings.FrontmatterDialect, defaulting to DialectYAML
+func (p *ArticleProcessor) frontmatterDialect() string {
+	if p.config != nil && p.config.Settings.FrontmatterDialect != "" {
+		return p.config.Settings.FrontmatterDialect
+	}
+	return DialectYAML
+}
+
+// renderArticle renders the article with its frontmatter in the configured dialect
+func (p *ArticleProcessor) renderArticle(article *Article) ([]byte, error) {
+	dialect := p.frontmatterDialect()
+	tmpl := frontmatterTemplates.Lookup(dialect)
+	if dialect == DialectYAML {
+		var err error
+		tmpl, err = template.New("article").Parse(defaultFrontmatterTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template: %w", err)
+		}
+	}
+
+	extra, err := renderExtraFrontmatter(article.ExtraFrontmatter, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	// Description is what the SSG dialects render as the page description
+	description := article.MetaDescription
+	if description == "" {
+		description = article.Deck
+	}
+	data := struct {
+		*Article
+		Extra       string
+		Description string
+	}{article, extra, description}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,2498 @@
+package newswriter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+func TestExtractTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"first heading", "# Title\nsome content", "Title"},
+		{"with spaces", "  # Spaced Title  \n", "Spaced Title"},
+		{"multiple headings", "# First\n## Second\n# Third", "First"},
+		{"no heading", "just text\nno heading", ""},
+		{"empty content", "", ""},
+		{"heading with prefix", "text\n# Real Title\nmore", "Real Title"},
+	}
+
+	p := &ArticleProcessor{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.extractTitle(tt.content)
+			if result != tt.expected {
+				t.Errorf("extractTitle() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateSlug(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		expected string
+	}{
+		{"basic", "Hello World", "hello-world"},
+		{"special chars", "Title: With & Special!", "title-with-special"},
+		{"unicode", "Café & Naïve", "caf-na-ve"},
+		{"numbers", "React 18.2 Guide", "react-18-2-guide"},
+		{"empty", "", ""},
+		{"long title", strings.Repeat("word ", 20), strings.Repeat("word-", 10)[:50]},
+		{"hyphen trimming", "---start---", "start"},
+		{"path traversal", "../../etc/passwd", "etc-passwd"},
+		{"dots only", "../..", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateSlug(tt.title)
+			if result != tt.expected {
+				t.Errorf("generateSlug() = %q, want %q", result, tt.expected)
+			}
+			if len(result) > 50 {
+				t.Errorf("generateSlug() result too long: %d chars", len(result))
+			}
+		})
+	}
+}
+
+func TestGenerateURLHash(t *testing.T) {
+	p := &ArticleProcessor{}
+
+	url1 := "https://example.com/article1"
+	url2 := "https://example.com/article2"
+
+	hash1 := p.generateURLHash(url1)
+	hash2 := p.generateURLHash(url2)
+
+	if len(hash1) != 8 {
+		t.Errorf("hash length = %d, want 8", len(hash1))
+	}
+
+	if hash1 == hash2 {
+		t.Error("different URLs produced same hash")
+	}
+
+	hash1Again := p.generateURLHash(url1)
+	if hash1 != hash1Again {
+		t.Error("same URL produced different hashes")
+	}
+}
+
+func TestSaveArticle(t *testing.T) {
+	p := &ArticleProcessor{}
+	tempDir := t.TempDir()
+
+	article := &Article{
+		Title:     "Test Title",
+		SourceURL: "https://example.com",
+		Content:   "# Test\n\nContent here",
+		CreatedAt: time.Now(),
+	}
+
+	filename := filepath.Join(tempDir, "test.md")
+	err := p.saveArticle(filename, article)
+	if err != nil {
+		t.Fatalf("saveArticle() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read saved file: %v", err)
+	}
+
+	contentStr := string(content)
+	if !strings.Contains(contentStr, "Test Title") {
+		t.Error("saved file missing title")
+	}
+
+	if !strings.Contains(contentStr, "https://example.com") {
+		t.Error("saved file missing source URL")
+	}
+}
+
+func TestGenerateFilename(t *testing.T) {
+	// Create a processor with mock config
+	config := &Config{
+		Settings: &Settings{
+			OutputDirectory: "articles",
+		},
+	}
+	p := &ArticleProcessor{
+		config: config,
+	}
+	tempDir := t.TempDir()
+
+	// Change to temp directory for test
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tempDir)
+
+	// Generate filename
+	filename := p.generateFilename("https://example.com", "Test Title", nil)
+
+	// Check for year/month in path
+	now := time.Now()
+	year := now.Format("2006")
+	month := now.Format("01")
+	expectedDir := filepath.Join("articles", year, month)
+
+	if !strings.HasPrefix(filename, expectedDir) {
+		t.Errorf("expected filename to be in %s, got %s", expectedDir, filename)
+	}
+
+	// Check for slug and hash
+	slug := "test-title"
+	hash := p.generateURLHash("https://example.com")
+	expectedSuffix := slug + "-" + hash + ".md"
+
+	if !strings.HasSuffix(filename, expectedSuffix) {
+		t.Errorf("expected filename to have suffix %s, got %s", expectedSuffix, filename)
+	}
+}
+
+func TestFindExistingFile(t *testing.T) {
+	// Create a processor with mock config
+	config := &Config{
+		Settings: &Settings{
+			OutputDirectory: "articles",
+		},
+	}
+	p := &ArticleProcessor{
+		config: config,
+	}
+	tempDir := t.TempDir()
+
+	// Change to temp directory for test
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tempDir)
+
+	// Create articles directory
+	os.MkdirAll("articles", 0755)
+
+	// Test non-existent file
+	result := p.findExistingFile("https://nonexistent.com")
+	if result != "" {
+		t.Errorf("expected empty string for non-existent file, got %s", result)
+	}
+
+	// Create test file
+	hash := p.generateURLHash("https://example.com")
+	testFile := filepath.Join("articles", "test-"+hash+".md")
+	os.WriteFile(testFile, []byte("test"), 0644)
+
+	// Test existing file
+	result = p.findExistingFile("https://example.com")
+	if result != testFile {
+		t.Errorf("expected %s, got %s", testFile, result)
+	}
+}
+
+func TestFindExistingFileRecursive(t *testing.T) {
+	// Create a processor with mock config
+	config := &Config{
+		Settings: &Settings{
+			OutputDirectory: "articles",
+		},
+	}
+	p := &ArticleProcessor{
+		config: config,
+	}
+	tempDir := t.TempDir()
+
+	// Change to temp directory for test
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tempDir)
+
+	// Create test file in a nested directory
+	hash := p.generateURLHash("https://example.com/nested")
+	nestedDir := filepath.Join("articles", "2025", "09")
+	os.MkdirAll(nestedDir, 0755)
+	nestedFile := filepath.Join(nestedDir, "nested-test-"+hash+".md")
+	os.WriteFile(nestedFile, []byte("nested test"), 0644)
+
+	// Test existing file (recursive)
+	result := p.findExistingFile("https://example.com/nested")
+	if result != nestedFile {
+		t.Errorf("expected %s, got %s", nestedFile, result)
+	}
+}
+
+func TestLoadURLsFromFile(t *testing.T) {
+	p := &ArticleProcessor{}
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    []string
+		expectError bool
+	}{
+		{
+			"basic urls",
+			"items:\n  - url: \"https://example.com\"\n  - url: \"https://test.com\"",
+			[]string{"https://example.com", "https://test.com"},
+			false,
+		},
+		{
+			"with empty url",
+			"items:\n  - url: \"https://example.com\"\n  - url: \"\"\n  - url: \"https://test.com\"",
+			nil,
+			true,
+		},
+		{
+			"invalid urls",
+			"items:\n  - url: \"https://example.com\"\n  - url: \"invalid-url\"\n  - url: \"ftp://test.com\"",
+			nil,
+			true,
+		},
+		{
+			"empty sources",
+			"items: []",
+			nil,
+			true,
+		},
+		{
+			"duplicate urls",
+			"items:\n  - url: \"https://example.com/a\"\n  - url: \"https://EXAMPLE.com/a/\"\n  - url: \" https://example.com/a#intro\"\n  - url: \"https://example.com/b\"",
+			[]string{"https://example.com/a", "https://example.com/b"},
+			false,
+		},
+		{
+			"slug and filename",
+			"items:\n  - url: \"https://example.com\"\n    slug: intro\n    filename: guides/intro",
+			nil,
+			true,
+		},
+		{
+			"filename outside output directory",
+			"items:\n  - url: \"https://example.com\"\n    filename: ../intro",
+			nil,
+			true,
+		},
+		{
+			"colliding filenames",
+			"items:\n  - url: \"https://example.com/a\"\n    filename: guides/intro.md\n  - url: \"https://example.com/b\"\n    filename: guides/intro",
+			nil,
+			true,
+		},
+		{
+			"colliding slugs",
+			"items:\n  - url: \"https://example.com/a\"\n    slug: My Intro\n  - url: \"https://example.com/b\"\n    slug: my-intro",
+			nil,
+			true,
+		},
+		{
+			"invalid content type",
+			"items:\n  - url: \"https://example.com/paper\"\n    content_type: \"pdf;;\"",
+			nil,
+			true,
+		},
+		{
+			"content type",
+			"items:\n  - url: \"https://example.com/paper\"\n    content_type: application/pdf",
+			[]string{"https://example.com/paper"},
+			false,
+		},
+		{
+			"skipped items",
+			"items:\n  - url: \"https://example.com\"\n    skip: true\n  - url: \"https://test.com\"\n    skip: false",
+			[]string{"https://test.com"},
+			false,
+		},
+		{
+			"all items skipped",
+			"items:\n  - url: \"https://example.com\"\n    skip: true",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(tempDir, "test.yaml")
+			os.WriteFile(filename, []byte(tt.content), 0644)
+
+			result, err := p.loadURLsFromFile(filename)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("loadURLsFromFile() error = %v", err)
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Errorf("got %d URLs, want %d", len(result), len(tt.expected))
+			}
+
+			for i, url := range result {
+				if i >= len(tt.expected) || url != tt.expected[i] {
+					t.Errorf("URL %d: got %q, want %q", i, url, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProcessURLsFromFileNoURLs(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "articles.yaml")
+	os.WriteFile(configPath, []byte("items:\n  - url: \"https://example.com\"\n    skip: true\n"), 0644)
+
+	prompter := &routingPrompter{}
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+
+	err := p.ProcessURLsFromFile(configPath)
+	if err == nil || !strings.Contains(err.Error(), "no valid URLs found in "+configPath) {
+		t.Errorf("ProcessURLsFromFile() error = %v, want no valid URLs found", err)
+	}
+	if prompter.calls != 0 {
+		t.Errorf("prompter called %d times for an empty URL list", prompter.calls)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	ap := &ArticleProcessor{}
+
+	tests := []struct {
+		name        string
+		config      *URLConfig
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "empty config",
+			config:      &URLConfig{Items: []ArticleItem{}},
+			expectError: true,
+			errorMsg:    "configuration is wrong",
+		},
+		{
+			name: "valid config",
+			config: &URLConfig{Items: []ArticleItem{
+				{URL: "https://example.com/article1"},
+				{URL: "http://example.com/article2"},
+			}},
+			expectError: false,
+		},
+		{
+			name: "empty URL",
+			config: &URLConfig{Items: []ArticleItem{
+				{URL: "https://example.com/article1"},
+				{URL: "   "},
+			}},
+			expectError: true,
+			errorMsg:    "item 2 has empty URL",
+		},
+		{
+			name: "invalid URL format",
+			config: &URLConfig{Items: []ArticleItem{
+				{URL: "https://example.com/article1"},
+				{URL: "ftp://example.com/article2"},
+			}},
+			expectError: true,
+			errorMsg:    "item 2 has invalid URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ap.validateConfig(tt.config, "test-config.yaml")
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error to contain '%s', got: %s", tt.errorMsg, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("expected no error but got: %s", err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestNewArticleProcessor(t *testing.T) {
+	tests := []struct {
+		name      string
+		apiKey    string
+		overrides *ConfigOverrides
+		wantErr   bool
+	}{
+		{"valid api key", "test-key", nil, false},
+		{"empty api key", "", nil, true},
+		{"with overrides", "test-key", &ConfigOverrides{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewArticleProcessor(tt.apiKey, WithOverrides(tt.overrides))
+
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !tt.wantErr && processor == nil {
+				t.Error("expected processor, got nil")
+			}
+		})
+	}
+}
+
+func TestProcessURLsValidation(t *testing.T) {
+	p := &ArticleProcessor{}
+
+	tests := []struct {
+		name     string
+		urls     []string
+		errorMsg string
+	}{
+		{"no urls", nil, "configuration is wrong"},
+		{"empty url", []string{"https://example.com", " "}, "item 2 has empty URL"},
+		{"invalid url", []string{"ftp://example.com"}, "item 1 has invalid URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.ProcessURLs(tt.urls)
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error to contain '%s', got: %s", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestFailureStage(t *testing.T) {
+	cause := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"fetch", &FetchError{URL: "https://example.com", Err: cause}, "fetch"},
+		{"plan", &PlanError{URL: "https://example.com", Err: cause}, "plan"},
+		{"write", &WriteError{URL: "https://example.com", Err: cause}, "write"},
+		{"postprocess", &PostProcessError{URL: "https://example.com", Err: cause}, "postprocess"},
+		{"save", &SaveError{URL: "https://example.com", Err: cause}, "save"},
+		{"wrapped", fmt.Errorf("outer: %w", &PlanError{Err: cause}), "plan"},
+		{"untyped", cause, "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := failureStage(tt.err); result != tt.expected {
+				t.Errorf("failureStage() = %q, want %q", result, tt.expected)
+			}
+			if !errors.Is(tt.err, cause) {
+				t.Error("stage error does not unwrap to its cause")
+			}
+		})
+	}
+}
+
+func TestArticleDirCategorySubdirs(t *testing.T) {
+	outputDir := t.TempDir()
+	now := time.Now()
+	dateDir := filepath.Join(now.Format("2006"), now.Format("01"))
+	noDate := false
+
+	tests := []struct {
+		name        string
+		categories  []string
+		dateSubdirs *bool
+		expected    string
+	}{
+		{"primary category", []string{"Development/Programming", "AI"}, nil, filepath.Join("development-programming", dateDir)},
+		{"without date", []string{"AI"}, &noDate, "ai"},
+		{"path traversal", []string{"../../etc"}, &noDate, "etc"},
+		{"no slug characters", []string{"../.."}, &noDate, "uncategorized"},
+		{"no categories", nil, &noDate, "uncategorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ArticleProcessor{config: &Config{Settings: &Settings{
+				OutputDirectory: outputDir,
+				CategorySubdirs: true,
+				DateSubdirs:     tt.dateSubdirs,
+			}}}
+			if dir := p.articleDir("https://example.com/post", tt.categories); dir != filepath.Join(outputDir, tt.expected) {
+				t.Errorf("articleDir() = %q, want %q", dir, filepath.Join(outputDir, tt.expected))
+			}
+		})
+	}
+}
+
+func TestArticleDirOutputRules(t *testing.T) {
+	outputDir := t.TempDir()
+	noDate := false
+	p := &ArticleProcessor{config: &Config{Settings: &Settings{
+		OutputDirectory: outputDir,
+		DateSubdirs:     &noDate,
+		OutputRules: []OutputRule{
+			{Domain: "youtube.com", Directory: "video"},
+			{Domain: "example.com", Category: "AI", Directory: "example/ai"},
+			{Category: "Development", Directory: "dev"},
+		},
+	}}}
+
+	tests := []struct {
+		name       string
+		url        string
+		categories []string
+		expected   string
+	}{
+		{"domain", "https://youtube.com/watch?v=1", []string{"Development/Programming"}, "video"},
+		{"subdomain and port", "https://www.YouTube.com:443/watch?v=1", nil, "video"},
+		{"domain and category", "https://example.com/post", []string{"AI"}, filepath.Join("example", "ai")},
+		{"parent category", "https://example.com/post", []string{"Development/Programming"}, "dev"},
+		{"lookalike domain", "https://notyoutube.com/watch", nil, ""},
+		{"no match", "https://example.org/post", []string{"AI"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if dir := p.articleDir(tt.url, tt.categories); dir != filepath.Join(outputDir, tt.expected) {
+				t.Errorf("articleDir() = %q, want %q", dir, filepath.Join(outputDir, tt.expected))
+			}
+		})
+	}
+}
+
+func TestResolveFilenameAdversarial(t *testing.T) {
+	titles := []string{"../../etc/passwd", "..", ".hidden", "a/b\\c", ""}
+	categories := [][]string{nil, {"../../etc"}, {"/absolute"}, {".."}}
+
+	for _, includeHash := range []bool{true, false} {
+		for _, title := range titles {
+			for _, cats := range categories {
+				t.Run(fmt.Sprintf("hash=%v/%q/%q", includeHash, title, cats), func(t *testing.T) {
+					outputDir := t.TempDir()
+					p := &ArticleProcessor{config: &Config{Settings: &Settings{
+						OutputDirectory:       outputDir,
+						IncludeHashInFilename: &includeHash,
+						CategorySubdirs:       true,
+					}}}
+
+					filename, err := p.resolveFilename("https://example.com/post", &Article{Title: title, Categories: cats})
+					if err != nil {
+						t.Fatalf("resolveFilename() error = %v", err)
+					}
+					rel, err := filepath.Rel(outputDir, filename)
+					if err != nil || !filepath.IsLocal(rel) {
+						t.Errorf("filename %q is outside %q", filename, outputDir)
+					}
+					if base := filepath.Base(filename); strings.HasPrefix(base, ".") || strings.HasPrefix(base, "-") {
+						t.Errorf("filename %q has an empty slug", filename)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestCheckInOutputDir(t *testing.T) {
+	p := &ArticleProcessor{config: &Config{Settings: &Settings{OutputDirectory: "articles"}}}
+
+	if err := p.checkInOutputDir(filepath.Join("articles", "2026", "10", "post.md")); err != nil {
+		t.Errorf("checkInOutputDir() error = %v for a file inside the output directory", err)
+	}
+	for _, filename := range []string{"articles/../../etc/passwd", "/etc/passwd", "articles"} {
+		if err := p.checkInOutputDir(filename); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("checkInOutputDir(%q) error = %v, want ErrUnsafePath", filename, err)
+		}
+	}
+}
+
+func TestIsSystemicSaveError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"disk full", &SaveError{Err: fmt.Errorf("creating file: %w", &os.PathError{Op: "write", Path: "a.md", Err: syscall.ENOSPC})}, true},
+		{"permission denied", &SaveError{Err: &os.PathError{Op: "open", Path: "a.md", Err: os.ErrPermission}}, true},
+		{"read-only filesystem", &SaveError{Err: syscall.EROFS}, true},
+		{"slug collision", &SaveError{Err: ErrSlugCollision}, false},
+		{"disk full outside save", &FetchError{Err: syscall.ENOSPC}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isSystemicSaveError(tt.err); result != tt.expected {
+				t.Errorf("isSystemicSaveError() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessURLsAbortOnSaveError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+
+	for _, abort := range []bool{false, true} {
+		t.Run(fmt.Sprintf("abort=%v", abort), func(t *testing.T) {
+			prompter := &routingPrompter{}
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+			p.config.Settings.SaveSource = true
+			p.config.Settings.AbortOnSaveError = abort
+
+			// Writing the first URL's source fails with ENOSPC
+			urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}
+			source := filepath.Join(p.articleDir(urls[0], nil), "parallel-"+p.generateURLHash(urls[0])+".source.md")
+			os.MkdirAll(filepath.Dir(source), 0755)
+			if err := os.Symlink("/dev/full", source); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := p.processURLs(urls)
+			if abort {
+				if !errors.Is(err, ErrRunAborted) || !errors.Is(err, syscall.ENOSPC) {
+					t.Errorf("processURLs() error = %v, want ErrRunAborted wrapping ENOSPC", err)
+				}
+				if len(results) != 1 || prompter.calls != 2 {
+					t.Errorf("processed %d URLs with %d prompts after abort, want 1 and 2", len(results), prompter.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("processURLs() error = %v", err)
+			}
+			if len(results) != 3 {
+				t.Errorf("processed %d URLs, want all 3", len(results))
+			}
+		})
+	}
+}
+
+func TestSaveArticleSEOFields(t *testing.T) {
+	p := &ArticleProcessor{}
+	tempDir := t.TempDir()
+
+	withSEO := &Article{
+		Title:           "Test Title",
+		CreatedAt:       time.Now(),
+		MetaDescription: "A short description for search results",
+		Keywords:        []string{"go", "testing"},
+
+		PlannerPromptVariant: "concise",
+	}
+	withoutSEO := &Article{Title: "Test Title", CreatedAt: time.Now()}
+
+	filename := filepath.Join(tempDir, "seo.md")
+	if err := p.saveArticle(filename, withSEO); err != nil {
+		t.Fatalf("saveArticle() error = %v", err)
+	}
+	content, _ := os.ReadFile(filename)
+	if !strings.Contains(string(content), `meta_description: "A short description for search results"`) {
+		t.Errorf("saved file missing meta_description:\n%s", content)
+	}
+	if !strings.Contains(string(content), `keywords: ["go", "testing"]`) {
+		t.Errorf("saved file missing keywords:\n%s", content)
+	}
+	if !strings.Contains(string(content), `planner_prompt_variant: "concise"`) {
+		t.Errorf("saved file missing planner_prompt_variant:\n%s", content)
+	}
+
+	filename = filepath.Join(tempDir, "plain.md")
+	if err := p.saveArticle(filename, withoutSEO); err != nil {
+		t.Fatalf("saveArticle() error = %v", err)
+	}
+	content, _ = os.ReadFile(filename)
+	if strings.Contains(string(content), "meta_description") || strings.Contains(string(content), "keywords") || strings.Contains(string(content), "planner_prompt_variant") {
+		t.Errorf("saved file should omit empty SEO fields:\n%s", content)
+	}
+}
+
+func TestSaveSource(t *testing.T) {
+	p := &ArticleProcessor{}
+	tempDir := t.TempDir()
+	filename := filepath.Join(tempDir, "title-abcd1234.md")
+
+	if err := p.saveSource(filename, &ContentResult{Text: "# Source"}); err != nil {
+		t.Fatalf("saveSource() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "title-abcd1234.source.md"))
+	if err != nil || string(content) != "# Source" {
+		t.Errorf("source markdown = %q, %v", content, err)
+	}
+
+	pdf := filepath.Join(tempDir, "download.pdf")
+	os.WriteFile(pdf, []byte("%PDF-1.4"), 0644)
+	if err := p.saveSource(filename, &ContentResult{FileID: "file-1", SourceFile: pdf}); err != nil {
+		t.Fatalf("saveSource() error = %v", err)
+	}
+	content, err = os.ReadFile(filepath.Join(tempDir, "title-abcd1234.source.pdf"))
+	if err != nil || string(content) != "%PDF-1.4" {
+		t.Errorf("source PDF = %q, %v", content, err)
+	}
+}
+
+// stubFetcher returns a fixed content result without touching the network
+type stubFetcher struct {
+	result *ContentResult
+	err    error
+}
+
+func (s *stubFetcher) FetchContentContext(ctx context.Context, url string) (*ContentResult, error) {
+	return s.result, s.err
+}
+
+// newPipelineProcessor builds a processor with stubbed fetch and AI responses writing into outputDir
+func newPipelineProcessor(outputDir string, fetcher Fetcher, prompter Prompter) *ArticleProcessor {
+	am := newTestAgentManager(prompter)
+	am.config.Settings.OutputDirectory = outputDir
+	return &ArticleProcessor{
+		agents:  am,
+		fetcher: fetcher,
+		config:  am.config,
+	}
+}
+
+func TestProcessURLPipeline(t *testing.T) {
+	plannerJSON := `{"title": "Stubbed Article", "deck": "A stubbed deck", "categories": ["Development/Programming"], "tags": ["go", "testing"], "target": {"tone": "technical", "audience": "developers"}}`
+	outputDir := filepath.Join(t.TempDir(), "articles")
+	prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(plannerJSON),
+		textResponse("# Stubbed Article\n\nGenerated body."),
+	}}
+	p := newPipelineProcessor(outputDir, &stubFetcher{result: &ContentResult{Text: "Source text", Kind: KindHTML}}, prompter)
+
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+
+	if !strings.HasPrefix(filename, outputDir) || !strings.HasSuffix(filename, "stubbed-article-"+p.generateURLHash("https://example.com/post")+".md") {
+		t.Errorf("ProcessURL() filename = %q", filename)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading saved article: %v", err)
+	}
+	for _, want := range []string{
+		`title: "Stubbed Article"`,
+		`categories: ["Development/Programming"]`,
+		`tags: ["go", "testing"]`,
+		`deck: "A stubbed deck"`,
+		`source_url: "https://example.com/post"`,
+		`source_domain: "example.com"`,
+		`source_type: "html"`,
+		"# Stubbed Article\n\nGenerated body.",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("saved article missing %q:\n%s", want, content)
+		}
+	}
+
+	if !strings.Contains(prompter.calls[0].userPrompt, "Source text") {
+		t.Error("planner did not receive fetched source")
+	}
+
+	// A second run skips the existing article without calling the AI again
+	again, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil || again != filename {
+		t.Errorf("second ProcessURL() = %q, %v, want skip to %q", again, err, filename)
+	}
+	if len(prompter.calls) != 2 {
+		t.Errorf("AI called %d times, want 2", len(prompter.calls))
+	}
+	if strings.Contains(string(content), "mode:") {
+		t.Errorf("article mode recorded in frontmatter:\n%s", content)
+	}
+}
+
+func TestProcessURLSummaryMode(t *testing.T) {
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+	p.config.Settings.Mode = ModeSummary
+
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil || !strings.Contains(string(content), `mode: "summary"`) {
+		t.Errorf("saved summary = %q (%v), want mode: \"summary\" in frontmatter", content, err)
+	}
+}
+
+func TestProcessURLDraftAndApprove(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, ".news-writer", "settings.yaml")
+	outputDir := filepath.Join(dir, "articles")
+	p := newPipelineProcessor(filepath.Join(dir, "drafts"), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+	p.publishedDir = outputDir
+	p.config.Settings.DraftDirectory = filepath.Join(dir, "drafts")
+	p.config.Overrides = &ConfigOverrides{SettingsPath: &settingsPath}
+	p.manifest, _ = loadManifest(p.config.manifestPath())
+
+	draft, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	content, _ := os.ReadFile(draft)
+	if !strings.HasPrefix(draft, p.config.Settings.DraftDirectory) || !strings.Contains(string(content), "draft: true") {
+		t.Fatalf("draft %s = %q, want draft: true under the draft directory", draft, content)
+	}
+
+	config := *p.config
+	config.Settings.OutputDirectory = outputDir
+	published, err := config.ApproveDraft(draft)
+	if err != nil {
+		t.Fatalf("ApproveDraft() error = %v", err)
+	}
+	rel, _ := filepath.Rel(p.config.Settings.DraftDirectory, draft)
+	if published != filepath.Join(outputDir, rel) {
+		t.Errorf("ApproveDraft() = %q, want %q", published, filepath.Join(outputDir, rel))
+	}
+	content, _ = os.ReadFile(published)
+	if !strings.Contains(string(content), "draft: false") || !strings.Contains(string(content), "# Parallel") {
+		t.Errorf("published article = %q, want draft: false and the body", content)
+	}
+	if _, err := os.Stat(draft); !os.IsNotExist(err) {
+		t.Errorf("draft still exists after approval (%v)", err)
+	}
+	m, _ := loadManifest(p.config.manifestPath())
+	if entry := m.URLs[normalizeURL("https://example.com/post")]; entry.Filename != published {
+		t.Errorf("manifest filename = %q, want %q", entry.Filename, published)
+	}
+
+	// Published articles are found in draft mode, so they aren't regenerated
+	if filename, err := p.ProcessURL("https://example.com/post", false); err != nil || filename != published {
+		t.Errorf("ProcessURL() after approval = %q, %v, want the published article %q", filename, err, published)
+	}
+
+	if _, err := config.ApproveDraft(published); !errors.Is(err, ErrNotDraft) {
+		t.Errorf("ApproveDraft(published) error = %v, want ErrNotDraft", err)
+	}
+}
+
+func TestDraftsKeepPublishedSlugs(t *testing.T) {
+	dir := t.TempDir()
+	draftDir, outputDir := filepath.Join(dir, "drafts"), filepath.Join(dir, "articles")
+	p := newPipelineProcessor(draftDir, nil, nil)
+	p.publishedDir = outputDir
+	p.config.Settings.DraftDirectory = draftDir
+
+	published := filepath.Join(outputDir, "2025", "post.md")
+	os.MkdirAll(filepath.Dir(published), 0755)
+	os.WriteFile(published, []byte("---\nsource_url: \"https://example.com/published\"\n---\n\nPublished body\n"), 0644)
+
+	if !p.existingSlugs()["post"] {
+		t.Error("existingSlugs() in draft mode misses the published article's slug")
+	}
+
+	// A draft from another source at the same path doesn't replace the published article
+	draft := filepath.Join(draftDir, "2025", "post.md")
+	os.MkdirAll(filepath.Dir(draft), 0755)
+	os.WriteFile(draft, []byte("---\ndraft: true\nsource_url: \"https://example.com/other\"\n---\n\nDraft body\n"), 0644)
+
+	config := *p.config
+	config.Settings.OutputDirectory = outputDir
+	if _, err := config.ApproveDraft(draft); !errors.Is(err, ErrPublishedConflict) {
+		t.Errorf("ApproveDraft() error = %v, want ErrPublishedConflict", err)
+	}
+	if content, _ := os.ReadFile(published); !strings.Contains(string(content), "Published body") {
+		t.Errorf("published article overwritten:\n%s", content)
+	}
+}
+
+func TestProcessURLFrontmatterOnly(t *testing.T) {
+	outputDir := t.TempDir()
+	fetcher := &stubFetcher{result: &ContentResult{Text: "Source"}}
+	p := newPipelineProcessor(outputDir, fetcher, &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"title": "Old Title", "deck": "Old deck", "categories": ["Dev"], "tags": [], "target": {}}`),
+		textResponse("# Old Title\n\nThe kept body."),
+	}})
+	p.agents.config.Settings.Agents.Writer.Model = "old-writer-model"
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	before, _ := readArticleFile(filename)
+
+	prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"title": "New Title", "deck": "New deck", "categories": ["Dev"], "tags": ["go"], "target": {}}`),
+	}}
+	p = newPipelineProcessor(outputDir, fetcher, prompter)
+	p.frontmatterOnly = true
+	p.agents.config.Settings.Agents.Writer.Model = "new-writer-model"
+
+	refreshed, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil || refreshed != filename {
+		t.Fatalf("ProcessURL() = %q, %v, want %q refreshed", refreshed, err, filename)
+	}
+	if len(prompter.calls) != 1 {
+		t.Errorf("prompter called %d times, want only the planner", len(prompter.calls))
+	}
+	content, _ := os.ReadFile(filename)
+	after, _ := readArticleFile(filename)
+	if !strings.Contains(string(content), `title: "New Title"`) || !strings.Contains(string(content), `tags: ["go"]`) {
+		t.Errorf("frontmatter not refreshed:\n%s", content)
+	}
+	if after.Body != before.Body || !after.Date.Equal(before.Date) || after.WriterModel != "old-writer-model" {
+		t.Errorf("refresh changed body, date or writer model: %+v, want %+v", after, before)
+	}
+
+	if _, err := p.ProcessURL("https://example.com/new", false); !errors.Is(err, ErrNoArticle) {
+		t.Errorf("ProcessURL() without an article error = %v, want ErrNoArticle", err)
+	}
+	if result := p.ProcessURLResult(context.Background(), "https://example.com/new", false); result.Status != StatusSkipped {
+		t.Errorf("ProcessURLResult() without an article status = %q, want skipped", result.Status)
+	}
+}
+
+func TestProcessURLSplitLongSources(t *testing.T) {
+	outputDir := t.TempDir()
+	source := strings.Repeat("A paragraph about the first topic.\n", 20) + strings.Repeat("A paragraph about the second topic.\n", 20) + "The closing paragraph.\n"
+	fetcher := &stubFetcher{result: &ContentResult{Text: source}}
+	prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"segments": [{"topic": "Second", "first_chunk": 21, "last_chunk": 40}, {"topic": "First", "first_chunk": 1, "last_chunk": 20}]}`),
+		// Every segment is planned before the first is written
+		textResponse(`{"title": "First Topic", "deck": "D", "categories": [], "tags": [], "target": {}}`),
+		textResponse(`{"title": "Second Topic", "deck": "D", "categories": [], "tags": [], "target": {}}`),
+		textResponse("# First Topic\n\nBody."),
+		textResponse("# Second Topic\n\nBody."),
+	}}
+	p := newPipelineProcessor(outputDir, fetcher, prompter)
+	p.config.Settings.SplitLongSources = true
+	p.config.Settings.SplitThresholdTokens = 100
+
+	url := "https://example.com/talk"
+	filename, err := p.ProcessURL(url, false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	second := p.findExistingFile(segmentURLKey(url, 2))
+	if filename != p.findExistingFile(segmentURLKey(url, 1)) || second == "" || second == filename {
+		t.Fatalf("segment files = %q, %q, want one article per segment", filename, second)
+	}
+	for i, file := range []string{filename, second} {
+		content, _ := os.ReadFile(file)
+		if !strings.Contains(string(content), `source_url: "`+url+`"`) || !strings.Contains(string(content), fmt.Sprintf("segment: %d", i+1)) {
+			t.Errorf("segment %d frontmatter missing source_url or segment:\n%s", i+1, content)
+		}
+	}
+	if writer := prompter.calls[3].userPrompt; !strings.Contains(writer, "part 1 of 2") || strings.Contains(writer, "closing paragraph") {
+		t.Errorf("first writer prompt should cover only the first segment:\n%s", writer)
+	}
+
+	if _, err := p.ProcessURL(url, false); err != nil || len(prompter.calls) != 5 {
+		t.Errorf("rerun = %v after %d calls, want the split source skipped as existing", err, len(prompter.calls))
+	}
+
+	// Rewriting into a single topic leaves the second segment behind, with a warning
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	prompter.responses = append(prompter.responses,
+		textResponse(`{"segments": [{"topic": "Everything", "first_chunk": 1, "last_chunk": 40}]}`),
+		textResponse(`{"title": "Whole Talk", "deck": "D", "categories": [], "tags": [], "target": {}}`),
+		textResponse("# Whole Talk\n\nBody."),
+	)
+	if _, err := p.ProcessURL(url, true); err != nil {
+		t.Fatalf("rewrite error = %v", err)
+	}
+	if !strings.Contains(logs.String(), "Warning: "+second+" is segment 2") {
+		t.Errorf("rewrite log missing stale segment warning:\n%s", logs.String())
+	}
+}
+
+func TestProcessURLPipelineStageErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fetcher  *stubFetcher
+		prompter *fakePrompter
+		stage    string
+	}{
+		{
+			name:     "fetch failure",
+			fetcher:  &stubFetcher{err: errors.New("unreachable")},
+			prompter: &fakePrompter{},
+			stage:    "fetch",
+		},
+		{
+			name:     "plan failure",
+			fetcher:  &stubFetcher{result: &ContentResult{Text: "Source"}},
+			prompter: &fakePrompter{errs: []error{errors.New("planner down")}},
+			stage:    "plan",
+		},
+		{
+			name:    "write failure",
+			fetcher: &stubFetcher{result: &ContentResult{Text: "Source"}},
+			prompter: &fakePrompter{
+				responses: []*types.AnthropicResponse{textResponse(`{"title": "T", "deck": "D", "categories": [], "tags": [], "target": {}}`)},
+				errs:      []error{nil, errors.New("writer down")},
+			},
+			stage: "write",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPipelineProcessor(t.TempDir(), tt.fetcher, tt.prompter)
+
+			_, err := p.ProcessURL("https://example.com/post", false)
+			if stage := failureStage(err); stage != tt.stage {
+				t.Errorf("ProcessURL() error = %v (stage %q), want stage %q", err, stage, tt.stage)
+			}
+		})
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"https://example.com/post", "HTTPS://Example.COM/post/", true},
+		{"https://example.com/post#section", "https://example.com/post", true},
+		{"https://example.com:443/post", "https://example.com/post", true},
+		{"http://example.com/post", "https://example.com/post", false},
+		{"https://example.com/post?id=1", "https://example.com/post?id=2", false},
+		{"https://example.com/Post", "https://example.com/post", false},
+	}
+
+	for _, tt := range tests {
+		if equal := normalizeURL(tt.a) == normalizeURL(tt.b); equal != tt.equal {
+			t.Errorf("normalizeURL(%q) == normalizeURL(%q) is %v, want %v", tt.a, tt.b, equal, tt.equal)
+		}
+	}
+}
+
+func TestLoadURLsFromDirectoryAndGlob(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"ai.yaml":   "items:\n  - url: \"https://example.com/ai\"\n  - url: \"https://example.com/shared\"",
+		"go.yml":    "items:\n  - url: \"https://example.com/go\"\n  - url: \"https://example.com/shared/\"",
+		"notes.txt": "not a config",
+		"rust.yaml": "items:\n  - url: \"https://example.com/rust\"",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &ArticleProcessor{}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			"directory",
+			tempDir,
+			[]string{"https://example.com/ai", "https://example.com/shared", "https://example.com/go", "https://example.com/rust"},
+		},
+		{
+			"glob",
+			filepath.Join(tempDir, "*.yaml"),
+			[]string{"https://example.com/ai", "https://example.com/shared", "https://example.com/rust"},
+		},
+		{
+			"single file",
+			filepath.Join(tempDir, "go.yml"),
+			[]string{"https://example.com/go", "https://example.com/shared/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.loadURLsFromFile(tt.path)
+			if err != nil {
+				t.Fatalf("loadURLsFromFile() error = %v", err)
+			}
+			if strings.Join(result, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := p.loadURLsFromFile(filepath.Join(tempDir, "*.json")); err == nil {
+		t.Error("expected error for pattern without matches")
+	}
+}
+
+func TestProcessURLOnlyCategories(t *testing.T) {
+	plannerJSON := `{"title": "Off Topic", "deck": "D", "categories": ["Business/Finance"], "tags": [], "target": {}}`
+
+	tests := []struct {
+		name     string
+		only     []string
+		wantSkip bool
+	}{
+		{"no filter", nil, false},
+		{"exact match", []string{"business/finance"}, false},
+		{"parent match", []string{"Business"}, false},
+		{"no match", []string{"AI", "Development/Programming"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+				textResponse(plannerJSON),
+				textResponse("# Off Topic\n\nBody."),
+			}}
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+			p.config.Settings.OnlyCategories = tt.only
+
+			_, err := p.ProcessURL("https://example.com/post", false)
+			if tt.wantSkip {
+				if !errors.Is(err, ErrCategoryMismatch) {
+					t.Fatalf("ProcessURL() error = %v, want ErrCategoryMismatch", err)
+				}
+				if len(prompter.calls) != 1 {
+					t.Errorf("writer called for filtered article: %d prompts", len(prompter.calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessURL() error = %v", err)
+			}
+		})
+	}
+}
+
+// routingPrompter answers planner calls (which carry a schema) and writer calls without ordering, so it is safe for concurrent use
+type routingPrompter struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *routingPrompter) Prompt(ctx context.Context, systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error) {
+	r.mu.Lock()
+	r.calls++
+	r.mu.Unlock()
+
+	if schema != "" {
+		return textResponse(`{"title": "Parallel", "deck": "D", "categories": ["Development/Programming"], "tags": [], "target": {}}`), nil
+	}
+	return textResponse("# Parallel\n\nBody."), nil
+}
+
+func TestNewArticleProcessorOptions(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "out")
+	prompter := &routingPrompter{}
+
+	p, err := NewArticleProcessor("test-key",
+		WithOutputDir(outputDir),
+		WithFetcher(&stubFetcher{result: &ContentResult{Text: "Source"}}),
+		WithPrompter(prompter),
+		WithDryRun(true),
+	)
+	if err != nil {
+		t.Fatalf("NewArticleProcessor() error = %v", err)
+	}
+
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	if !strings.HasPrefix(filename, outputDir) {
+		t.Errorf("filename %q not in output dir %q", filename, outputDir)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote %s", filename)
+	}
+	if prompter.calls != 1 {
+		t.Errorf("got %d prompts, want planner only", prompter.calls)
+	}
+}
+
+func TestProcessURLsConcurrency(t *testing.T) {
+	outputDir := t.TempDir()
+	prompter := &routingPrompter{}
+
+	p, err := NewArticleProcessor("test-key",
+		WithOutputDir(outputDir),
+		WithFetcher(&stubFetcher{result: &ContentResult{Text: "Source"}}),
+		WithPrompter(prompter),
+		WithConcurrency(3),
+	)
+	if err != nil {
+		t.Fatalf("NewArticleProcessor() error = %v", err)
+	}
+
+	var urls []string
+	for i := range 6 {
+		urls = append(urls, fmt.Sprintf("https://example.com/post-%d", i))
+	}
+	if err := p.ProcessURLs(urls); err != nil {
+		t.Fatalf("ProcessURLs() error = %v", err)
+	}
+
+	for _, url := range urls {
+		if p.findExistingFile(url) == "" {
+			t.Errorf("no article saved for %s", url)
+		}
+	}
+	if prompter.calls != 2*len(urls) {
+		t.Errorf("got %d prompts, want %d", prompter.calls, 2*len(urls))
+	}
+}
+
+func TestProcessURLPostProcess(t *testing.T) {
+	t.Run("modifies article", func(t *testing.T) {
+		p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+		p.postProcess = func(article *Article) error {
+			article.Content += "\n\n_Disclaimer: AI-distilled._"
+			return nil
+		}
+
+		filename, err := p.ProcessURL("https://example.com/post", false)
+		if err != nil {
+			t.Fatalf("ProcessURL() error = %v", err)
+		}
+		content, _ := os.ReadFile(filename)
+		if !strings.Contains(string(content), "_Disclaimer: AI-distilled._") {
+			t.Errorf("post-processed content not saved:\n%s", content)
+		}
+	})
+
+	t.Run("rejects article", func(t *testing.T) {
+		outputDir := t.TempDir()
+		p := newPipelineProcessor(outputDir, &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+		rejected := errors.New("banned word")
+		p.postProcess = func(article *Article) error { return rejected }
+
+		_, err := p.ProcessURL("https://example.com/post", false)
+		if !errors.Is(err, rejected) || failureStage(err) != "postprocess" {
+			t.Fatalf("ProcessURL() error = %v, want postprocess stage wrapping hook error", err)
+		}
+		if p.findExistingFile("https://example.com/post") != "" {
+			t.Error("rejected article was saved")
+		}
+	})
+}
+
+func TestProcessURLBannedPatterns(t *testing.T) {
+	plannerJSON := `{"title": "Returns", "deck": "D", "categories": [], "tags": [], "target": {}}`
+	banned := "# Returns\n\nThis fund offers guaranteed returns."
+	clean := "# Returns\n\nThis fund has historically performed well."
+
+	tests := []struct {
+		name      string
+		action    string
+		responses []string
+		wantErr   bool
+		wantText  string
+	}{
+		{"fail", "", []string{plannerJSON, banned}, true, ""},
+		{"revise succeeds", "revise", []string{plannerJSON, banned, clean}, false, "historically performed well"},
+		{"revise still banned", "revise", []string{plannerJSON, banned, banned}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := &fakePrompter{}
+			for _, r := range tt.responses {
+				prompter.responses = append(prompter.responses, textResponse(r))
+			}
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+			p.config.Settings.BannedPatterns = []string{`(?i)guaranteed\s+returns`}
+			p.config.Settings.BannedAction = tt.action
+
+			filename, err := p.ProcessURL("https://example.com/fund", false)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBannedContent) || !strings.Contains(err.Error(), "guaranteed returns") {
+					t.Fatalf("ProcessURL() error = %v, want ErrBannedContent naming the match", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessURL() error = %v", err)
+			}
+			content, _ := os.ReadFile(filename)
+			if !strings.Contains(string(content), tt.wantText) {
+				t.Errorf("saved article missing revision:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		similar bool
+	}{
+		{"React Performance: Essential Optimization Techniques", "React performance - essential optimization techniques", true},
+		{"Go 1.24 Released", "Go 1.24 released!", true},
+		{"React Performance Tips", "Vue Performance Tips", false},
+		{"", "Anything", false},
+	}
+
+	for _, tt := range tests {
+		if similar := titleSimilarity(tt.a, tt.b) >= similarTitleThreshold; similar != tt.similar {
+			t.Errorf("titleSimilarity(%q, %q) similar = %v, want %v", tt.a, tt.b, similar, tt.similar)
+		}
+	}
+}
+
+func TestCheckDuplicateTitle(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"", "Go 1.24 Released"},
+		{"warn", "Go 1.24 Released"},
+		{"disambiguate", "Go 1.24 Released (b.com)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p := &ArticleProcessor{config: &Config{Settings: &Settings{DuplicateTitles: tt.mode}}}
+
+			first := &Article{Title: "Go 1.24 released!", SourceDomain: "a.com"}
+			p.checkDuplicateTitle(context.Background(), first)
+			unsaved := &Article{Title: "Go 1.24 Released", SourceDomain: "b.com"}
+			p.checkDuplicateTitle(context.Background(), unsaved)
+			if unsaved.Title != "Go 1.24 Released" {
+				t.Errorf("title compared against an unsaved article: %q", unsaved.Title)
+			}
+			p.recordTitle(first.Title)
+			second := &Article{Title: "Go 1.24 Released", SourceDomain: "b.com"}
+			p.checkDuplicateTitle(context.Background(), second)
+
+			if first.Title != "Go 1.24 released!" {
+				t.Errorf("first title changed to %q", first.Title)
+			}
+			if second.Title != tt.expected {
+				t.Errorf("second title = %q, want %q", second.Title, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAppendAttribution(t *testing.T) {
+	tmpl := "Source: [{source_domain}]({source_url}), retrieved {date}."
+	article := &Article{
+		SourceURL:    "https://example.com/post",
+		SourceDomain: "example.com",
+		CreatedAt:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	want := "# Title\n\nBody.\n\nSource: [example.com](https://example.com/post), retrieved 2025-03-01.\n"
+
+	once := appendAttribution("# Title\n\nBody.\n", tmpl, article)
+	if once != want {
+		t.Errorf("appendAttribution() = %q, want %q", once, want)
+	}
+
+	// A rewrite on a later date replaces the footer instead of appending a second one
+	article.CreatedAt = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	twice := appendAttribution(once, tmpl, article)
+	if strings.Count(twice, "Source: [") != 1 || !strings.Contains(twice, "retrieved 2025-04-01.") {
+		t.Errorf("appendAttribution() not idempotent: %q", twice)
+	}
+}
+
+func TestProcessURLRefreshNotModified(t *testing.T) {
+	outputDir := t.TempDir()
+	prompter := &routingPrompter{}
+	fetcher := &notModifiedFetcher{}
+	p := newPipelineProcessor(outputDir, fetcher, prompter)
+
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+
+	p.refresh = true
+	calls := prompter.calls
+	refreshed, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("refresh of unchanged source error = %v", err)
+	}
+	if refreshed != filename || prompter.calls != calls || fetcher.conditional != 1 {
+		t.Errorf("unchanged source regenerated: file %q (want %q), %d new prompts, %d conditional fetches", refreshed, filename, prompter.calls-calls, fetcher.conditional)
+	}
+
+	// An explicit rewrite regenerates from a full fetch, e.g. after a prompt change
+	if _, err := p.ProcessURL("https://example.com/post", true); err != nil {
+		t.Fatalf("rewrite error = %v", err)
+	}
+	if prompter.calls == calls || fetcher.conditional != 1 {
+		t.Errorf("rewrite made %d prompts and %d conditional fetches, want a full regeneration", prompter.calls-calls, fetcher.conditional)
+	}
+}
+
+func TestProcessURLRefreshAfterFailedWrite(t *testing.T) {
+	version := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<article><p>Version %d of the story.</p></article>", version)
+	}))
+	defer server.Close()
+
+	settings := &Settings{CacheDirectory: t.TempDir()}
+	prompter := &routingPrompter{}
+	p := newPipelineProcessor(t.TempDir(), NewContentFetcher("", settings), prompter)
+	p.config.Settings.CacheDirectory = settings.CacheDirectory
+	url := server.URL + "/post"
+
+	if _, err := p.ProcessURL(url, false); err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+
+	// The source changes; a dry run and a failed save don't store its validators
+	version = 2
+	p.refresh = true
+	p.dryRun = true
+	if _, err := p.ProcessURL(url, false); err != nil {
+		t.Fatalf("dry run refresh error = %v", err)
+	}
+	p.dryRun = false
+	p.postProcess = func(*Article) error { return errors.New("rejected") }
+	if _, err := p.ProcessURL(url, false); err == nil {
+		t.Fatal("refresh with a failing post-process succeeded")
+	}
+
+	p.postProcess = nil
+	calls := prompter.calls
+	if _, err := p.ProcessURL(url, false); err != nil {
+		t.Fatalf("refresh error = %v", err)
+	}
+	if prompter.calls == calls {
+		t.Error("refresh after the failed write kept the stale article")
+	}
+}
+
+func TestProcessURLRewriteDiff(t *testing.T) {
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	original, _ := os.ReadFile(filename)
+
+	var diff strings.Builder
+	p.diffOut = &diff
+	p.diffOnly = true
+	p.agents.prompter = &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"title": "Parallel", "deck": "D", "categories": ["Development/Programming"], "tags": [], "target": {}}`),
+		textResponse("# Parallel\n\nRewritten body."),
+	}}
+
+	if _, err := p.ProcessURL("https://example.com/post", true); err != nil {
+		t.Fatalf("rewrite error = %v", err)
+	}
+
+	if !strings.Contains(diff.String(), "-Body.") || !strings.Contains(diff.String(), "+Rewritten body.") {
+		t.Errorf("diff missing body change:\n%s", diff.String())
+	}
+	if current, _ := os.ReadFile(filename); string(current) != string(original) {
+		t.Error("diff-only rewrite modified the article")
+	}
+}
+
+func TestResolveFilenameWithoutHash(t *testing.T) {
+	noHash := false
+
+	tests := []struct {
+		strategy string
+		expected string
+		wantErr  error
+	}{
+		{"", "same-title-2.md", nil},
+		{"numeric", "same-title-2.md", nil},
+		{"content-hash", "same-title-" + fmt.Sprintf("%x", sha256.Sum256([]byte("Second body")))[:8] + ".md", nil},
+		{"fail", "", ErrSlugCollision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			outputDir := t.TempDir()
+			p := &ArticleProcessor{config: &Config{Settings: &Settings{
+				OutputDirectory:       outputDir,
+				IncludeHashInFilename: &noHash,
+				SlugCollisionStrategy: tt.strategy,
+			}}}
+
+			// An article from an earlier month already uses the slug
+			old := filepath.Join(outputDir, "2024", "01", "same-title.md")
+			os.MkdirAll(filepath.Dir(old), 0755)
+			os.WriteFile(old, []byte("---\ntitle: \"Same Title\"\nsource_url: \"https://a.com/post\"\n---\n\nFirst body"), 0644)
+
+			filename, err := p.resolveFilename("https://b.com/post", &Article{Title: "Same Title", Content: "Second body"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveFilename() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveFilename() error = %v", err)
+			}
+			if filepath.Base(filename) != tt.expected {
+				t.Errorf("resolveFilename() = %s, want %s", filepath.Base(filename), tt.expected)
+			}
+
+			// Slugs handed out in this run are not reused before they are saved, and
+			// the output tree is walked only once
+			os.Remove(old)
+			next, _ := p.resolveFilename("https://c.com/post", &Article{Title: "Same Title", Content: "Third body"})
+			if next == filename || filepath.Base(next) == "same-title.md" {
+				t.Errorf("resolveFilename() = %s after %s, want neither reused nor the tree walked again", next, filename)
+			}
+			os.WriteFile(old, []byte("---\ntitle: \"Same Title\"\nsource_url: \"https://a.com/post\"\n---\n\nFirst body"), 0644)
+
+			if found := p.findExistingFile("https://a.com/post"); found != old {
+				t.Errorf("findExistingFile() = %q, want %q via source_url", found, old)
+			}
+		})
+	}
+}
+
+func TestRenderArticleExtraFrontmatter(t *testing.T) {
+	p := &ArticleProcessor{}
+	article := &Article{
+		Title:        "Title",
+		CreatedAt:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		SourceDomain: "example.com",
+		Content:      "Body",
+		ExtraFrontmatter: map[string]any{
+			"weight":  10,
+			"authors": []string{"alice", "bob"},
+			"series":  "Go Weekly",
+			"title":   "Overridden",
+			"params":  map[string]any{"z": 1, "a": true},
+		},
+	}
+
+	first, err := p.renderArticle(article)
+	if err != nil {
+		t.Fatalf("renderArticle() error = %v", err)
+	}
+	for range 10 {
+		again, _ := p.renderArticle(article)
+		if string(again) != string(first) {
+			t.Fatalf("renderArticle() output not deterministic:\n%s\n---\n%s", first, again)
+		}
+	}
+
+	want := `source_domain: "example.com"
+authors:
+    - alice
+    - bob
+params:
+    a: true
+    z: 1
+series: Go Weekly
+weight: 10
+---`
+	if !strings.Contains(string(first), want) {
+		t.Errorf("extras not rendered after core fields in sorted order:\n%s", first)
+	}
+	if strings.Contains(string(first), "Overridden") {
+		t.Errorf("extra frontmatter overrode a core field:\n%s", first)
+	}
+}
+
+func TestProcessURLTargetFrontmatter(t *testing.T) {
+	plannerJSON := `{"title": "Targeted", "deck": "D", "categories": [], "tags": [], "target": {"tone": "technical", "audience": "developers"}}`
+
+	for _, include := range []bool{false, true} {
+		t.Run(fmt.Sprint(include), func(t *testing.T) {
+			prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+				textResponse(plannerJSON),
+				textResponse("# Targeted\n\nBody."),
+			}}
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+			p.config.Settings.IncludeTargetInFrontmatter = include
+
+			filename, err := p.ProcessURL("https://example.com/post", false)
+			if err != nil {
+				t.Fatalf("ProcessURL() error = %v", err)
+			}
+			content, _ := os.ReadFile(filename)
+			hasTarget := strings.Contains(string(content), "tone: \"technical\"\naudience: \"developers\"\n")
+			if hasTarget != include {
+				t.Errorf("target in frontmatter = %v, want %v:\n%s", hasTarget, include, content)
+			}
+		})
+	}
+}
+
+func TestProcessURLSourceAndFetchMetadata(t *testing.T) {
+	fetchedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	content := &ContentResult{Text: "Source", FetchedAt: fetchedAt, HTTPStatus: 200, FinalURL: "https://example.com/moved", SourceDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)}
+
+	for _, include := range []bool{false, true} {
+		t.Run(fmt.Sprint(include), func(t *testing.T) {
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: content}, &routingPrompter{})
+			p.config.Settings.IncludeFetchMetadata = include
+			p.config.Settings.IncludeSourceDate = include
+
+			filename, err := p.ProcessURL("https://example.com/post", false)
+			if err != nil {
+				t.Fatalf("ProcessURL() error = %v", err)
+			}
+			saved, _ := os.ReadFile(filename)
+			hasMetadata := strings.Contains(string(saved), "source_date: 2024-12-31T00:00:00Z\nfetched_at: 2025-03-01T12:00:00Z\nhttp_status: 200\nfinal_url: \"https://example.com/moved\"\n")
+			if hasMetadata != include {
+				t.Errorf("fetch metadata in frontmatter = %v, want %v:\n%s", hasMetadata, include, saved)
+			}
+		})
+	}
+}
+
+func TestProcessURLSourceOverride(t *testing.T) {
+	promptPath := filepath.Join(t.TempDir(), "research-writer.md")
+	os.WriteFile(promptPath, []byte("Research writer prompt"), 0644)
+
+	prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"title": "Paper", "deck": "D", "categories": [], "tags": [], "target": {"tone": "breezy", "audience": "all"}}`),
+		textResponse("# Paper\n\nBody."),
+	}}
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+	p.config.Settings.SourceOverrides = map[string]SourceOverride{
+		"research.org": {WriterPromptPath: promptPath, Tone: "academic", Categories: []string{"Science/Papers"}},
+	}
+
+	if _, err := p.ProcessURL("https://blog.research.org/paper", false); err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	if len(prompter.calls) != 2 {
+		t.Fatalf("prompter calls = %d, want 2", len(prompter.calls))
+	}
+	if planner := prompter.calls[0].systemPrompt; !strings.Contains(planner, "- Science/Papers") {
+		t.Errorf("planner prompt missing override categories:\n%s", planner)
+	}
+	writer := prompter.calls[1]
+	if writer.systemPrompt != "Research writer prompt" {
+		t.Errorf("writer system prompt = %q, want override", writer.systemPrompt)
+	}
+	if !strings.Contains(writer.userPrompt, "<Tone>academic</Tone>") {
+		t.Errorf("writer plan missing override tone:\n%s", writer.userPrompt)
+	}
+}
+
+func TestProcessURLJSONL(t *testing.T) {
+	fetcher := &stubFetcher{result: &ContentResult{Text: "Source"}}
+	p := newPipelineProcessor(t.TempDir(), fetcher, &routingPrompter{})
+	var out strings.Builder
+	p.jsonlOut = &out
+
+	p.ProcessURL("https://example.com/post", false) // Written
+	p.ProcessURL("https://example.com/post", false) // Existing article kept
+	fetcher.err = errors.New("connection refused")
+	p.ProcessURL("https://example.com/other", false)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d JSON lines, want 3:\n%s", len(lines), out.String())
+	}
+	var records []jsonlRecord
+	for _, line := range lines {
+		var record jsonlRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if r := records[0]; r.Status != StatusSuccess || r.Article == nil || r.Article.Title != "Parallel" || r.Filename == "" {
+		t.Errorf("first record = %+v, want success with article", r)
+	}
+	if r := records[1]; r.Status != StatusSkipped || r.Article != nil || r.Filename != records[0].Filename {
+		t.Errorf("second record = %+v, want skipped existing file", r)
+	}
+	if r := records[2]; r.Status != StatusError || r.Stage != "fetch" || !strings.Contains(r.Error, "connection refused") {
+		t.Errorf("third record = %+v, want fetch error", r)
+	}
+}
+
+func TestProcessURLPromptVersion(t *testing.T) {
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+
+	filename, err := p.ProcessURL("https://example.com/post", false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	want, err := p.config.PromptVersion("example.com", "", "")
+	if err != nil {
+		t.Fatalf("PromptVersion() error = %v", err)
+	}
+	content, _ := os.ReadFile(filename)
+	if !strings.Contains(string(content), fmt.Sprintf("prompt_version: %q\n", want)) {
+		t.Errorf("frontmatter missing prompt_version %q:\n%s", want, content)
+	}
+}
+
+func TestProcessURLItemFilename(t *testing.T) {
+	tests := []struct {
+		name        string
+		item        string
+		includeHash bool
+		want        string // Relative to the output directory; */* matches year/month
+	}{
+		{"slug with hash", "slug: React Guide", true, "*/*/react-guide-%s.md"},
+		{"slug without hash", "slug: React Guide", false, "*/*/react-guide.md"},
+		{"filename with hash", "filename: guides/react.md", true, "guides/react-%s.md"},
+		{"filename without hash", "filename: guides/react", false, "guides/react.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			configPath := filepath.Join(t.TempDir(), "articles.yaml")
+			url := "https://example.com/post"
+			os.WriteFile(configPath, []byte(fmt.Sprintf("items:\n  - url: %q\n    %s\n", url, tt.item)), 0644)
+
+			p := newPipelineProcessor(outputDir, &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+			p.config.Settings.IncludeHashInFilename = &tt.includeHash
+			if err := p.ProcessURLsFromFile(configPath); err != nil {
+				t.Fatalf("ProcessURLsFromFile() error = %v", err)
+			}
+
+			want := tt.want
+			if tt.includeHash {
+				want = fmt.Sprintf(want, p.generateURLHash(url))
+			}
+			matches, _ := filepath.Glob(filepath.Join(outputDir, want))
+			if len(matches) != 1 {
+				all, _ := filepath.Glob(filepath.Join(outputDir, "*", "*", "*"))
+				t.Errorf("no article matching %s, found %v", want, all)
+			}
+		})
+	}
+}
+
+func TestProcessURLsFromFileItemNotes(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "articles.yaml")
+	os.WriteFile(configPath, []byte("items:\n  - url: \"https://example.com/post\"\n    notes: Focus on the security implications.\n"), 0644)
+
+	prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"title": "T", "deck": "D", "categories": [], "tags": [], "target": {}}`),
+		textResponse("# T\n\nBody."),
+	}}
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+	if err := p.ProcessURLsFromFile(configPath); err != nil {
+		t.Fatalf("ProcessURLsFromFile() error = %v", err)
+	}
+	if len(prompter.calls) != 2 {
+		t.Fatalf("prompter called %d times, want planner and writer", len(prompter.calls))
+	}
+	if writer := prompter.calls[1].userPrompt; !strings.Contains(writer, "<editor_notes>\nFocus on the security implications.\n</editor_notes>") {
+		t.Errorf("item notes missing from the writer prompt:\n%s", writer)
+	}
+}
+
+func TestResolveFilenameItemCollision(t *testing.T) {
+	outputDir := t.TempDir()
+	includeHash := false
+	p := newPipelineProcessor(outputDir, nil, &fakePrompter{})
+	p.config.Settings.IncludeHashInFilename = &includeHash
+	p.items = map[string]ArticleItem{"https://example.com/post": {URL: "https://example.com/post", Filename: "about"}}
+	os.WriteFile(filepath.Join(outputDir, "about.md"), []byte("taken"), 0644)
+
+	if _, err := p.resolveFilename("https://example.com/post", &Article{Title: "About"}); !errors.Is(err, ErrSlugCollision) {
+		t.Errorf("resolveFilename() error = %v, want ErrSlugCollision", err)
+	}
+}
+
+func TestProcessURLSourceLinks(t *testing.T) {
+	links := []string{"https://a.com/1", "https://b.com/2", "https://c.com/3"}
+
+	for _, limit := range []int{0, 2} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			fetcher := &stubFetcher{result: &ContentResult{Text: "Source", Links: links}}
+			p := newPipelineProcessor(t.TempDir(), fetcher, &routingPrompter{})
+			p.config.Settings.MaxSourceLinks = limit
+
+			filename, err := p.ProcessURL("https://example.com/post", false)
+			if err != nil {
+				t.Fatalf("ProcessURL() error = %v", err)
+			}
+			content, _ := os.ReadFile(filename)
+
+			want := "\nsource_links: [\"https://a.com/1\", \"https://b.com/2\"]\n"
+			if limit == 0 {
+				if strings.Contains(string(content), "source_links:") {
+					t.Errorf("source_links written with max_source_links 0:\n%s", content)
+				}
+			} else if !strings.Contains(string(content), want) {
+				t.Errorf("frontmatter missing %q:\n%s", want, content)
+			}
+		})
+	}
+}
+
+func TestProcessURLsFromFileChangedOnly(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "settings.yaml")
+	configPath := filepath.Join(dir, "articles.yaml")
+	writeConfig := func(urls ...string) {
+		var config strings.Builder
+		config.WriteString("items:\n")
+		for _, url := range urls {
+			fmt.Fprintf(&config, "  - url: %q\n", url)
+		}
+		os.WriteFile(configPath, []byte(config.String()), 0644)
+	}
+
+	prompter := &routingPrompter{}
+	p := newPipelineProcessor(filepath.Join(dir, "articles"), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+	p.config.Overrides = &ConfigOverrides{SettingsPath: &settingsPath}
+	p.manifest, _ = loadManifest(p.config.manifestPath())
+	p.changedOnly = true
+	p.deleteRemoved = true
+
+	writeConfig("https://example.com/a", "https://example.com/b")
+	if err := p.ProcessURLsFromFile(configPath); err != nil {
+		t.Fatalf("first run error = %v", err)
+	}
+	if prompter.calls != 4 {
+		t.Fatalf("first run prompter calls = %d, want 4", prompter.calls)
+	}
+	removedFile := p.findExistingFile("https://example.com/a")
+	if removedFile == "" {
+		t.Fatal("first run did not write the article for /a")
+	}
+
+	// Edit the config: /a removed, /b unchanged, /c added
+	writeConfig("https://example.com/b", "https://example.com/c")
+	if err := p.ProcessURLsFromFile(configPath); err != nil {
+		t.Fatalf("second run error = %v", err)
+	}
+	if prompter.calls != 6 {
+		t.Errorf("second run prompter calls = %d, want 2 more for /c only", prompter.calls-4)
+	}
+	if _, err := os.Stat(removedFile); !os.IsNotExist(err) {
+		t.Errorf("article for removed URL still exists: %v", err)
+	}
+
+	m, err := loadManifest(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.URLs) != 2 || m.URLs["https://example.com/a"].URL != "" {
+		t.Errorf("manifest URLs = %v, want /b and /c", m.URLs)
+	}
+}
+
+func TestLoadManifestMigratesProcessedJSON(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, legacyManifestFile)
+	os.WriteFile(legacy, []byte(`{"urls": {"https://example.com/a": {"url": "https://example.com/a", "status": "success", "filename": "a.md"}}}`), 0644)
+
+	path := filepath.Join(dir, manifestFile)
+	m, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	if m.URLs["https://example.com/a"].Filename != "a.md" {
+		t.Errorf("migrated URLs = %v", m.URLs)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("manifest.json not written: %v", err)
+	}
+	if _, err := os.Stat(legacy); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("processed.json still present: %v", err)
+	}
+}
+
+func TestNewArticleProcessorManifestOptIn(t *testing.T) {
+	p, err := NewArticleProcessor("test-key", WithOutputDir(t.TempDir()), WithPrompter(&fakePrompter{}))
+	if err != nil {
+		t.Fatalf("NewArticleProcessor() error = %v", err)
+	}
+	if p.manifest != nil {
+		t.Error("manifest loaded without WithManifest")
+	}
+	if p, _ = NewArticleProcessor("test-key", WithOutputDir(t.TempDir()), WithPrompter(&fakePrompter{}), WithManifest(true)); p.manifest == nil {
+		t.Error("manifest not loaded with WithManifest(true)")
+	}
+}
+
+func TestManifestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".news-writer", manifestFile)
+	m, err := loadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			url := fmt.Sprintf("https://example.com/post-%d", i)
+			if err := m.update(url, fmt.Sprintf("post-%d.md", i), nil); err != nil {
+				t.Errorf("update(%s) error = %v", url, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A later failure keeps the filename from the successful run
+	fetchErr := &FetchError{URL: "https://example.com/post-0", Err: errors.New("timeout")}
+	if err := m.update("https://example.com/post-0", "", fetchErr); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.update("https://example.com/noindex", "", ErrNoindex); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.URLs) != 11 {
+		t.Errorf("manifest has %d URLs, want 11", len(loaded.URLs))
+	}
+	failed := loaded.URLs["https://example.com/post-0"]
+	if failed.Status != StatusError || failed.Filename != "post-0.md" || failed.Error != fetchErr.Error() || failed.ProcessedAt.IsZero() {
+		t.Errorf("failed entry = %+v", failed)
+	}
+	if got := loaded.URLs["https://example.com/noindex"].Status; got != StatusSkipped {
+		t.Errorf("noindex status = %q, want skipped", got)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp")); len(tmp) > 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+}
+
+func TestProcessURLExpandShortURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/s" {
+			http.Redirect(w, r, "/article?id=1", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+	short := server.URL + "/s"
+	resolved := server.URL + "/article?id=1"
+
+	for _, expand := range []bool{false, true} {
+		t.Run(fmt.Sprintf("expand=%v", expand), func(t *testing.T) {
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+			p.config.Settings.ExpandShortURLs = expand
+			p.config.Settings.ShortURLHosts = []string{"127.0.0.1"}
+
+			filename, err := p.ProcessURL(short, false)
+			if err != nil {
+				t.Fatalf("ProcessURL() error = %v", err)
+			}
+			content, _ := os.ReadFile(filename)
+
+			if !expand {
+				if !strings.Contains(string(content), `source_url: "`+short+`"`) || strings.Contains(string(content), "original_url:") {
+					t.Errorf("short URL expanded with expand_short_urls off:\n%s", content)
+				}
+				return
+			}
+			if !strings.HasSuffix(filename, "-"+p.generateURLHash(resolved)+".md") {
+				t.Errorf("filename %q not hashed from the resolved URL", filename)
+			}
+			want := "source_url: \"" + resolved + "\"\noriginal_url: \"" + short + "\"\n"
+			if !strings.Contains(string(content), want) {
+				t.Errorf("frontmatter missing %q:\n%s", want, content)
+			}
+		})
+	}
+}
+
+func TestProcessURLsExpandsBeforeDedupe(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/s" {
+			userAgent = r.UserAgent()
+			http.Redirect(w, r, "/article?id=1", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+	short := server.URL + "/s"
+	resolved := server.URL + "/article?id=1"
+
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+	p.config.Settings.ExpandShortURLs = true
+	p.config.Settings.ShortURLHosts = []string{"127.0.0.1"}
+
+	results, err := p.processURLs([]string{short, resolved})
+	if err != nil {
+		t.Fatalf("processURLs() error = %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("processURLs() processed %d URLs, want the short link and its destination deduplicated", len(results))
+	}
+	if userAgent != fetcherUserAgent {
+		t.Errorf("short URL resolved with User-Agent %q, want %q", userAgent, fetcherUserAgent)
+	}
+	content, _ := os.ReadFile(results[0].Filename)
+	if !strings.Contains(string(content), "original_url: \""+short+"\"") {
+		t.Errorf("frontmatter missing the short URL:\n%s", content)
+	}
+}
+
+func TestIsShortURL(t *testing.T) {
+	p := &ArticleProcessor{config: &Config{Settings: &Settings{ShortURLHosts: []string{"go.example.com"}}}}
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://t.co/abc123", true},
+		{"https://www.bit.ly/abc", true},
+		{"https://BIT.LY/abc", true},
+		{"https://go.example.com/x", true},
+		{"https://example.com/t.co", false},
+		{"https://notbit.ly/abc", false},
+	}
+
+	for _, tt := range tests {
+		if result := p.isShortURL(tt.url); result != tt.expected {
+			t.Errorf("isShortURL(%q) = %v, want %v", tt.url, result, tt.expected)
+		}
+	}
+}
+
+func TestProcessURLsCountsExistingAsSkipped(t *testing.T) {
+	for _, quiet := range []bool{false, true} {
+		t.Run(fmt.Sprintf("quiet=%v", quiet), func(t *testing.T) {
+			prompter := &routingPrompter{}
+			p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+			p.quietSkips = quiet
+			if _, err := p.ProcessURL("https://example.com/a", false); err != nil {
+				t.Fatal(err)
+			}
+
+			var logs strings.Builder
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			results, err := p.processURLs([]string{"https://example.com/a", "https://example.com/b"})
+			if err != nil {
+				t.Fatalf("processURLs() error = %v", err)
+			}
+			statuses := map[string]ProcessingStatus{}
+			for _, result := range results {
+				statuses[result.URL] = result.Status
+			}
+			if statuses["https://example.com/a"] != StatusSkipped || statuses["https://example.com/b"] != StatusSuccess {
+				t.Errorf("statuses = %v, want /a skipped and /b success", statuses)
+			}
+			if !strings.Contains(logs.String(), "1 successful, 0 failed, 1 skipped (1 existing,") {
+				t.Errorf("summary does not count the existing article:\n%s", logs.String())
+			}
+			if strings.Contains(logs.String(), "Skipping existing") == quiet {
+				t.Errorf("quiet=%v, skip log:\n%s", quiet, logs.String())
+			}
+		})
+	}
+}
+
+func TestProcessURLResult(t *testing.T) {
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, &routingPrompter{})
+
+	first := p.ProcessURLResult(context.Background(), "https://example.com/a", false)
+	if first.Status != StatusSuccess || first.Error != nil || first.Filename == "" {
+		t.Fatalf("first ProcessURLResult() = %+v, want success", first)
+	}
+
+	second := p.ProcessURLResult(context.Background(), "https://example.com/a", false)
+	if second.Status != StatusSkipped || !errors.Is(second.Error, ErrAlreadyExists) || second.Filename != first.Filename {
+		t.Errorf("second ProcessURLResult() = %+v, want skipped with ErrAlreadyExists and %s", second, first.Filename)
+	}
+
+	p.fetcher = &stubFetcher{err: ErrNoindex}
+	if noindex := p.ProcessURLResult(context.Background(), "https://example.com/b", false); noindex.Status != StatusSkipped {
+		t.Errorf("noindex ProcessURLResult() status = %q, want skipped", noindex.Status)
+	}
+}
+
+// stagePrompter answers like routingPrompter and records the peak number of
+// simultaneous planner and writer calls, and whether the two ever overlapped
+type stagePrompter struct {
+	mu                      sync.Mutex
+	planning, writing       int
+	peakPlanner, peakWriter int
+	overlapped              bool
+}
+
+func (s *stagePrompter) Prompt(ctx context.Context, systemPrompt, userPrompt, schema, apiKey string, settings types.RequestSettings, files ...types.File) (*types.AnthropicResponse, error) {
+	inFlight, peak := &s.writing, &s.peakWriter
+	if schema != "" {
+		inFlight, peak = &s.planning, &s.peakPlanner
+	}
+	s.mu.Lock()
+	*inFlight++
+	*peak = max(*peak, *inFlight)
+	s.overlapped = s.overlapped || (s.planning > 0 && s.writing > 0)
+	s.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	s.mu.Lock()
+	*inFlight--
+	s.mu.Unlock()
+	return (&routingPrompter{}).Prompt(ctx, systemPrompt, userPrompt, schema, apiKey, settings, files...)
+}
+
+func TestProcessURLsStages(t *testing.T) {
+	prompter := &stagePrompter{}
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+	p.concurrency = 1 // The stages run concurrently regardless
+	p.plannerConcurrency = 3
+	p.writerConcurrency = 1
+
+	var urls []string
+	for i := range 8 {
+		urls = append(urls, fmt.Sprintf("https://example.com/post-%d", i))
+	}
+	if _, err := p.processURLs(urls); err != nil {
+		t.Fatalf("processURLs() error = %v", err)
+	}
+
+	if prompter.peakPlanner > 3 || prompter.peakPlanner < 2 {
+		t.Errorf("peak planner calls = %d, want 2-3", prompter.peakPlanner)
+	}
+	if prompter.peakWriter != 1 {
+		t.Errorf("peak writer calls = %d, want 1", prompter.peakWriter)
+	}
+	if !prompter.overlapped {
+		t.Error("planner never ran while the writer was busy")
+	}
+	for _, url := range urls {
+		if p.findExistingFile(url) == "" {
+			t.Errorf("no article saved for %s", url)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	prompter := &fakePrompter{responses: []*types.AnthropicResponse{
+		textResponse(`{"title": "Report <Title>", "deck": "D", "categories": ["Development/Programming"], "tags": [], "target": {}}`),
+		textResponse("# Report\n\nOne two three four."),
+	}}
+	prompter.responses[0].Usage = types.Usage{InputTokens: 1000, OutputTokens: 100}
+	prompter.responses[1].Usage = types.Usage{InputTokens: 2000, CacheReadInputTokens: 1000, OutputTokens: 900}
+
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+	p.config.Settings.Agents.Planner.Model = "planner-model"
+	p.config.Settings.Agents.Writer.Model = "writer-model"
+	p.config.Settings.Pricing = map[string]ModelPrice{"planner-model": {Input: 1, Output: 10}, "writer-model": {Input: 2, Output: 20}}
+	p.reportPath = filepath.Join(t.TempDir(), "report.html")
+
+	results, err := p.processURLs([]string{"https://example.com/post"})
+	if err != nil {
+		t.Fatalf("processURLs() error = %v", err)
+	}
+
+	result := results[0]
+	if result.Title != "Report <Title>" || result.WordCount != 6 || result.Duration <= 0 {
+		t.Errorf("result = %+v, want title, 6 words and a duration", result)
+	}
+	// planner: 1000*1 + 100*10; writer: 3000*2 + 900*20 (per million)
+	wantUsage := TokenUsage{InputTokens: 4000, OutputTokens: 1000, Cost: 0.026}
+	if result.Usage.InputTokens != wantUsage.InputTokens || result.Usage.OutputTokens != wantUsage.OutputTokens ||
+		math.Abs(result.Usage.Cost-wantUsage.Cost) > 1e-9 {
+		t.Errorf("Usage = %+v, want %+v", result.Usage, wantUsage)
+	}
+
+	report, err := os.ReadFile(p.reportPath)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	for _, want := range []string{"https://example.com/post", "Report &lt;Title&gt;", "Development/Programming", "$0.0260", "1 successful, 0 skipped, 0 failed"} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestRenderArticleDialects(t *testing.T) {
+	article := &Article{
+		Title:           `Say "Hello"`,
+		CreatedAt:       time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Draft:           true,
+		Categories:      []string{"Development/Programming"},
+		Tags:            []string{"go"},
+		Deck:            "The deck",
+		MetaDescription: "The description",
+		SourceURL:       "https://example.com/post",
+		SourceDomain:    "example.com",
+		SourceType:      KindPDF,
+		Content:         "Body",
+		ExtraFrontmatter: map[string]any{
+			"weight":  10,
+			"authors": []any{"alice"},
+			"layout":  "wide",
+		},
+	}
+
+	tests := []struct {
+		dialect string
+		want    []string
+		absent  []string
+	}{
+		{DialectHugoYAML, []string{
+			"---\ntitle: \"Say \\\"Hello\\\"\"\n", "draft: true\n", "description: \"The description\"\n",
+			"categories: [\"Development/Programming\"]\n", "deck: \"The deck\"\n", "source_url: \"https://example.com/post\"\n", "source_type: \"pdf\"\n",
+			"authors:\n    - alice\n", "weight: 10\n---\n\nBody",
+		}, []string{"layout"}},
+		{DialectHugoTOML, []string{
+			"+++\ntitle = \"Say \\\"Hello\\\"\"\n", "date = 2025-03-01T12:00:00Z\n", "draft = true\n",
+			"tags = [\"go\"]\n", "source_url = \"https://example.com/post\"\n", "source_type = \"pdf\"\n", "authors = [\"alice\"]\nweight = 10\n+++\n\nBody",
+		}, []string{"layout", "---"}},
+		{DialectJekyll, []string{
+			"---\nlayout: post\n", "date: 2025-03-01 12:00:00 +0000\n", "published: false\n", "description: \"The description\"\n",
+		}, []string{"draft:", "wide"}},
+		{DialectZola, []string{
+			"+++\ntitle = ", "description = \"The description\"\n\n[taxonomies]\ncategories = [\"Development/Programming\"]\ntags = [\"go\"]\n\n[extra]\n",
+			"source_url = \"https://example.com/post\"\n", "weight = 10\n+++\n\nBody",
+		}, []string{"layout"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			p := newPipelineProcessor(t.TempDir(), nil, nil)
+			p.config.Settings.FrontmatterDialect = tt.dialect
+
+			rendered, err := p.renderArticle(article)
+			if err != nil {
+				t.Fatalf("renderArticle() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(rendered), want) {
+					t.Errorf("output missing %q:\n%s", want, rendered)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(string(rendered), absent) {
+					t.Errorf("output contains %q:\n%s", absent, rendered)
+				}
+			}
+
+			// Articles saved without the URL hash are still found by source_url
+			filename := filepath.Join(p.config.Settings.OutputDirectory, "post.md")
+			if err := p.saveArticle(filename, article); err != nil {
+				t.Fatalf("saveArticle() error = %v", err)
+			}
+			if found := p.findBySourceURL(p.config.Settings.OutputDirectory, article.SourceURL); found != filename {
+				t.Errorf("findBySourceURL() = %q, want %q", found, filename)
+			}
+		})
+	}
+}
+
+func TestFindBySourceURL(t *testing.T) {
+	escaped := `https://example.com/search?q="go"`
+	for _, dialect := range []string{DialectHugoYAML, DialectHugoTOML, DialectJekyll, DialectZola} {
+		t.Run(dialect, func(t *testing.T) {
+			p := newPipelineProcessor(t.TempDir(), nil, nil)
+			p.config.Settings.FrontmatterDialect = dialect
+			outputDir := p.config.Settings.OutputDirectory
+
+			first := filepath.Join(outputDir, "first.md")
+			if err := p.saveArticle(first, &Article{Title: "First", SourceURL: escaped}); err != nil {
+				t.Fatalf("saveArticle() error = %v", err)
+			}
+			if found := p.findBySourceURL(outputDir, escaped); found != first {
+				t.Errorf("findBySourceURL(%q) = %q, want %q", escaped, found, first)
+			}
+
+			// The tree is read once; articles saved since are added to the index
+			second := filepath.Join(outputDir, "2025", "second.md")
+			if err := p.saveArticle(second, &Article{Title: "Second", SourceURL: "https://example.com/second"}); err != nil {
+				t.Fatalf("saveArticle() error = %v", err)
+			}
+			os.WriteFile(filepath.Join(outputDir, "unseen.md"), []byte("---\nsource_url: \"https://example.com/unseen\"\n---\n"), 0644)
+			if found := p.findBySourceURL(outputDir, "https://example.com/second"); found != second {
+				t.Errorf("findBySourceURL() = %q, want %q", found, second)
+			}
+			if found := p.findBySourceURL(outputDir, "https://example.com/unseen"); found != "" {
+				t.Errorf("findBySourceURL() = %q, want the output tree walked only once", found)
+			}
+		})
+	}
+}
+
+// notModifiedFetcher answers conditional fetches with ErrNotModified, like a server
+// returning 304 for an unchanged source
+type notModifiedFetcher struct {
+	conditional int
+}
+
+func (f *notModifiedFetcher) FetchContentContext(ctx context.Context, url string) (*ContentResult, error) {
+	if isConditionalFetch(ctx) {
+		f.conditional++
+		return nil, ErrNotModified
+	}
+	return &ContentResult{Text: "Source"}, nil
+}
+
+func TestProcessURLsForce(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "articles.yaml")
+	urls := []string{"https://example.com/a", "https://example.com/b"}
+	if err := os.WriteFile(configPath, []byte("items:\n  - url: "+urls[0]+"\n  - url: "+urls[1]+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher := &notModifiedFetcher{}
+	prompter := &routingPrompter{}
+	p := newPipelineProcessor(filepath.Join(dir, "out"), fetcher, prompter)
+	if err := p.ProcessURLsFromFile(configPath); err != nil {
+		t.Fatalf("ProcessURLsFromFile() error = %v", err)
+	}
+	for _, url := range urls {
+		os.WriteFile(p.findExistingFile(url), []byte("stale"), 0644)
+	}
+
+	// Without force existing articles are skipped; refresh keeps them when the source is unchanged
+	prompter.calls = 0
+	if err := p.ProcessURLsFromFile(configPath); err != nil {
+		t.Fatalf("ProcessURLsFromFile() error = %v", err)
+	}
+	p.refresh = true
+	if _, err := p.ProcessURL(urls[0], false); err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	if prompter.calls != 0 || fetcher.conditional != 1 {
+		t.Fatalf("got %d prompts and %d conditional fetches, want 0 and 1", prompter.calls, fetcher.conditional)
+	}
+
+	// Force regenerates every article from a full fetch, even with changed-only and refresh set
+	p.force = true
+	p.changedOnly = true
+	if err := p.ProcessURLsFromFile(configPath); err != nil {
+		t.Fatalf("ProcessURLsFromFile() error = %v", err)
+	}
+	if prompter.calls != 2*len(urls) || fetcher.conditional != 1 {
+		t.Errorf("got %d prompts and %d conditional fetches, want %d and 1", prompter.calls, fetcher.conditional, 2*len(urls))
+	}
+	for _, url := range urls {
+		if content, _ := os.ReadFile(p.findExistingFile(url)); string(content) == "stale" {
+			t.Errorf("article for %s was not regenerated", url)
+		}
+	}
+}
+
+func TestProcessURLsReview(t *testing.T) {
+	prompter := &routingPrompter{}
+	p := newPipelineProcessor(t.TempDir(), &stubFetcher{result: &ContentResult{Text: "Source"}}, prompter)
+
+	var reviews int
+	p.review = func(article *Article) (ReviewDecision, error) {
+		reviews++
+		switch reviews {
+		case 1:
+			return ReviewRegenerate, nil
+		case 2:
+			article.Content = "# Parallel\n\nEdited body."
+			return ReviewSave, nil
+		default:
+			return ReviewQuit, nil
+		}
+	}
+
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}
+	results, err := p.processURLs(urls)
+	if err != nil {
+		t.Fatalf("processURLs() error = %v", err)
+	}
+
+	// a is planned once and written twice, b is discarded on quit and c never starts
+	if prompter.calls != 5 || len(results) != 2 {
+		t.Fatalf("got %d prompts and %d results, want 5 and 2", prompter.calls, len(results))
+	}
+	content, err := os.ReadFile(results[0].Filename)
+	if err != nil || !strings.Contains(string(content), "Edited body.") {
+		t.Errorf("saved article = %q (%v), want the edited body", content, err)
+	}
+	if !errors.Is(results[1].Error, ErrReviewQuit) || p.findExistingFile(urls[1]) != "" {
+		t.Errorf("second result = %+v, want ErrReviewQuit and no saved article", results[1])
+	}
+}
+
+func TestNewArticleProcessorAbsolutePaths(t *testing.T) {
+	dir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	settings, err := os.ReadFile(filepath.Join(oldWd, "..", "..", configDirName, "settings.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(dir, configDirName), 0755)
+	os.WriteFile(filepath.Join(dir, configDirName, "settings.yaml"), settings, 0644)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldWd)
+
+	p, err := NewArticleProcessor("test-key",
+		WithOutputDir("articles"),
+		WithFetcher(&stubFetcher{result: &ContentResult{Text: "Source"}}),
+		WithPrompter(&routingPrompter{}),
+		WithAbsolutePaths(true),
+		WithManifest(true),
+	)
+	if err != nil {
+		t.Fatalf("NewArticleProcessor() error = %v", err)
+	}
+
+	url := "https://example.com/post"
+	filename, err := p.ProcessURL(url, false)
+	if err != nil {
+		t.Fatalf("ProcessURL() error = %v", err)
+	}
+	if !filepath.IsAbs(filename) {
+		t.Errorf("ProcessURL() = %q, want an absolute path", filename)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("article not saved at %s: %v", filename, err)
+	}
+
+	// Existing articles are reported absolute too, and so is the manifest entry
+	if existing, _ := p.ProcessURL(url, false); existing != filename {
+		t.Errorf("ProcessURL() for existing article = %q, want %q", existing, filename)
+	}
+	if entry := p.manifest.URLs[normalizeURL(url)]; entry.Filename != filename {
+		t.Errorf("manifest filename = %q, want %q", entry.Filename, filename)
+	}
+}
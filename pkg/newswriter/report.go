@@ -0,0 +1,70 @@
+package newswriter
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+//go:embed defaults/run-report.html
+var runReportTemplate string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join":     strings.Join,
+	"duration": func(d time.Duration) string { return d.Round(100 * time.Millisecond).String() },
+	"cost":     func(usd float64) string { return fmt.Sprintf("$%.4f", usd) },
+}).Parse(runReportTemplate))
+
+// reportData is what the run report template renders
+type reportData struct {
+	Generated                   time.Time
+	Results                     []ProcessingResult
+	Successful, Skipped, Failed int
+	Words                       int
+	Duration                    time.Duration // Sum of per-URL durations, which overlap under concurrency
+	Usage                       TokenUsage
+}
+
+// WriteReport renders an HTML page summarizing results: one row per URL with its
+// status, title, categories, word count, duration, tokens and cost, plus totals
+func WriteReport(w io.Writer, results []ProcessingResult) error {
+	data := reportData{Generated: time.Now(), Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case StatusSuccess:
+			data.Successful++
+		case StatusSkipped:
+			data.Skipped++
+		default:
+			data.Failed++
+		}
+		data.Words += result.WordCount
+		data.Duration += result.Duration
+		data.Usage.InputTokens += result.Usage.InputTokens
+		data.Usage.OutputTokens += result.Usage.OutputTokens
+		data.Usage.Cost += result.Usage.Cost
+	}
+	return reportTemplate.Execute(w, data)
+}
+
+// WriteReport writes the run report for results to the path set with WithReport;
+// it does nothing when no path is set
+func (p *ArticleProcessor) WriteReport(results []ProcessingResult) error {
+	if p.reportPath == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, results); err != nil {
+		return fmt.Errorf("rendering report: %w", err)
+	}
+	if err := os.WriteFile(p.reportPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package newswriter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrReviewQuit is returned for the URL whose article the reviewer quit on; the
+// article is not saved and no further URLs are processed
+var ErrReviewQuit = errors.New("quit during review")
+
+// ReviewDecision is a reviewer's verdict on a generated article
+type ReviewDecision int
+
+const (
+	ReviewSave       ReviewDecision = iota // Save the article, including any edits made to it
+	ReviewRegenerate                       // Discard the article and call the writer again
+	ReviewQuit                             // Discard the article and stop the run
+)
+
+// ReviewFunc is shown each generated article before it is saved. It may edit the
+// article in place (e.g. its Title or Content) before returning ReviewSave.
+type ReviewFunc func(article *Article) (ReviewDecision, error)
+
+// reviewArticle asks p.review about article and reports whether to regenerate it.
+// Without a reviewer every article is saved.
+func (p *ArticleProcessor) reviewArticle(url string, article *Article) (bool, error) {
+	if p.review == nil {
+		return false, nil
+	}
+
+	decision, err := p.review(article)
+	if err != nil {
+		return false, &WriteError{URL: url, Err: fmt.Errorf("review: %w", err)}
+	}
+	switch decision {
+	case ReviewSave:
+		return false, nil
+	case ReviewRegenerate:
+		log.Printf("→ Regenerating %s", url)
+		return true, nil
+	case ReviewQuit:
+		return false, ErrReviewQuit
+	default:
+		return false, &WriteError{URL: url, Err: fmt.Errorf("review: unknown decision %d", decision)}
+	}
+}
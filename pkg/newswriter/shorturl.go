@@ -0,0 +1,127 @@
+package newswriter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultShortURLHosts are link shorteners expanded with Settings.ExpandShortURLs
+var defaultShortURLHosts = []string{
+	"t.co", "bit.ly", "bitly.com", "goo.gl", "tinyurl.com", "ow.ly", "buff.ly", "lnkd.in",
+	"is.gd", "rebrand.ly", "dlvr.it", "fb.me", "amzn.to", "trib.al", "tiny.cc", "shorturl.at",
+}
+
+type originalURLKey struct{}
+
+// withOriginalURL records the short URL the URL being processed was expanded from
+func withOriginalURL(ctx context.Context, original string) context.Context {
+	return context.WithValue(ctx, originalURLKey{}, original)
+}
+
+// originalURLFrom returns the URL set with withOriginalURL, or ""
+func originalURLFrom(ctx context.Context) string {
+	original, _ := ctx.Value(originalURLKey{}).(string)
+	return original
+}
+
+// isShortURL reports whether rawURL is on a known shortener host or one in Settings.ShortURLHosts
+func (p *ArticleProcessor) isShortURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	return slices.Contains(defaultShortURLHosts, host) ||
+		slices.ContainsFunc(p.config.Settings.ShortURLHosts, func(h string) bool { return strings.EqualFold(h, host) })
+}
+
+// shortURLTimeout bounds resolving a single short URL, so a slow shortener can't stall the run
+var shortURLTimeout = 10 * time.Second
+
+// expandURL resolves a short URL to where its redirects end, so the destination is
+// what gets hashed, deduplicated and fetched. It returns rawURL unchanged when
+// expansion is off, the host isn't a shortener, or resolving fails. Results are
+// remembered for the run, so a URL expanded by expandURLs isn't requested again.
+func (p *ArticleProcessor) expandURL(ctx context.Context, rawURL string) string {
+	if !p.config.Settings.ExpandShortURLs || !p.isShortURL(rawURL) {
+		return rawURL
+	}
+
+	p.expansionsMu.Lock()
+	resolved, ok := p.expansions[rawURL]
+	p.expansionsMu.Unlock()
+	if ok {
+		return resolved
+	}
+
+	resolved, err := p.linkFetcher().resolveRedirects(ctx, rawURL)
+	if err != nil {
+		logf(ctx, "Warning: expanding %s: %v", rawURL, err)
+		resolved = rawURL
+	} else if resolved != rawURL {
+		logf(ctx, "→ Expanded %s -> %s", rawURL, resolved)
+	}
+
+	p.expansionsMu.Lock()
+	if p.expansions == nil {
+		p.expansions = make(map[string]string)
+	}
+	p.expansions[rawURL] = resolved
+	p.expansionsMu.Unlock()
+	return resolved
+}
+
+// expandURLs expands the short URLs in urls and drops those that now duplicate an
+// earlier entry. It returns the URLs and, for each expanded one, the short URL it came from.
+func (p *ArticleProcessor) expandURLs(urls []string) ([]string, map[string]string) {
+	if !p.config.Settings.ExpandShortURLs {
+		return urls, nil
+	}
+
+	originals := make(map[string]string)
+	expanded := make([]string, 0, len(urls))
+	for _, url := range urls {
+		resolved := p.expandURL(context.Background(), url)
+		if resolved != url {
+			if _, ok := originals[resolved]; !ok {
+				originals[resolved] = url
+			}
+		}
+		expanded = append(expanded, resolved)
+	}
+	if len(originals) == 0 {
+		return urls, nil
+	}
+	return dedupeURLs(expanded), originals
+}
+
+// resolveRedirects follows rawURL's redirects with HEAD requests and returns the final URL
+func (f *ContentFetcher) resolveRedirects(ctx context.Context, rawURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, shortURLTimeout)
+	defer cancel()
+
+	req, err := f.newRequest(ctx, http.MethodHead, rawURL)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	// Some destinations reject HEAD; the redirects were still followed to reach them
+	final := resp.Request.URL
+	if resp.StatusCode >= 400 && final.String() == rawURL {
+		return "", &HTTPError{StatusCode: resp.StatusCode, URL: rawURL}
+	}
+	if final.Scheme != "http" && final.Scheme != "https" {
+		return "", fmt.Errorf("redirected to unsupported URL %s", final)
+	}
+	return final.String(), nil
+}
@@ -0,0 +1,349 @@
+package newswriter
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+// Split mode defaults when the settings are zero
+const (
+	defaultSplitThresholdTokens = 20000
+	defaultSplitMaxSegments     = 5
+)
+
+// splitChunks is the number of numbered chunks the planner sees when segmenting a
+// source; segments are ranges of them
+const splitChunks = 40
+
+// segmenterSchema constrains the planner's segmentation response
+const segmenterSchema = `{
+  "name": "segmenter_output",
+  "description": "Topics of a long source as ranges of its numbered chunks",
+  "strict": true,
+  "schema": {
+    "type": "object",
+    "properties": {
+      "segments": {
+        "type": "array",
+        "items": {
+          "type": "object",
+          "properties": {
+            "topic": {"type": "string"},
+            "first_chunk": {"type": "integer"},
+            "last_chunk": {"type": "integer"}
+          },
+          "required": ["topic", "first_chunk", "last_chunk"],
+          "additionalProperties": false
+        }
+      }
+    },
+    "required": ["segments"],
+    "additionalProperties": false
+  }
+}`
+
+const segmenterSystemPrompt = `You divide long sources into topics that each deserve their own news article.
+The source is given as numbered chunks, each shortened to its opening text.
+Return contiguous, non-overlapping ranges of chunks in order, one per topic, covering every chunk.
+Return at most %d topics; return a single topic when the source doesn't cover several distinct ones.`
+
+// Segment is one topic of a source split with Settings.SplitLongSources
+type Segment struct {
+	Topic      string `json:"topic"`
+	FirstChunk int    `json:"first_chunk"` // 1-based, inclusive
+	LastChunk  int    `json:"last_chunk"`  // 1-based, inclusive
+}
+
+// sourceSegment is the content of one segment, numbered from 1
+type sourceSegment struct {
+	Index   int
+	Topic   string
+	Content *ContentResult
+}
+
+type segmentKey struct{}
+
+// withSegment records that the article generated with ctx is segment index of a split source
+func withSegment(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, segmentKey{}, index)
+}
+
+// segmentFrom returns the segment number set with withSegment; 0 for whole sources
+func segmentFrom(ctx context.Context) int {
+	index, _ := ctx.Value(segmentKey{}).(int)
+	return index
+}
+
+// segmentURLKey is the key hashed into the filename of segment index of url, so each
+// segment gets its own article while sharing the source_url
+func segmentURLKey(url string, index int) string {
+	return fmt.Sprintf("%s#segment-%d", url, index)
+}
+
+// chunkText splits text into at most n chunks of roughly equal length, extended to the
+// next line break where one is close
+func chunkText(text string, n int) []string {
+	size := len(text)/n + 1
+	var chunks []string
+	for len(text) > 0 {
+		if len(text) <= size {
+			chunks = append(chunks, text)
+			break
+		}
+		end := runeStart(text, size)
+		if i := strings.IndexByte(text[end:], '\n'); i >= 0 && i < size/2 {
+			end += i + 1
+		}
+		chunks = append(chunks, text[:end])
+		text = text[end:]
+	}
+	return chunks
+}
+
+// SegmentContent asks the planner to divide chunks of a long source into topics. The
+// returned segments are contiguous, in order and cover every chunk.
+func (am *AgentManager) SegmentContent(ctx context.Context, url string, chunks []string, maxSegments int) ([]Segment, error) {
+	logf(ctx, "→ Segmenting %s", url)
+	planner := am.config.Settings.Agents.Planner
+
+	// Each chunk gets an equal share of the planner's content budget
+	previewChars := planner.ContentMaxTokens * 4 / len(chunks)
+	var outline strings.Builder
+	for i, chunk := range chunks {
+		preview := strings.TrimSpace(chunk)
+		if len(preview) > previewChars {
+			preview = preview[:runeStart(preview, previewChars)] + "..."
+		}
+		fmt.Fprintf(&outline, "[%d]\n%s\n\n", i+1, preview)
+	}
+
+	systemPrompt := fmt.Sprintf(segmenterSystemPrompt, maxSegments)
+	userPrompt := fmt.Sprintf("Source with %d chunks:\n\n%s", len(chunks), outline.String())
+	schema := segmenterSchema
+	schemaLess := planner.StructuredOutput != nil && !*planner.StructuredOutput
+	if schemaLess {
+		systemPrompt += "\n\nRespond with only a JSON object that conforms to this JSON schema:\n\n```json\n" + schema + "\n```"
+		schema = ""
+	}
+
+	settings := types.RequestSettings{
+		Model:       planner.Model,
+		MaxTokens:   planner.MaxTokens,
+		Temperature: planner.Temperature,
+	}
+	response, err := am.prompt(ctx, systemPrompt, userPrompt, schema, settings)
+	if err != nil {
+		return nil, fmt.Errorf("planner agent failed: %w", err)
+	}
+	logResponse(ctx, "Planner (segments)", response)
+	if len(response.Content) == 0 {
+		return nil, fmt.Errorf("no content in planner response")
+	}
+
+	var result struct {
+		Segments []Segment `json:"segments"`
+	}
+	if schemaLess {
+		err = extractJSONObject(response.Content[0].Text, &result)
+	} else {
+		err = json.Unmarshal([]byte(response.Content[0].Text), &result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse planner segments: %w", err)
+	}
+
+	segments := normalizeSegments(result.Segments, len(chunks), maxSegments)
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("planner returned no segments")
+	}
+	logf(ctx, "✓ Segmented into %d topics", len(segments))
+	return segments, nil
+}
+
+// normalizeSegments orders segments and makes them contiguous over chunks 1..n, keeping
+// each segment's start: gaps and overlaps are resolved by ending a segment where the
+// next begins. Segments past maxSegments are folded into the last one kept.
+func normalizeSegments(segments []Segment, n, maxSegments int) []Segment {
+	var valid []Segment
+	for _, segment := range segments {
+		if segment.FirstChunk >= 1 && segment.FirstChunk <= n {
+			valid = append(valid, segment)
+		}
+	}
+	sort.SliceStable(valid, func(i, j int) bool { return valid[i].FirstChunk < valid[j].FirstChunk })
+
+	var normalized []Segment
+	for _, segment := range valid {
+		if len(normalized) > 0 && normalized[len(normalized)-1].FirstChunk == segment.FirstChunk {
+			continue
+		}
+		normalized = append(normalized, segment)
+	}
+	if len(normalized) > maxSegments {
+		normalized = normalized[:maxSegments]
+	}
+	for i := range normalized {
+		if i == 0 {
+			normalized[i].FirstChunk = 1
+		}
+		if i+1 < len(normalized) {
+			normalized[i].LastChunk = normalized[i+1].FirstChunk - 1
+		} else {
+			normalized[i].LastChunk = n
+		}
+	}
+	return normalized
+}
+
+// splitSource divides content into topics with Settings.SplitLongSources when its
+// text is longer than Settings.SplitThresholdTokens. It returns nil when the source
+// is written as one article.
+func (p *ArticleProcessor) splitSource(ctx context.Context, url string, content *ContentResult) ([]sourceSegment, error) {
+	settings := p.config.Settings
+	if !settings.SplitLongSources || content.Text == "" {
+		return nil, nil
+	}
+	threshold := settings.SplitThresholdTokens
+	if threshold == 0 {
+		threshold = defaultSplitThresholdTokens
+	}
+	if len(content.Text)/4 <= threshold { // Rough approximation: 4 chars ≈ 1 token
+		return nil, nil
+	}
+	maxSegments := settings.SplitMaxSegments
+	if maxSegments == 0 {
+		maxSegments = defaultSplitMaxSegments
+	}
+
+	chunks := chunkText(content.Text, splitChunks)
+	segments, err := p.agents.SegmentContent(ctx, url, chunks, maxSegments)
+	if err != nil || len(segments) < 2 {
+		return nil, err
+	}
+
+	result := make([]sourceSegment, len(segments))
+	for i, segment := range segments {
+		segmentContent := *content
+		segmentContent.Text = strings.Join(chunks[segment.FirstChunk-1:segment.LastChunk], "")
+		result[i] = sourceSegment{Index: i + 1, Topic: segment.Topic, Content: &segmentContent}
+	}
+	return result, nil
+}
+
+// planSegments plans one article per segment, each under its own segment key, and
+// returns the step that writes them. Segments that already have an article are
+// skipped unless rewriting. The write step returns the first article generated,
+// with its filename.
+func (p *ArticleProcessor) planSegments(ctx context.Context, url string, segments []sourceSegment, rewrite bool) (string, *Article, writeStep, error) {
+	logf(ctx, "→ Splitting %s into %d articles", url, len(segments))
+	notes := writerNotesFrom(ctx)
+
+	var firstFilename string // Of a dry run, which plans without writing
+	var writes []writeStep
+	var skipErr error
+	for _, segment := range segments {
+		key := segmentURLKey(url, segment.Index)
+		existingFile := p.findExistingFile(key)
+		if existingFile != "" && !rewrite && !p.force && !p.refresh {
+			p.logSkip(ctx, "→ Skipping existing segment: %s", existingFile)
+			continue
+		}
+
+		segmentNotes := fmt.Sprintf("This article is part %d of %d written from the source, covering: %s. Write about this part only.",
+			segment.Index, len(segments), segment.Topic)
+		if strings.TrimSpace(notes) != "" {
+			segmentNotes = notes + "\n\n" + segmentNotes
+		}
+		segmentCtx := withSegment(withWriterNotes(ctx, segmentNotes), segment.Index)
+
+		filename, write, err := p.planArticle(segmentCtx, url, key, existingFile, segment.Content)
+		if err != nil {
+			if outcomeStatus(err) != StatusSkipped {
+				return filename, nil, nil, err
+			}
+			p.logSkip(ctx, "→ Skipping segment %d: %v", segment.Index, err)
+			skipErr = err
+			continue
+		}
+		if write == nil {
+			firstFilename = cmp.Or(firstFilename, filename)
+			continue
+		}
+		writes = append(writes, write)
+	}
+
+	if len(writes) == 0 {
+		p.warnStaleSegments(ctx, url, len(segments)+1)
+		if skipErr != nil {
+			return "", nil, nil, skipErr
+		}
+		return firstFilename, nil, nil, nil
+	}
+	return "", nil, func() (string, *Article, error) {
+		var firstArticle *Article
+		for _, write := range writes {
+			filename, article, err := write()
+			if err != nil {
+				return filename, article, err
+			}
+			if firstArticle == nil {
+				firstFilename, firstArticle = filename, article
+			}
+		}
+		p.warnStaleSegments(ctx, url, len(segments)+1)
+		return firstFilename, firstArticle, nil
+	}, nil
+}
+
+// warnStaleSegments warns about the saved articles of url's segments from index on,
+// left over from an earlier split into more topics. They aren't removed, since they
+// may have been edited or published.
+func (p *ArticleProcessor) warnStaleSegments(ctx context.Context, url string, index int) {
+	for ; ; index++ {
+		existingFile := p.findExistingFile(segmentURLKey(url, index))
+		if existingFile == "" {
+			return
+		}
+		logf(ctx, "Warning: %s is segment %d of an earlier split of %s; remove it if it is no longer wanted", existingFile, index, url)
+	}
+}
+
+// refreshSegments re-plans the frontmatter of each saved segment of url in
+// frontmatter-only mode. Segments are planned from their saved article body, since
+// segmenting the source again may not reproduce the same topics.
+func (p *ArticleProcessor) refreshSegments(ctx context.Context, url string, content *ContentResult) (string, *Article, error) {
+	var firstFilename string
+	var firstArticle *Article
+	var files []string
+	for i := 1; ; i++ {
+		existingFile := p.findExistingFile(segmentURLKey(url, i))
+		if existingFile == "" {
+			break
+		}
+		files = append(files, existingFile)
+	}
+	for i, existingFile := range files {
+		existing, err := readArticleFile(existingFile)
+		if err != nil {
+			return "", nil, &PlanError{URL: url, Err: err}
+		}
+		segmentContent := *content
+		segmentContent.Text = existing.Body
+		segmentCtx := withSegment(ctx, i+1)
+
+		filename, article, err := p.planAndWrite(segmentCtx, url, segmentURLKey(url, i+1), existingFile, &segmentContent)
+		if err != nil {
+			return filename, article, err
+		}
+		if firstArticle == nil {
+			firstFilename, firstArticle = filename, article
+		}
+	}
+	return firstFilename, firstArticle, nil
+}
@@ -0,0 +1,61 @@
+package newswriter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Segment
+		max      int
+		want     []Segment
+	}{
+		{
+			name:     "gaps and overlaps",
+			segments: []Segment{{"B", 4, 6}, {"A", 2, 5}, {"C", 8, 9}},
+			max:      5,
+			want:     []Segment{{"A", 1, 3}, {"B", 4, 7}, {"C", 8, 10}},
+		},
+		{
+			name:     "out of range and duplicate starts",
+			segments: []Segment{{"A", 1, 5}, {"A again", 1, 3}, {"Z", 11, 12}, {"B", 6, 10}},
+			max:      5,
+			want:     []Segment{{"A", 1, 5}, {"B", 6, 10}},
+		},
+		{
+			name:     "too many segments",
+			segments: []Segment{{"A", 1, 3}, {"B", 4, 6}, {"C", 7, 10}},
+			max:      2,
+			want:     []Segment{{"A", 1, 3}, {"B", 4, 10}},
+		},
+		{
+			name:     "none",
+			segments: []Segment{{"Z", 0, 0}},
+			max:      5,
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSegments(tt.segments, 10, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	text := strings.Repeat("line of text\n", 30)
+	chunks := chunkText(text, 4)
+	if len(chunks) > 4 || strings.Join(chunks, "") != text {
+		t.Fatalf("chunkText() = %d chunks, want at most 4 that join to the text", len(chunks))
+	}
+	for _, chunk := range chunks[:len(chunks)-1] {
+		if !strings.HasSuffix(chunk, "\n") {
+			t.Errorf("chunk %q doesn't end at a line break", chunk)
+		}
+	}
+}
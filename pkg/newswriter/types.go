@@ -0,0 +1,173 @@
+package newswriter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// Article represents the article output with full frontmatter
+type Article struct {
+	Title        string    `json:"title"`
+	SourceURL    string    `json:"source_url"`
+	SourceDomain string    `json:"source_domain"`
+	Content      string    `json:"content"`
+	CreatedAt    time.Time `json:"created_at"`
+	Draft        bool      `json:"draft"`
+	Categories   []string  `json:"categories"`
+	Tags         []string  `json:"tags"`
+	PlannerModel string    `json:"planner_model"`
+	WriterModel  string    `json:"writer_model"`
+	Deck         string    `json:"deck"`
+
+	PlannerPromptVariant string `json:"planner_prompt_variant,omitempty"`
+	PromptVersion        string `json:"prompt_version,omitempty"` // Short hash of the effective planner and writer system prompts
+	Tone                 string `json:"tone,omitempty"`           // Set when Settings.IncludeTargetInFrontmatter is on
+	Audience             string `json:"audience,omitempty"`       // Set when Settings.IncludeTargetInFrontmatter is on
+
+	// ExtraFrontmatter is rendered after the core fields with keys sorted alphabetically
+	ExtraFrontmatter map[string]any `json:"extra_frontmatter,omitempty"`
+
+	MetaDescription string   `json:"meta_description,omitempty"`
+	Keywords        []string `json:"keywords,omitempty"`
+
+	SourceLinks []string `json:"source_links,omitempty"` // Outbound links from the source, up to Settings.MaxSourceLinks
+	OriginalURL string   `json:"original_url,omitempty"` // Short URL that SourceURL was expanded from
+	Mode        string   `json:"mode,omitempty"`         // "summary" in summary mode, empty for full articles
+
+	SourceType ContentKind `json:"source_type,omitempty"` // Kind of source the article was written from, when known
+	Segment    int         `json:"segment,omitempty"`     // Topic number within a source split with Settings.SplitLongSources
+
+	SourceDate time.Time `json:"source_date,omitzero"` // When the source was published; set when Settings.IncludeSourceDate is on
+
+	// Set when Settings.IncludeFetchMetadata is on
+	FetchedAt  time.Time `json:"fetched_at,omitzero"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	FinalURL   string    `json:"final_url,omitempty"`
+
+	BrokenLinks []BrokenLink `json:"broken_links,omitempty"` // Found with --check-links; not rendered in frontmatter
+}
+
+// ProcessingStatus represents the outcome status of processing an article
+type ProcessingStatus string
+
+const (
+	StatusSuccess ProcessingStatus = "success"
+	StatusSkipped ProcessingStatus = "skipped"
+	StatusError   ProcessingStatus = "error"
+)
+
+// ProcessingResult tracks the outcome of processing each URL. Title, Categories and
+// WordCount are only set when an article was generated.
+type ProcessingResult struct {
+	URL      string
+	Status   ProcessingStatus
+	Filename string
+	Error    error
+
+	Title      string
+	Categories []string
+	WordCount  int
+	Duration   time.Duration
+	Usage      TokenUsage
+
+	BrokenLinks []BrokenLink // Found with --check-links
+}
+
+// FetchError reports a failure fetching the source content of a URL
+type FetchError struct {
+	URL string
+	Err error
+}
+
+func (e *FetchError) Error() string { return fmt.Sprintf("fetching content: %v", e.Err) }
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// PlanError reports a failure generating metadata with the planner agent
+type PlanError struct {
+	URL string
+	Err error
+}
+
+func (e *PlanError) Error() string { return fmt.Sprintf("generating metadata: %v", e.Err) }
+func (e *PlanError) Unwrap() error { return e.Err }
+
+// WriteError reports a failure generating the article with the writer agent
+type WriteError struct {
+	URL string
+	Err error
+}
+
+func (e *WriteError) Error() string { return fmt.Sprintf("generating article: %v", e.Err) }
+func (e *WriteError) Unwrap() error { return e.Err }
+
+// PostProcessError reports an article rejected or failed by the post-process hook
+type PostProcessError struct {
+	URL string
+	Err error
+}
+
+func (e *PostProcessError) Error() string { return fmt.Sprintf("post-processing article: %v", e.Err) }
+func (e *PostProcessError) Unwrap() error { return e.Err }
+
+// SaveError reports a failure saving the generated article to disk
+type SaveError struct {
+	URL      string
+	Filename string
+	Err      error
+}
+
+func (e *SaveError) Error() string { return fmt.Sprintf("saving article: %v", e.Err) }
+func (e *SaveError) Unwrap() error { return e.Err }
+
+// isSystemicSaveError reports whether err is a save failure that will recur for every
+// later article too: disk full, quota exceeded, read-only filesystem or permission denied
+func isSystemicSaveError(err error) bool {
+	var saveErr *SaveError
+	if !errors.As(err, &saveErr) {
+		return false
+	}
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.ENOSPC) ||
+		errors.Is(err, syscall.EDQUOT) || errors.Is(err, syscall.EROFS)
+}
+
+// outcomeStatus classifies the error from processing a URL: sources skipped on purpose
+// (existing article, no transcript or captions, too short transcript, noindex, no content, consent wall, off-topic, no article to refresh) are StatusSkipped
+func outcomeStatus(err error) ProcessingStatus {
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case errors.Is(err, ErrAlreadyExists), errors.Is(err, ErrTranscriptsDisabled), errors.Is(err, ErrNoCaptions),
+		errors.Is(err, ErrTranscriptTooShort), errors.Is(err, ErrNoindex), errors.Is(err, ErrNoContent), errors.Is(err, ErrConsentWall),
+		errors.Is(err, ErrCategoryMismatch), errors.Is(err, ErrNoArticle):
+		return StatusSkipped
+	default:
+		return StatusError
+	}
+}
+
+// failureStage returns the pipeline stage an error originated from
+func failureStage(err error) string {
+	var fetchErr *FetchError
+	var planErr *PlanError
+	var writeErr *WriteError
+	var postProcessErr *PostProcessError
+	var saveErr *SaveError
+
+	switch {
+	case errors.As(err, &fetchErr):
+		return "fetch"
+	case errors.As(err, &planErr):
+		return "plan"
+	case errors.As(err, &writeErr):
+		return "write"
+	case errors.As(err, &postProcessErr):
+		return "postprocess"
+	case errors.As(err, &saveErr):
+		return "save"
+	default:
+		return "other"
+	}
+}
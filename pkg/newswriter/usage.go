@@ -0,0 +1,60 @@
+package newswriter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aktagon/llmkit/anthropic/types"
+)
+
+// ModelPrice is what a model costs in USD per million tokens, used to estimate run cost
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`  // Per million input tokens, including cache reads and writes
+	Output float64 `yaml:"output"` // Per million output tokens
+}
+
+// TokenUsage totals the tokens of the API calls made for one URL and their estimated
+// cost; Cost is zero when Settings.Pricing has no price for the models used
+type TokenUsage struct {
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// usageMeter accumulates the token usage of the prompts made for one URL
+type usageMeter struct {
+	mu    sync.Mutex
+	usage TokenUsage
+}
+
+type usageMeterKey struct{}
+
+func withUsageMeter(ctx context.Context, m *usageMeter) context.Context {
+	return context.WithValue(ctx, usageMeterKey{}, m)
+}
+
+// usageMeterFrom returns the meter attached to ctx, or nil
+func usageMeterFrom(ctx context.Context) *usageMeter {
+	m, _ := ctx.Value(usageMeterKey{}).(*usageMeter)
+	return m
+}
+
+// add records the usage of one response, priced with price when it is known
+func (m *usageMeter) add(usage types.Usage, price *ModelPrice) {
+	input := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.InputTokens += input
+	m.usage.OutputTokens += usage.OutputTokens
+	if price != nil {
+		m.usage.Cost += (float64(input)*price.Input + float64(usage.OutputTokens)*price.Output) / 1e6
+	}
+}
+
+// total returns the usage recorded so far
+func (m *usageMeter) total() TokenUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
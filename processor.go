@@ -1,336 +0,0 @@
-package main
-
-import (
-	"crypto/sha256"
-	"fmt"
-	"log"
-	"net/url"
-	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
-	"text/template"
-	"time"
-
-	"gopkg.in/yaml.v3"
-)
-
-// ArticleProcessor handles the main workflow
-type ArticleProcessor struct {
-	agents  *AgentManager
-	fetcher *ContentFetcher
-	config  *Config
-	apiKey  string
-}
-
-// NewArticleProcessor creates a new processor with agent manager and config
-func NewArticleProcessor(apiKey string, overrides *ConfigOverrides) (*ArticleProcessor, error) {
-	config, err := NewConfig(overrides)
-	if err != nil {
-		return nil, fmt.Errorf("creating config: %w", err)
-	}
-
-	agents, err := NewAgentManager(apiKey, config)
-	if err != nil {
-		return nil, fmt.Errorf("creating agent manager: %w", err)
-	}
-
-	fetcher := NewContentFetcher(apiKey)
-
-	return &ArticleProcessor{
-		agents:  agents,
-		fetcher: fetcher,
-		config:  config,
-		apiKey:  apiKey,
-	}, nil
-}
-
-// ProcessURLsFromFile processes all URLs from a config file
-func (p *ArticleProcessor) ProcessURLsFromFile(configPath string) error {
-	urls, err := p.loadURLsFromFile(configPath)
-	if err != nil {
-		return fmt.Errorf("loading URLs: %w", err)
-	}
-
-	log.Printf("Processing %d URLs from %s", len(urls), configPath)
-
-	successful := 0
-	failed := 0
-	skipped := 0
-
-	for _, url := range urls {
-		filename, err := p.ProcessURL(url, false)
-		if err != nil {
-			log.Printf("✗ Failed: %s - %v", url, err)
-			failed++
-		} else {
-			log.Printf("✓ %s -> %s", url, filename)
-			successful++
-		}
-	}
-
-	log.Printf("Complete: %d successful, %d failed, %d skipped", successful, failed, skipped)
-	return nil
-}
-
-// ProcessURL processes a single URL
-func (p *ArticleProcessor) ProcessURL(url string, rewrite bool) (string, error) {
-	// Check if article already exists
-	existingFile := p.findExistingFile(url)
-	if existingFile != "" && !rewrite {
-		log.Printf("→ Skipping existing: %s", existingFile)
-		return existingFile, nil
-	}
-
-	// Fetch content
-	content, err := p.fetcher.FetchContent(url)
-	if err != nil {
-		return "", fmt.Errorf("fetching content: %w", err)
-	}
-
-	// Generate metadata using planner agent
-	metadata, err := p.agents.PlanMetadata(url, content)
-	if err != nil {
-		return "", fmt.Errorf("generating metadata: %w", err)
-	}
-
-	// Generate article with single AI call
-	article, err := p.generateArticle(url, content, metadata)
-	if err != nil {
-		return "", fmt.Errorf("generating article: %w", err)
-	}
-
-	// Generate filename
-	filename := existingFile
-	if filename == "" {
-		filename = p.generateFilename(url, article.Title)
-	}
-
-	// Save article
-	err = p.saveArticle(filename, article)
-	if err != nil {
-		return "", fmt.Errorf("saving article: %w", err)
-	}
-
-	log.Printf("✓ Saved: %s", filename)
-	return filename, nil
-}
-
-// ArticleItem represents a single article URL in the configuration
-type ArticleItem struct {
-	URL string `yaml:"url"`
-}
-
-// URLConfig represents the YAML configuration structure for URL loading
-type URLConfig struct {
-	Items []ArticleItem `yaml:"items"`
-}
-
-// loadConfig loads configuration from YAML file
-func (ap *ArticleProcessor) loadConfig(configPath string) (*URLConfig, error) {
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("reading config file: %w", err)
-	}
-
-	var config URLConfig
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, fmt.Errorf("parsing YAML configuration: %w", err)
-	}
-
-	// Validate configuration structure
-	if err := ap.validateConfig(&config, configPath); err != nil {
-		return nil, err
-	}
-
-	return &config, nil
-}
-
-// validateConfig validates the loaded configuration structure
-func (ap *ArticleProcessor) validateConfig(config *URLConfig, configPath string) error {
-	if len(config.Items) == 0 {
-		return fmt.Errorf("configuration is wrong. Example:\nitems:\n  - url: \"https://example.com/article1\"")
-	}
-
-	// Validate each item has a URL
-	for i, item := range config.Items {
-		url := strings.TrimSpace(item.URL)
-		if url == "" {
-			return fmt.Errorf("item %d has empty URL", i+1)
-		}
-		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-			return fmt.Errorf("item %d has invalid URL: %s", i+1, url)
-		}
-	}
-
-	return nil
-}
-
-// loadURLsFromFile loads URLs from YAML file
-func (p *ArticleProcessor) loadURLsFromFile(configPath string) ([]string, error) {
-	config, err := p.loadConfig(configPath)
-	if err != nil {
-		return nil, err
-	}
-
-	var urls []string
-	for _, item := range config.Items {
-		urls = append(urls, item.URL)
-	}
-
-	return urls, nil
-}
-
-// generateArticle creates an article using the AgentManager
-func (p *ArticleProcessor) generateArticle(url string, content *ContentResult, metadata *FrontmatterMetadata) (*Article, error) {
-	// Use AgentManager to write the article with configured prompts
-	articleContent, err := p.agents.Write(content, metadata)
-	if err != nil {
-		return nil, fmt.Errorf("AI generation failed: %w", err)
-	}
-
-	// Get model info from agents
-	plannerModel, writerModel := p.agents.GetModelInfo()
-
-	// Extract domain from URL
-	sourceDomain := p.extractDomain(url)
-
-	return &Article{
-		Title:        metadata.Title,
-		SourceURL:    url,
-		SourceDomain: sourceDomain,
-		Content:      articleContent,
-		CreatedAt:    time.Now(),
-		Draft:        false,
-		Categories:   metadata.Categories,
-		Tags:         metadata.Tags,
-		PlannerModel: plannerModel,
-		WriterModel:  writerModel,
-		Deck:         metadata.Deck,
-	}, nil
-}
-
-// extractTitle extracts the first # heading from markdown content
-func (p *ArticleProcessor) extractTitle(content string) string {
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(strings.TrimSpace(line), "# ") {
-			return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
-		}
-	}
-	return ""
-}
-
-// extractDomain extracts the domain from a URL
-func (p *ArticleProcessor) extractDomain(rawURL string) string {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return ""
-	}
-	return parsedURL.Host
-}
-
-// generateFilename creates a hash-based filename with year/month subdirectories
-func (p *ArticleProcessor) generateFilename(url, title string) string {
-	slug := p.generateSlug(title)
-	hash := p.generateURLHash(url)
-
-	// Create year/month subdirectories
-	now := time.Now()
-	year := now.Format("2006")
-	month := now.Format("01")
-	outputDir := filepath.Join(p.config.Settings.OutputDirectory, year, month)
-
-	// Ensure output directory exists
-	os.MkdirAll(outputDir, 0755)
-
-	return filepath.Join(outputDir, fmt.Sprintf("%s-%s.md", slug, hash))
-}
-
-// generateSlug creates a URL-safe slug from title
-func (p *ArticleProcessor) generateSlug(title string) string {
-	// Convert to lowercase and replace spaces/special chars with hyphens
-	slug := strings.ToLower(title)
-	slug = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(slug, "-")
-	slug = strings.Trim(slug, "-")
-
-	// Limit length
-	if len(slug) > 50 {
-		slug = slug[:50]
-	}
-
-	return slug
-}
-
-// generateURLHash creates a short hash of the URL
-func (p *ArticleProcessor) generateURLHash(url string) string {
-	hash := sha256.Sum256([]byte(url))
-	return fmt.Sprintf("%x", hash)[:8]
-}
-
-// findExistingFile finds an existing article file by URL (recursively)
-func (p *ArticleProcessor) findExistingFile(url string) string {
-	outputDir := p.config.Settings.OutputDirectory
-	urlHash := p.generateURLHash(url)
-	suffix := fmt.Sprintf("-%s.md", urlHash)
-
-	var existingFile string
-
-	// Walk the directory tree
-	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && strings.HasSuffix(path, suffix) {
-			existingFile = path
-			return filepath.SkipDir // Stop searching once found
-		}
-		return nil
-	})
-
-	if err != nil {
-		log.Printf("Error walking directory: %v", err)
-	}
-
-	return existingFile
-}
-
-// saveArticle saves the article to a file
-func (p *ArticleProcessor) saveArticle(filename string, article *Article) error {
-	// Ensure directory exists
-	dir := filepath.Dir(filename)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
-	}
-
-	// Create file
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
-	}
-	defer file.Close()
-
-	// Template with full frontmatter
-	tmplStr := `---
-title: "{{.Title}}"
-date: {{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}
-draft: {{.Draft}}
-categories: [{{range $i, $cat := .Categories}}{{if $i}}, {{end}}"{{$cat}}"{{end}}]
-tags: [{{range $i, $tag := .Tags}}{{if $i}}, {{end}}"{{$tag}}"{{end}}]
-planner_model: "{{.PlannerModel}}"
-writer_model: "{{.WriterModel}}"
-deck: "{{.Deck}}"
-source_url: "{{.SourceURL}}"
-source_domain: "{{.SourceDomain}}"
----
-
-{{.Content}}`
-
-	tmpl, err := template.New("article").Parse(tmplStr)
-	if err != nil {
-		return fmt.Errorf("parsing template: %w", err)
-	}
-
-	return tmpl.Execute(file, article)
-}